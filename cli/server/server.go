@@ -267,7 +267,10 @@ func startServer(ctx *cli.Context) error {
 	}
 
 	configureAddresses(cfg.ApplicationConfiguration)
-	server := network.NewServer(serverConfig, chain)
+	server, err := network.NewServer(serverConfig, chain)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("failed to create network server: %v", err), 1)
+	}
 	rpcServer := rpc.NewServer(chain, cfg.ApplicationConfiguration.RPC, server)
 	errChan := make(chan error)
 	monitoring := metrics.NewMetricsService(cfg.ApplicationConfiguration.Monitoring)
@@ -73,19 +73,53 @@ type (
 		Relay             bool                     `yaml:"Relay"`
 		DialTimeout       time.Duration            `yaml:"DialTimeout"`
 		ProtoTickInterval time.Duration            `yaml:"ProtoTickInterval"`
+		PingInterval      time.Duration            `yaml:"PingInterval"`
 		MaxPeers          int                      `yaml:"MaxPeers"`
 		AttemptConnPeers  int                      `yaml:"AttemptConnPeers"`
 		MinPeers          int                      `yaml:"MinPeers"`
+		AddressBookPath   string                   `yaml:"AddressBookPath"`
+		AddrMaxAge        time.Duration            `yaml:"AddrMaxAge"`
 		Monitoring        metrics.PrometheusConfig `yaml:"Monitoring"`
 		RPC               RPCConfig                `yaml:"RPC"`
+		UnlockWallet      *WalletConfig            `yaml:"UnlockWallet"`
 	}
 
 	// RPCConfig is an RPC service configuration information (to be moved to the rpc package, see #423).
 	RPCConfig struct {
-		Enabled              bool   `yaml:"Enabled"`
-		EnableCORSWorkaround bool   `yaml:"EnableCORSWorkaround"`
-		Address              string `yaml:"Address"`
-		Port                 uint16 `yaml:"Port"`
+		Enabled              bool         `yaml:"Enabled"`
+		EnableCORSWorkaround bool         `yaml:"EnableCORSWorkaround"`
+		Address              string       `yaml:"Address"`
+		MaxBatchConcurrency  int          `yaml:"MaxBatchConcurrency"`
+		MaxGasInvoke         util.Fixed8  `yaml:"MaxGasInvoke"`
+		Port                 uint16       `yaml:"Port"`
+		TLSConfig            RPCTLSConfig `yaml:"TLSConfig"`
+		BasicAuth            RPCBasicAuth `yaml:"BasicAuth"`
+		EnabledMethods       []string     `yaml:"EnabledMethods"`
+		DisabledMethods      []string     `yaml:"DisabledMethods"`
+		RateLimit            int          `yaml:"RateLimit"`
+		MaxRequestBodyBytes  int64        `yaml:"MaxRequestBodyBytes"`
+	}
+
+	// RPCTLSConfig describes TLS settings of the RPC server, when it's
+	// enabled the server only accepts HTTPS connections.
+	RPCTLSConfig struct {
+		Enabled  bool   `yaml:"Enabled"`
+		CertFile string `yaml:"CertFile"`
+		KeyFile  string `yaml:"KeyFile"`
+	}
+
+	// RPCBasicAuth holds HTTP basic authentication credentials for the RPC
+	// server, authentication is not required if User is empty.
+	RPCBasicAuth struct {
+		User     string `yaml:"User"`
+		Password string `yaml:"Password"`
+	}
+
+	// WalletConfig is a wallet info used to unlock the node's own wallet,
+	// it's needed for the node to participate in consensus.
+	WalletConfig struct {
+		Path     string `yaml:"Path"`
+		Password string `yaml:"Password"`
 	}
 
 	// NetMode describes the mode the blockchain will operate on.
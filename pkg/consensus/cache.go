@@ -0,0 +1,51 @@
+package consensus
+
+import (
+	"sync"
+
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// relayCache holds the consensus payloads known to the node, they're
+// served to other nodes requesting them.
+type relayCache struct {
+	lock     sync.RWMutex
+	payloads map[util.Uint256]*Payload
+}
+
+func newRelayCache() *relayCache {
+	return &relayCache{
+		payloads: make(map[util.Uint256]*Payload),
+	}
+}
+
+// Add adds the payload to the cache.
+func (c *relayCache) Add(p *Payload) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.payloads[p.Hash()] = p
+}
+
+// Get returns the payload with the given hash or nil if it's not cached.
+func (c *relayCache) Get(h util.Uint256) *Payload {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.payloads[h]
+}
+
+// Has returns true if the payload with the given hash is cached.
+func (c *relayCache) Has(h util.Uint256) bool {
+	return c.Get(h) != nil
+}
+
+// RemoveBelow removes all payloads for blocks with index lower than the
+// given one.
+func (c *relayCache) RemoveBelow(index uint32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for h, p := range c.payloads {
+		if p.BlockIndex < index {
+			delete(c.payloads, h)
+		}
+	}
+}
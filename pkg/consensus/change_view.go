@@ -0,0 +1,24 @@
+package consensus
+
+import "github.com/infinitete/neo-go-inf/pkg/io"
+
+// changeView is a request of the validator to move to the next view, its
+// new view number is always the view number of the message plus one.
+type changeView struct {
+	Timestamp uint32
+}
+
+// EncodeBinary implements Serializable interface.
+func (c *changeView) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(c.Timestamp)
+}
+
+// DecodeBinary implements Serializable interface.
+func (c *changeView) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&c.Timestamp)
+}
+
+// newViewNumber returns the view number requested by the ChangeView message.
+func (m *message) newViewNumber() byte {
+	return m.ViewNumber + 1
+}
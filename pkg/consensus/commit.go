@@ -0,0 +1,18 @@
+package consensus
+
+import "github.com/infinitete/neo-go-inf/pkg/io"
+
+// commit carries the validator's signature of the proposed block.
+type commit struct {
+	Signature [signatureSize]byte
+}
+
+// EncodeBinary implements Serializable interface.
+func (c *commit) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(c.Signature)
+}
+
+// DecodeBinary implements Serializable interface.
+func (c *commit) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&c.Signature)
+}
@@ -0,0 +1,1070 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/infinitete/neo-go-inf/config"
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/wallet"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// queueSize is the size of the queues of incoming payloads,
+	// transactions and blocks.
+	queueSize = 100
+
+	// defaultTimePerBlock is used when the block time is not configured.
+	defaultTimePerBlock = 15 * time.Second
+
+	// maxTimeoutShift limits the exponential growth of view timeouts.
+	maxTimeoutShift = 16
+)
+
+// Service represents a consensus instance.
+type Service interface {
+	// Start initializes dBFT and starts the event loop of the consensus
+	// service.
+	Start()
+	// Shutdown stops the event loop of the consensus service, it can't
+	// be started again after that.
+	Shutdown()
+	// OnPayload is a callback to notify Service about a new received
+	// payload.
+	OnPayload(p *Payload)
+	// OnTransaction is a callback to notify Service about a new received
+	// transaction.
+	OnTransaction(tx *transaction.Transaction)
+	// OnNewBlock is a callback to notify Service about a new block added
+	// to the chain.
+	OnNewBlock(b *core.Block)
+	// GetPayload returns the Payload with the given hash if it's present
+	// in the local cache.
+	GetPayload(h util.Uint256) *Payload
+}
+
+// Config is a configuration for the consensus service.
+type Config struct {
+	// Broadcast is a callback which is called to notify the server about
+	// a new consensus payload to send.
+	Broadcast func(p *Payload)
+	// RelayBlock is a callback which is called to notify the server about
+	// a new block that needs to be broadcasted.
+	RelayBlock func(b *core.Block)
+	// RequestTx is a callback which is called when the node lacks
+	// transactions present in the block proposal.
+	RequestTx func(h ...util.Uint256)
+	// Chain is a core.Blockchainer instance.
+	Chain core.Blockchainer
+	// TimePerBlock is the expected time between blocks.
+	TimePerBlock time.Duration
+	// Wallet is the local node's wallet configuration.
+	Wallet *config.WalletConfig
+}
+
+// service is the dBFT 2.0 implementation of the Service.
+type service struct {
+	Config
+
+	ctx         consensusContext
+	privateKeys []*keys.PrivateKey
+	cache       *relayCache
+
+	payloads     chan *Payload
+	transactions chan *transaction.Transaction
+	blocks       chan *core.Block
+
+	timer       *time.Timer
+	timerHeight uint32
+	timerView   byte
+	timerStart  time.Time
+	timerDelay  time.Duration
+
+	// blockReceived is the time the last block was received.
+	blockReceived time.Time
+	// recovering is set while the RecoveryMessage is being processed.
+	recovering bool
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	// quit is closed on Shutdown, finished is closed when the event
+	// loop exits.
+	quit     chan struct{}
+	finished chan struct{}
+}
+
+// NewService returns a new consensus.Service instance.
+func NewService(cfg Config) (Service, error) {
+	s := newService(cfg)
+	if cfg.Wallet != nil {
+		privs, err := getKeysFromWallet(cfg.Wallet)
+		if err != nil {
+			return nil, err
+		}
+		s.privateKeys = privs
+	}
+	return s, nil
+}
+
+func newService(cfg Config) *service {
+	if cfg.TimePerBlock <= 0 {
+		cfg.TimePerBlock = defaultTimePerBlock
+	}
+
+	s := &service{
+		Config:       cfg,
+		cache:        newRelayCache(),
+		payloads:     make(chan *Payload, queueSize),
+		transactions: make(chan *transaction.Transaction, queueSize),
+		blocks:       make(chan *core.Block, queueSize),
+		timer:        time.NewTimer(time.Hour),
+		quit:         make(chan struct{}),
+		finished:     make(chan struct{}),
+	}
+	s.ctx.MyIndex = -1
+	s.timer.Stop()
+	return s
+}
+
+// getKeysFromWallet returns private keys of all the accounts from the given
+// wallet.
+func getKeysFromWallet(cfg *config.WalletConfig) ([]*keys.PrivateKey, error) {
+	w, err := wallet.NewWalletFromFile(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+
+	var privs []*keys.PrivateKey
+	for _, acc := range w.Accounts {
+		decrypted, err := wallet.DecryptAccount(acc.EncryptedWIF, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("can't decrypt account %s: %s", acc.Address, err)
+		}
+		privs = append(privs, decrypted.PrivateKey())
+	}
+	if len(privs) == 0 {
+		return nil, errors.New("no accounts found in the wallet")
+	}
+	return privs, nil
+}
+
+// Start implements Service interface.
+func (s *service) Start() {
+	s.startOnce.Do(func() {
+		s.blockReceived = time.Now()
+		s.initializeConsensus(0)
+		if s.ctx.WatchOnly() {
+			log.Info("consensus: node is not a validator, running in watch-only mode")
+		} else {
+			s.sendRecoveryRequest()
+		}
+		go s.eventLoop()
+	})
+}
+
+// Shutdown implements Service interface.
+func (s *service) Shutdown() {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+	})
+}
+
+// OnPayload implements Service interface.
+func (s *service) OnPayload(p *Payload) {
+	if s.cache.Has(p.Hash()) {
+		return
+	}
+	select {
+	case s.payloads <- p:
+	case <-s.quit:
+	}
+}
+
+// OnTransaction implements Service interface.
+func (s *service) OnTransaction(tx *transaction.Transaction) {
+	select {
+	case s.transactions <- tx:
+	case <-s.quit:
+	}
+}
+
+// OnNewBlock implements Service interface.
+func (s *service) OnNewBlock(b *core.Block) {
+	select {
+	case s.blocks <- b:
+	case <-s.quit:
+	}
+}
+
+// GetPayload implements Service interface.
+func (s *service) GetPayload(h util.Uint256) *Payload {
+	return s.cache.Get(h)
+}
+
+func (s *service) eventLoop() {
+	defer close(s.finished)
+	for {
+		select {
+		case <-s.quit:
+			s.timer.Stop()
+			return
+		case <-s.timer.C:
+			s.onTimeout()
+		case p := <-s.payloads:
+			s.onPayload(p)
+		case tx := <-s.transactions:
+			s.onTransaction(tx)
+		case <-s.blocks:
+			s.checkChain()
+		}
+	}
+}
+
+// checkChain starts the new round if the chain already has the block the
+// consensus is held for.
+func (s *service) checkChain() bool {
+	height := s.Chain.BlockHeight()
+	if height < s.ctx.BlockIndex {
+		return false
+	}
+	s.blockReceived = time.Now()
+	s.cache.RemoveBelow(height + 1)
+	s.initializeConsensus(0)
+	return true
+}
+
+// getValidators returns the sorted list of validators for the next block.
+func (s *service) getValidators() ([]*keys.PublicKey, error) {
+	validators, err := s.Chain.GetValidators()
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, errors.New("no validators")
+	}
+	return sortValidators(validators), nil
+}
+
+func (s *service) initializeConsensus(view byte) {
+	var validators []*keys.PublicKey
+	if view == 0 {
+		var err error
+		validators, err = s.getValidators()
+		if err != nil {
+			log.Errorf("consensus: can't get validators: %s", err)
+			return
+		}
+	}
+	s.ctx.reset(s.Chain, validators, s.privateKeys, view)
+
+	log.WithFields(log.Fields{
+		"height":  s.ctx.BlockIndex,
+		"view":    view,
+		"index":   s.ctx.MyIndex,
+		"primary": s.ctx.PrimaryIndex,
+	}).Info("consensus: initialized")
+
+	if s.ctx.WatchOnly() {
+		return
+	}
+	if s.ctx.IsPrimary() && !s.recovering {
+		s.changeTimer(s.TimePerBlock - time.Since(s.blockReceived))
+	} else {
+		s.changeTimer(s.timeoutForView(view))
+	}
+}
+
+// timeoutForView returns the timeout of the given view for backup nodes.
+func (s *service) timeoutForView(view byte) time.Duration {
+	shift := uint(view) + 1
+	if shift > maxTimeoutShift {
+		shift = maxTimeoutShift
+	}
+	return s.TimePerBlock << shift
+}
+
+// changeTimer resets the timer of the current round to the given delay.
+func (s *service) changeTimer(d time.Duration) {
+	if !s.timer.Stop() {
+		select {
+		case <-s.timer.C:
+		default:
+		}
+	}
+	if d < 0 {
+		d = 0
+	}
+	s.timerHeight = s.ctx.BlockIndex
+	s.timerView = s.ctx.ViewNumber
+	s.timerStart = time.Now()
+	s.timerDelay = d
+	s.timer.Reset(d)
+}
+
+// extendTimer prolongs the timer by the given factor of the block time
+// divided by M.
+func (s *service) extendTimer(factor int) {
+	if s.ctx.WatchOnly() || s.ctx.ViewChanging() || s.ctx.CommitSent() {
+		return
+	}
+	delay := s.timerDelay - time.Since(s.timerStart) +
+		time.Duration(factor)*s.TimePerBlock/time.Duration(s.ctx.M())
+	if delay > 0 {
+		s.changeTimer(delay)
+	}
+}
+
+func (s *service) onTimeout() {
+	if s.checkChain() {
+		return
+	}
+	if s.ctx.WatchOnly() || s.ctx.BlockSent() {
+		return
+	}
+	if s.timerHeight != s.ctx.BlockIndex || s.timerView != s.ctx.ViewNumber {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"height": s.ctx.BlockIndex,
+		"view":   s.ctx.ViewNumber,
+	}).Debug("consensus: timeout")
+
+	if s.ctx.IsPrimary() && !s.ctx.RequestSentOrReceived() {
+		s.sendPrepareRequest()
+	} else if s.ctx.CommitSent() {
+		// Re-send the commit in case of a network issue.
+		s.broadcast(s.makeRecoveryMessage())
+		s.changeTimer(s.TimePerBlock << 1)
+	} else {
+		s.requestChangeView()
+	}
+}
+
+// makePayload creates the signed payload with the given message.
+func (s *service) makePayload(m *message) *Payload {
+	p := &Payload{
+		PrevHash:       s.ctx.PrevHash,
+		BlockIndex:     s.ctx.BlockIndex,
+		ValidatorIndex: uint16(s.ctx.MyIndex),
+	}
+	p.setMessage(m)
+	if err := p.Sign(s.ctx.PrivateKey); err != nil {
+		log.Errorf("consensus: can't sign payload: %s", err)
+		return nil
+	}
+	return p
+}
+
+// broadcast caches the payload and sends it to other nodes.
+func (s *service) broadcast(p *Payload) {
+	if p == nil {
+		return
+	}
+	s.cache.Add(p)
+	s.Broadcast(p)
+}
+
+func (s *service) sendPrepareRequest() {
+	if err := s.fillProposal(); err != nil {
+		log.Errorf("consensus: can't create block proposal: %s", err)
+		return
+	}
+	msg := &message{
+		Type:       prepareRequestType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload: &prepareRequest{
+			Timestamp:         s.ctx.Timestamp,
+			Nonce:             s.ctx.Nonce,
+			NextConsensus:     s.ctx.NextConsensus,
+			TransactionHashes: s.ctx.TransactionHashes,
+			MinerTransaction:  *s.ctx.Transactions[s.ctx.TransactionHashes[0]],
+		},
+	}
+	p := s.makePayload(msg)
+	if p == nil {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"height": s.ctx.BlockIndex,
+		"view":   s.ctx.ViewNumber,
+		"txs":    len(s.ctx.TransactionHashes),
+	}).Info("consensus: sending PrepareRequest")
+
+	s.ctx.PreparationPayloads[s.ctx.MyIndex] = p
+	s.broadcast(p)
+	s.changeTimer(s.timeoutForView(s.ctx.ViewNumber))
+}
+
+// fillProposal creates the block proposal from the verified memory pool
+// transactions.
+func (s *service) fillProposal() error {
+	prevHeader, err := s.Chain.GetHeader(s.ctx.PrevHash)
+	if err != nil {
+		return err
+	}
+
+	pool := s.Chain.GetMemPool()
+	txs := pool.GetVerifiedTransactions()
+	if max := s.Chain.GetConfig().MaxTransactionsPerBlock; max > 0 && int64(len(txs)) > max-1 {
+		txs = txs[:max-1]
+	}
+
+	var netFee util.Fixed8
+	for _, tx := range txs {
+		netFee += s.Chain.NetworkFee(tx)
+	}
+
+	validators, err := s.Chain.GetValidators(txs...)
+	if err != nil {
+		return err
+	}
+	nextConsensus, err := getNextConsensus(validators)
+	if err != nil {
+		return err
+	}
+
+	nonce := rand.Uint64()
+	miner, err := s.makeMinerTx(uint32(nonce), netFee)
+	if err != nil {
+		return err
+	}
+
+	s.ctx.Nonce = nonce
+	s.ctx.NextConsensus = nextConsensus
+	s.ctx.Timestamp = uint32(time.Now().Unix())
+	if s.ctx.Timestamp <= prevHeader.Timestamp {
+		s.ctx.Timestamp = prevHeader.Timestamp + 1
+	}
+	s.ctx.TransactionHashes = make([]util.Uint256, 0, len(txs)+1)
+	s.ctx.TransactionHashes = append(s.ctx.TransactionHashes, miner.Hash())
+	s.ctx.Transactions[miner.Hash()] = miner
+	for _, tx := range txs {
+		s.ctx.TransactionHashes = append(s.ctx.TransactionHashes, tx.Hash())
+		s.ctx.Transactions[tx.Hash()] = tx
+	}
+	s.ctx.header = nil
+	return nil
+}
+
+// makeMinerTx creates the miner transaction paying the network fee of the
+// block to the node.
+func (s *service) makeMinerTx(nonce uint32, netFee util.Fixed8) (*transaction.Transaction, error) {
+	tx := &transaction.Transaction{
+		Type:       transaction.MinerType,
+		Data:       &transaction.MinerTX{Nonce: nonce},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{},
+		Scripts:    []*transaction.Witness{},
+	}
+	if netFee > 0 {
+		script, err := smartcontract.CreateSignatureRedeemScript(s.ctx.PrivateKey.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		tx.AddOutput(transaction.NewOutput(core.UtilityTokenID(), netFee, hash.Hash160(script)))
+	}
+	return tx, nil
+}
+
+// verifyMinerTx checks that the miner transaction of the proposal pays
+// exactly the network fee of the block.
+func (s *service) verifyMinerTx() error {
+	miner := s.ctx.Transactions[s.ctx.TransactionHashes[0]]
+	if miner.Type != transaction.MinerType {
+		return fmt.Errorf("the first transaction is %s", miner.Type)
+	}
+
+	var netFee util.Fixed8
+	for _, h := range s.ctx.TransactionHashes[1:] {
+		netFee += s.Chain.NetworkFee(s.ctx.Transactions[h])
+	}
+	if netFee < 0 {
+		netFee = 0
+	}
+
+	var amount util.Fixed8
+	utilityTokenID := core.UtilityTokenID()
+	for _, out := range miner.Outputs {
+		if !out.AssetID.Equals(utilityTokenID) {
+			return errors.New("miner transaction pays non-utility token")
+		}
+		amount += out.Amount
+	}
+	if amount != netFee {
+		return fmt.Errorf("miner transaction pays %s instead of %s", amount, netFee)
+	}
+	return nil
+}
+
+// verifyNextConsensus checks that NextConsensus of the proposal matches the
+// validators calculated with the proposed transactions.
+func (s *service) verifyNextConsensus() error {
+	txs := make([]*transaction.Transaction, 0, len(s.ctx.TransactionHashes))
+	for _, h := range s.ctx.TransactionHashes {
+		txs = append(txs, s.ctx.Transactions[h])
+	}
+	validators, err := s.Chain.GetValidators(txs...)
+	if err != nil {
+		return err
+	}
+	expected, err := getNextConsensus(validators)
+	if err != nil {
+		return err
+	}
+	if !expected.Equals(s.ctx.NextConsensus) {
+		return fmt.Errorf("got %s, expected %s", s.ctx.NextConsensus, expected)
+	}
+	return nil
+}
+
+func (s *service) sendPrepareResponse() {
+	msg := &message{
+		Type:       prepareResponseType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload: &prepareResponse{
+			PreparationHash: s.ctx.PreparationPayloads[s.ctx.PrimaryIndex].Hash(),
+		},
+	}
+	p := s.makePayload(msg)
+	if p == nil {
+		return
+	}
+	s.ctx.PreparationPayloads[s.ctx.MyIndex] = p
+	s.broadcast(p)
+}
+
+func (s *service) makeCommit() *Payload {
+	if p := s.ctx.CommitPayloads[s.ctx.MyIndex]; p != nil {
+		return p
+	}
+	data, err := s.ctx.makeHeader().GetHashableData()
+	if err != nil {
+		log.Errorf("consensus: can't get block data: %s", err)
+		return nil
+	}
+	sig, err := s.ctx.PrivateKey.Sign(data)
+	if err != nil {
+		log.Errorf("consensus: can't sign block: %s", err)
+		return nil
+	}
+	c := new(commit)
+	copy(c.Signature[:], sig)
+	p := s.makePayload(&message{
+		Type:       commitType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload:    c,
+	})
+	s.ctx.CommitPayloads[s.ctx.MyIndex] = p
+	return p
+}
+
+func (s *service) makeChangeView() *Payload {
+	p := s.makePayload(&message{
+		Type:       changeViewType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload:    &changeView{Timestamp: uint32(time.Now().Unix())},
+	})
+	s.ctx.ChangeViewPayloads[s.ctx.MyIndex] = p
+	return p
+}
+
+func (s *service) sendRecoveryRequest() {
+	s.broadcast(s.makePayload(&message{
+		Type:       recoveryRequestType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload:    &recoveryRequest{Timestamp: uint32(time.Now().Unix())},
+	}))
+}
+
+func (s *service) makeRecoveryMessage() *Payload {
+	m := new(recoveryMessage)
+	for _, p := range s.ctx.LastChangeViewPayloads {
+		if p == nil || len(m.ChangeViewPayloads) == s.ctx.M() {
+			continue
+		}
+		m.ChangeViewPayloads = append(m.ChangeViewPayloads, changeViewCompact{
+			ValidatorIndex:     p.ValidatorIndex,
+			OriginalViewNumber: p.message.ViewNumber,
+			Timestamp:          p.message.changeView().Timestamp,
+			InvocationScript:   p.Witness.InvocationScript,
+		})
+	}
+
+	if s.ctx.RequestSentOrReceived() {
+		m.PrepareRequest = s.ctx.PreparationPayloads[s.ctx.PrimaryIndex].message
+	} else {
+		// Use the most popular preparation hash of the responses received.
+		var (
+			counts = make(map[util.Uint256]int)
+			best   int
+		)
+		for _, p := range s.ctx.PreparationPayloads {
+			if p == nil {
+				continue
+			}
+			h := p.message.prepareResponse().PreparationHash
+			counts[h]++
+			if counts[h] > best {
+				best = counts[h]
+				m.PreparationHash = &h
+			}
+		}
+	}
+
+	for _, p := range s.ctx.PreparationPayloads {
+		if p != nil {
+			m.PreparationPayloads = append(m.PreparationPayloads, preparationCompact{
+				ValidatorIndex:   p.ValidatorIndex,
+				InvocationScript: p.Witness.InvocationScript,
+			})
+		}
+	}
+
+	if s.ctx.CommitSent() {
+		for _, p := range s.ctx.CommitPayloads {
+			if p != nil {
+				m.CommitPayloads = append(m.CommitPayloads, commitCompact{
+					ViewNumber:       p.message.ViewNumber,
+					ValidatorIndex:   p.ValidatorIndex,
+					Signature:        p.message.commit().Signature,
+					InvocationScript: p.Witness.InvocationScript,
+				})
+			}
+		}
+	}
+
+	return s.makePayload(&message{
+		Type:       recoveryMessageType,
+		ViewNumber: s.ctx.ViewNumber,
+		payload:    m,
+	})
+}
+
+// verifyPayload checks that the payload belongs to the current round and
+// is properly signed.
+func (s *service) verifyPayload(p *Payload) bool {
+	if p.BlockIndex != s.ctx.BlockIndex || !p.PrevHash.Equals(s.ctx.PrevHash) {
+		return false
+	}
+	if int(p.ValidatorIndex) >= s.ctx.N() {
+		return false
+	}
+	if !p.Verify(s.ctx.Validators[p.ValidatorIndex]) {
+		return false
+	}
+	_, err := p.decodeData()
+	return err == nil
+}
+
+// onPayload processes the payload received from the network.
+func (s *service) onPayload(p *Payload) {
+	if s.cache.Has(p.Hash()) || !s.verifyPayload(p) {
+		return
+	}
+	s.cache.Add(p)
+	s.Broadcast(p)
+	s.handlePayload(p)
+}
+
+// reverifyAndProcess processes the payload restored from the RecoveryMessage.
+func (s *service) reverifyAndProcess(p *Payload) {
+	if s.verifyPayload(p) {
+		s.handlePayload(p)
+	}
+}
+
+func (s *service) handlePayload(p *Payload) {
+	if s.ctx.BlockSent() {
+		return
+	}
+	msg := p.message
+	s.ctx.LastSeenMessage[p.ValidatorIndex] = int64(p.BlockIndex)
+
+	log.WithFields(log.Fields{
+		"type":      msg.Type,
+		"validator": p.ValidatorIndex,
+		"view":      msg.ViewNumber,
+	}).Debug("consensus: received payload")
+
+	switch msg.Type {
+	case changeViewType:
+		s.onChangeView(p, msg)
+	case commitType:
+		s.onCommit(p, msg)
+	case recoveryRequestType:
+		s.onRecoveryRequest(p)
+	case recoveryMessageType:
+		s.onRecoveryMessage(p, msg)
+	default:
+		if msg.ViewNumber != s.ctx.ViewNumber {
+			return
+		}
+		switch msg.Type {
+		case prepareRequestType:
+			s.onPrepareRequest(p, msg)
+		case prepareResponseType:
+			s.onPrepareResponse(p, msg)
+		}
+	}
+}
+
+func (s *service) onPrepareRequest(p *Payload, msg *message) {
+	if s.ctx.RequestSentOrReceived() || s.ctx.NotAcceptingPayloadsDueToViewChanging() {
+		return
+	}
+	if int(p.ValidatorIndex) != s.ctx.PrimaryIndex {
+		return
+	}
+
+	req := msg.prepareRequest()
+	prevHeader, err := s.Chain.GetHeader(s.ctx.PrevHash)
+	if err != nil {
+		return
+	}
+	maxTimestamp := time.Now().Add(8 * s.TimePerBlock).Unix()
+	if req.Timestamp <= prevHeader.Timestamp || int64(req.Timestamp) > maxTimestamp {
+		log.WithField("timestamp", req.Timestamp).Warn("consensus: invalid PrepareRequest timestamp")
+		return
+	}
+	pool := s.Chain.GetMemPool()
+	for _, h := range req.TransactionHashes {
+		if s.Chain.HasTransaction(h) && !pool.ContainsKey(h) {
+			log.WithField("hash", h.ReverseString()).Warn("consensus: PrepareRequest contains persisted transaction")
+			return
+		}
+	}
+
+	s.extendTimer(2)
+
+	s.ctx.Timestamp = req.Timestamp
+	s.ctx.Nonce = req.Nonce
+	s.ctx.NextConsensus = req.NextConsensus
+	s.ctx.TransactionHashes = req.TransactionHashes
+	s.ctx.Transactions = make(map[util.Uint256]*transaction.Transaction)
+	s.ctx.header = nil
+	s.ctx.PreparationPayloads[p.ValidatorIndex] = p
+
+	for i, pp := range s.ctx.PreparationPayloads {
+		if pp != nil && pp.message.Type == prepareResponseType &&
+			!pp.message.prepareResponse().PreparationHash.Equals(p.Hash()) {
+			s.ctx.PreparationPayloads[i] = nil
+		}
+	}
+	for i, cp := range s.ctx.CommitPayloads {
+		if cp != nil && cp.message.ViewNumber == s.ctx.ViewNumber && !s.verifyCommit(cp) {
+			s.ctx.CommitPayloads[i] = nil
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"height": s.ctx.BlockIndex,
+		"view":   s.ctx.ViewNumber,
+		"txs":    len(req.TransactionHashes),
+	}).Info("consensus: received PrepareRequest")
+
+	if !s.addTransaction(&req.MinerTransaction, false) {
+		return
+	}
+
+	var missing []util.Uint256
+	for _, h := range req.TransactionHashes[1:] {
+		if tx, ok := pool.TryGetValue(h); ok {
+			if !s.addTransaction(tx, false) {
+				return
+			}
+		} else {
+			missing = append(missing, h)
+		}
+	}
+	if len(missing) > 0 {
+		s.RequestTx(missing...)
+	}
+}
+
+// addTransaction adds the transaction of the proposal to the context. The
+// PrepareResponse is sent once all the transactions are received.
+func (s *service) addTransaction(tx *transaction.Transaction, verify bool) bool {
+	if verify {
+		if err := s.Chain.VerifyTx(tx, nil); err != nil {
+			log.WithField("hash", tx.Hash().ReverseString()).Warnf("consensus: invalid transaction: %s", err)
+			s.requestChangeView()
+			return false
+		}
+	}
+	s.ctx.Transactions[tx.Hash()] = tx
+	if !s.ctx.hasAllTransactions() {
+		return true
+	}
+
+	if err := s.verifyMinerTx(); err != nil {
+		log.Warnf("consensus: invalid miner transaction: %s", err)
+		s.requestChangeView()
+		return false
+	}
+	if err := s.verifyNextConsensus(); err != nil {
+		log.Warnf("consensus: invalid NextConsensus: %s", err)
+		s.requestChangeView()
+		return false
+	}
+	if s.ctx.IsBackup() && !s.ctx.ResponseSent() {
+		s.extendTimer(2)
+		s.sendPrepareResponse()
+		s.checkPreparations()
+	}
+	return true
+}
+
+func (s *service) onPrepareResponse(p *Payload, msg *message) {
+	if int(p.ValidatorIndex) == s.ctx.PrimaryIndex || s.ctx.PreparationPayloads[p.ValidatorIndex] != nil {
+		return
+	}
+	if s.ctx.NotAcceptingPayloadsDueToViewChanging() {
+		return
+	}
+	req := s.ctx.PreparationPayloads[s.ctx.PrimaryIndex]
+	if req != nil && !msg.prepareResponse().PreparationHash.Equals(req.Hash()) {
+		return
+	}
+
+	s.extendTimer(2)
+	s.ctx.PreparationPayloads[p.ValidatorIndex] = p
+	if s.ctx.WatchOnly() || s.ctx.CommitSent() {
+		return
+	}
+	if s.ctx.RequestSentOrReceived() {
+		s.checkPreparations()
+	}
+}
+
+func (s *service) checkPreparations() {
+	if s.ctx.WatchOnly() || countPayloads(s.ctx.PreparationPayloads) < s.ctx.M() || !s.ctx.hasAllTransactions() {
+		return
+	}
+	p := s.makeCommit()
+	if p == nil {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"height": s.ctx.BlockIndex,
+		"view":   s.ctx.ViewNumber,
+	}).Info("consensus: sending Commit")
+
+	s.broadcast(p)
+	// The commit is re-sent on timeout in case of a network issue.
+	s.changeTimer(s.TimePerBlock)
+	s.checkCommits()
+}
+
+// verifyCommit checks the block signature of the Commit payload.
+func (s *service) verifyCommit(p *Payload) bool {
+	header := s.ctx.makeHeader()
+	if header == nil {
+		return false
+	}
+	data, err := header.GetHashableData()
+	if err != nil {
+		return false
+	}
+	sig := p.message.commit().Signature
+	return s.ctx.Validators[p.ValidatorIndex].Verify(sig[:], hash.Sha256(data).Bytes())
+}
+
+func (s *service) onCommit(p *Payload, msg *message) {
+	if s.ctx.CommitPayloads[p.ValidatorIndex] != nil {
+		return
+	}
+	s.extendTimer(4)
+
+	if msg.ViewNumber != s.ctx.ViewNumber || s.ctx.makeHeader() == nil {
+		// Commits from other views and commits received before the
+		// proposal are kept to be checked later.
+		s.ctx.CommitPayloads[p.ValidatorIndex] = p
+		return
+	}
+	if s.verifyCommit(p) {
+		s.ctx.CommitPayloads[p.ValidatorIndex] = p
+		s.checkCommits()
+	}
+}
+
+func (s *service) checkCommits() {
+	var count int
+	for _, p := range s.ctx.CommitPayloads {
+		if p != nil && p.message.ViewNumber == s.ctx.ViewNumber {
+			count++
+		}
+	}
+	if count < s.ctx.M() || !s.ctx.hasAllTransactions() {
+		return
+	}
+
+	block := s.ctx.makeBlock()
+	if block == nil {
+		return
+	}
+	s.ctx.block = block
+
+	log.WithFields(log.Fields{
+		"height": block.Index,
+		"hash":   block.Hash().ReverseString(),
+		"txs":    len(block.Transactions),
+	}).Info("consensus: relaying block")
+
+	s.RelayBlock(block)
+}
+
+func (s *service) onChangeView(p *Payload, msg *message) {
+	newView := msg.newViewNumber()
+	if newView <= s.ctx.ViewNumber {
+		s.onRecoveryRequest(p)
+	}
+	if s.ctx.CommitSent() {
+		return
+	}
+	if cv := s.ctx.ChangeViewPayloads[p.ValidatorIndex]; cv != nil && newView <= cv.message.newViewNumber() {
+		return
+	}
+	s.ctx.ChangeViewPayloads[p.ValidatorIndex] = p
+	s.checkExpectedView(newView)
+}
+
+func (s *service) checkExpectedView(view byte) {
+	if s.ctx.ViewNumber >= view {
+		return
+	}
+	var count int
+	for _, p := range s.ctx.ChangeViewPayloads {
+		if p != nil && p.message.newViewNumber() >= view {
+			count++
+		}
+	}
+	if count < s.ctx.M() {
+		return
+	}
+	if !s.ctx.WatchOnly() {
+		cv := s.ctx.ChangeViewPayloads[s.ctx.MyIndex]
+		if cv == nil || cv.message.newViewNumber() < view {
+			s.broadcast(s.makeChangeView())
+		}
+	}
+	s.initializeConsensus(view)
+}
+
+func (s *service) requestChangeView() {
+	if s.ctx.WatchOnly() {
+		return
+	}
+	view := s.ctx.ViewNumber + 1
+	s.changeTimer(s.timeoutForView(view))
+
+	if s.ctx.MoreThanFNodesCommittedOrLost() {
+		log.Info("consensus: more than F nodes committed or lost, requesting recovery instead of view change")
+		s.sendRecoveryRequest()
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"height": s.ctx.BlockIndex,
+		"view":   view,
+	}).Info("consensus: requesting view change")
+
+	s.broadcast(s.makeChangeView())
+	s.checkExpectedView(view)
+}
+
+func (s *service) onRecoveryRequest(p *Payload) {
+	if s.ctx.WatchOnly() {
+		return
+	}
+	if !s.ctx.CommitSent() {
+		// Only F+1 validators following the sender answer the request.
+		var shouldSend bool
+		for i := 1; i <= s.ctx.F()+1; i++ {
+			if (int(p.ValidatorIndex)+i)%s.ctx.N() == s.ctx.MyIndex {
+				shouldSend = true
+				break
+			}
+		}
+		if !shouldSend {
+			return
+		}
+	}
+	s.broadcast(s.makeRecoveryMessage())
+}
+
+func (s *service) onRecoveryMessage(p *Payload, msg *message) {
+	rec := msg.recoveryMessage()
+	s.recovering = true
+	defer func() { s.recovering = false }()
+
+	if msg.ViewNumber > s.ctx.ViewNumber {
+		if s.ctx.CommitSent() {
+			return
+		}
+		for _, cp := range rec.changeViewPayloads(p, s.ctx.Validators) {
+			s.reverifyAndProcess(cp)
+		}
+	}
+
+	if msg.ViewNumber == s.ctx.ViewNumber && !s.ctx.NotAcceptingPayloadsDueToViewChanging() && !s.ctx.CommitSent() {
+		primary := uint16(s.ctx.PrimaryIndex)
+		if !s.ctx.RequestSentOrReceived() {
+			if req := rec.prepareRequestPayload(p, primary, s.ctx.Validators); req != nil {
+				s.reverifyAndProcess(req)
+			} else if s.ctx.IsPrimary() {
+				s.sendPrepareRequest()
+			}
+		}
+
+		prepHash := rec.PreparationHash
+		if req := s.ctx.PreparationPayloads[s.ctx.PrimaryIndex]; req != nil {
+			h := req.Hash()
+			prepHash = &h
+		}
+		if prepHash != nil {
+			for _, cp := range rec.prepareResponsePayloads(p, s.ctx.ViewNumber, primary, *prepHash, s.ctx.Validators) {
+				s.reverifyAndProcess(cp)
+			}
+		}
+	}
+
+	if msg.ViewNumber <= s.ctx.ViewNumber {
+		for _, cp := range rec.commitPayloads(p, s.ctx.Validators) {
+			s.reverifyAndProcess(cp)
+		}
+	}
+}
+
+func (s *service) onTransaction(tx *transaction.Transaction) {
+	if s.ctx.WatchOnly() || s.ctx.BlockSent() || !s.ctx.IsBackup() ||
+		s.ctx.NotAcceptingPayloadsDueToViewChanging() ||
+		!s.ctx.RequestSentOrReceived() || s.ctx.ResponseSent() {
+		return
+	}
+	h := tx.Hash()
+	if _, ok := s.ctx.Transactions[h]; ok {
+		return
+	}
+	for _, th := range s.ctx.TransactionHashes {
+		if th.Equals(h) {
+			// The transaction was verified before being added to
+			// the memory pool.
+			s.addTransaction(tx, false)
+			return
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package consensus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/infinitete/neo-go-inf/config"
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWIFs are the keys of the unit_testnet standby validators.
+var testWIFs = []string{
+	"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+	"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+	"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+	"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+}
+
+func newTestChain(t *testing.T, ctx context.Context) *core.Blockchain {
+	cfg, err := config.Load("../../config", config.ModeUnitTestNet)
+	require.NoError(t, err)
+	chain, err := core.NewBlockchain(storage.NewMemoryStore(), cfg.ProtocolConfiguration)
+	require.NoError(t, err)
+	go chain.Run(ctx)
+	return chain
+}
+
+// newTestServices creates the consensus services for the given number of
+// validators connected with each other directly.
+func newTestServices(t *testing.T, chain core.Blockchainer, n int) []*service {
+	var (
+		lock     sync.Mutex
+		services = make([]*service, n)
+	)
+
+	relayBlock := func(b *core.Block) {
+		lock.Lock()
+		defer lock.Unlock()
+		if b.Index <= chain.BlockHeight() {
+			return
+		}
+		if err := chain.AddBlock(b); err != nil {
+			t.Errorf("failed to add block %d: %s", b.Index, err)
+			return
+		}
+		for _, srv := range services {
+			go srv.OnNewBlock(b)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		from := i
+		broadcast := func(p *Payload) {
+			for j, srv := range services {
+				if j != from {
+					go srv.OnPayload(p)
+				}
+			}
+		}
+		priv, err := keys.NewPrivateKeyFromWIF(testWIFs[i])
+		require.NoError(t, err)
+
+		srv := newService(Config{
+			Broadcast:    broadcast,
+			RelayBlock:   relayBlock,
+			RequestTx:    func(...util.Uint256) {},
+			Chain:        chain,
+			TimePerBlock: 200 * time.Millisecond,
+		})
+		srv.privateKeys = []*keys.PrivateKey{priv}
+		services[i] = srv
+	}
+	return services
+}
+
+func waitForHeight(t *testing.T, chain core.Blockchainer, height uint32) {
+	deadline := time.Now().Add(10 * time.Second)
+	for chain.BlockHeight() < height {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for block %d, current height is %d", height, chain.BlockHeight())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestServiceProducesBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chain := newTestChain(t, ctx)
+	services := newTestServices(t, chain, len(testWIFs))
+	for _, srv := range services {
+		srv.Start()
+		defer srv.Shutdown()
+	}
+	waitForHeight(t, chain, 2)
+
+	b, err := chain.GetBlock(chain.GetHeaderHash(1))
+	require.NoError(t, err)
+	require.NotEmpty(t, b.Transactions)
+	assert.Equal(t, transaction.MinerType, b.Transactions[0].Type)
+	validators, err := services[0].getValidators()
+	require.NoError(t, err)
+	nextConsensus, err := getNextConsensus(validators)
+	require.NoError(t, err)
+	assert.Equal(t, nextConsensus, b.NextConsensus)
+}
+
+func TestServiceWatchOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chain := newTestChain(t, ctx)
+	srv := newService(Config{
+		Broadcast:  func(*Payload) { t.Error("watch-only node must not send payloads") },
+		RelayBlock: func(*core.Block) { t.Error("watch-only node must not produce blocks") },
+		Chain:      chain,
+	})
+	srv.Start()
+	defer srv.Shutdown()
+	assert.True(t, srv.ctx.WatchOnly())
+	assert.Equal(t, uint32(1), srv.ctx.BlockIndex)
+	assert.Equal(t, 4, srv.ctx.N())
+}
+
+func TestServiceShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chain := newTestChain(t, ctx)
+	srv := newTestServices(t, chain, 1)[0]
+	srv.Start()
+	srv.Shutdown()
+	select {
+	case <-srv.finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event loop didn't stop")
+	}
+	// Doesn't block after the shutdown.
+	for i := 0; i < queueSize+1; i++ {
+		srv.OnNewBlock(&core.Block{})
+	}
+	srv.Shutdown()
+}
@@ -0,0 +1,306 @@
+package consensus
+
+import (
+	"sort"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+// consensusContext holds the state of the current consensus round.
+type consensusContext struct {
+	// Validators is the sorted list of validators for the current block.
+	Validators []*keys.PublicKey
+	// MyIndex is the index of the node in the validators list, it's -1
+	// for nodes that are not validators.
+	MyIndex int
+	// PrivateKey is the key used to sign the node's payloads.
+	PrivateKey *keys.PrivateKey
+
+	PrevHash     util.Uint256
+	BlockIndex   uint32
+	ViewNumber   byte
+	PrimaryIndex int
+
+	// Fields of the block proposal.
+	Timestamp         uint32
+	Nonce             uint64
+	NextConsensus     util.Uint160
+	TransactionHashes []util.Uint256
+	Transactions      map[util.Uint256]*transaction.Transaction
+
+	PreparationPayloads    []*Payload
+	CommitPayloads         []*Payload
+	ChangeViewPayloads     []*Payload
+	LastChangeViewPayloads []*Payload
+
+	// LastSeenMessage holds the index of the last block every validator
+	// has sent a message for.
+	LastSeenMessage []int64
+
+	// block is the block assembled by the node, it's set once the block
+	// is relayed.
+	block *core.Block
+	// header is the block header for the current proposal.
+	header *core.Block
+}
+
+// N returns the number of validators.
+func (c *consensusContext) N() int { return len(c.Validators) }
+
+// F returns the maximum number of faulty nodes.
+func (c *consensusContext) F() int { return (len(c.Validators) - 1) / 3 }
+
+// M returns the number of nodes needed to reach the consensus.
+func (c *consensusContext) M() int { return len(c.Validators) - c.F() }
+
+// WatchOnly returns true if the node is not a validator.
+func (c *consensusContext) WatchOnly() bool { return c.MyIndex < 0 }
+
+// IsPrimary returns true if the node is the primary in the current view.
+func (c *consensusContext) IsPrimary() bool { return c.MyIndex == c.PrimaryIndex }
+
+// IsBackup returns true if the node is a backup validator in the current view.
+func (c *consensusContext) IsBackup() bool { return c.MyIndex >= 0 && c.MyIndex != c.PrimaryIndex }
+
+// RequestSentOrReceived returns true if the PrepareRequest was sent or
+// received in the current view.
+func (c *consensusContext) RequestSentOrReceived() bool {
+	return c.PreparationPayloads[c.PrimaryIndex] != nil
+}
+
+// ResponseSent returns true if the node has sent its PrepareResponse.
+func (c *consensusContext) ResponseSent() bool {
+	return !c.WatchOnly() && c.PreparationPayloads[c.MyIndex] != nil
+}
+
+// CommitSent returns true if the node has sent its Commit.
+func (c *consensusContext) CommitSent() bool {
+	return !c.WatchOnly() && c.CommitPayloads[c.MyIndex] != nil
+}
+
+// BlockSent returns true if the block was already assembled and relayed.
+func (c *consensusContext) BlockSent() bool { return c.block != nil }
+
+// ViewChanging returns true if the node has requested a view change.
+func (c *consensusContext) ViewChanging() bool {
+	if c.WatchOnly() {
+		return false
+	}
+	p := c.ChangeViewPayloads[c.MyIndex]
+	return p != nil && p.message.newViewNumber() > c.ViewNumber
+}
+
+// CountCommitted returns the number of received Commit payloads.
+func (c *consensusContext) CountCommitted() int {
+	return countPayloads(c.CommitPayloads)
+}
+
+// CountFailed returns the number of validators no messages were received
+// from for the current or the previous block.
+func (c *consensusContext) CountFailed() int {
+	var count int
+	for _, h := range c.LastSeenMessage {
+		if h < int64(c.BlockIndex)-1 {
+			count++
+		}
+	}
+	return count
+}
+
+// MoreThanFNodesCommittedOrLost returns true if the view can't be changed
+// anymore because too many nodes are either committed or lost.
+func (c *consensusContext) MoreThanFNodesCommittedOrLost() bool {
+	return c.CountCommitted()+c.CountFailed() > c.F()
+}
+
+// NotAcceptingPayloadsDueToViewChanging returns true if the node has
+// requested the view change and should ignore the current view payloads.
+func (c *consensusContext) NotAcceptingPayloadsDueToViewChanging() bool {
+	return c.ViewChanging() && !c.MoreThanFNodesCommittedOrLost()
+}
+
+// reset prepares the context for the given view. For view 0 the state of
+// the next block is taken from the chain.
+func (c *consensusContext) reset(chain core.Blockchainer, validators []*keys.PublicKey, privs []*keys.PrivateKey, view byte) {
+	if view == 0 {
+		c.MyIndex = -1
+		c.PrivateKey = nil
+		c.PrevHash = chain.CurrentBlockHash()
+		c.BlockIndex = chain.BlockHeight() + 1
+		c.Validators = validators
+
+		for i, v := range validators {
+			for _, priv := range privs {
+				if string(priv.PublicKey().Bytes()) == string(v.Bytes()) {
+					c.MyIndex = i
+					c.PrivateKey = priv
+				}
+			}
+		}
+
+		if len(c.LastSeenMessage) != len(validators) {
+			c.LastSeenMessage = make([]int64, len(validators))
+			for i := range c.LastSeenMessage {
+				c.LastSeenMessage[i] = -1
+			}
+		}
+		c.ChangeViewPayloads = make([]*Payload, len(validators))
+		c.LastChangeViewPayloads = make([]*Payload, len(validators))
+		c.CommitPayloads = make([]*Payload, len(validators))
+	} else {
+		for i, p := range c.ChangeViewPayloads {
+			if p != nil && p.message.newViewNumber() >= view {
+				c.LastChangeViewPayloads[i] = p
+			} else {
+				c.LastChangeViewPayloads[i] = nil
+			}
+		}
+	}
+
+	c.ViewNumber = view
+	c.PrimaryIndex = c.getPrimaryIndex(view)
+	c.Timestamp = 0
+	c.Nonce = 0
+	c.NextConsensus = util.Uint160{}
+	c.TransactionHashes = nil
+	c.Transactions = make(map[util.Uint256]*transaction.Transaction)
+	c.PreparationPayloads = make([]*Payload, len(c.Validators))
+	if c.MyIndex >= 0 {
+		c.LastSeenMessage[c.MyIndex] = int64(c.BlockIndex)
+	}
+	c.block = nil
+	c.header = nil
+}
+
+// getPrimaryIndex returns the index of the primary node for the given view.
+func (c *consensusContext) getPrimaryIndex(view byte) int {
+	n := c.N()
+	p := (int(c.BlockIndex) - int(view)) % n
+	if p < 0 {
+		p += n
+	}
+	return p
+}
+
+// makeHeader returns the header of the proposed block or nil if there is no
+// proposal in the current view.
+func (c *consensusContext) makeHeader() *core.Block {
+	if c.TransactionHashes == nil {
+		return nil
+	}
+	if c.header == nil {
+		merkle, err := crypto.NewMerkleTree(c.TransactionHashes)
+		if err != nil {
+			return nil
+		}
+		c.header = &core.Block{
+			BlockBase: core.BlockBase{
+				Version:       0,
+				PrevHash:      c.PrevHash,
+				MerkleRoot:    merkle.Root(),
+				Timestamp:     c.Timestamp,
+				Index:         c.BlockIndex,
+				ConsensusData: c.Nonce,
+				NextConsensus: c.NextConsensus,
+			},
+		}
+	}
+	return c.header
+}
+
+// makeBlock assembles the block signed by M validators. It returns nil if
+// there are not enough commits.
+func (c *consensusContext) makeBlock() *core.Block {
+	header := c.makeHeader()
+	if header == nil {
+		return nil
+	}
+
+	verif, err := getMultisigScript(c.Validators)
+	if err != nil {
+		return nil
+	}
+
+	// Validators are sorted the same way as the keys of the multisig
+	// script, so signatures can be pushed in the index order.
+	var (
+		inv   []byte
+		count int
+	)
+	for _, p := range c.CommitPayloads {
+		if count == c.M() {
+			break
+		}
+		if p == nil || p.message.ViewNumber != c.ViewNumber {
+			continue
+		}
+		sig := p.message.commit().Signature
+		inv = append(inv, byte(vm.PUSHBYTES64))
+		inv = append(inv, sig[:]...)
+		count++
+	}
+	if count < c.M() {
+		return nil
+	}
+
+	block := &core.Block{
+		BlockBase:    header.BlockBase,
+		Transactions: make([]*transaction.Transaction, 0, len(c.TransactionHashes)),
+	}
+	block.Script = &transaction.Witness{
+		InvocationScript:   inv,
+		VerificationScript: verif,
+	}
+	for _, h := range c.TransactionHashes {
+		block.Transactions = append(block.Transactions, c.Transactions[h])
+	}
+	return block
+}
+
+// hasAllTransactions returns true if all transactions of the proposal are
+// known to the node.
+func (c *consensusContext) hasAllTransactions() bool {
+	return c.TransactionHashes != nil && len(c.Transactions) == len(c.TransactionHashes)
+}
+
+// countPayloads returns the number of non-nil payloads in the given list.
+func countPayloads(ps []*Payload) int {
+	var count int
+	for _, p := range ps {
+		if p != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// sortValidators returns a sorted copy of the given validators list.
+func sortValidators(validators []*keys.PublicKey) []*keys.PublicKey {
+	sorted := make(keys.PublicKeys, len(validators))
+	copy(sorted, validators)
+	sort.Sort(sorted)
+	return sorted
+}
+
+// getMultisigScript returns the verification script of the block signed by
+// the given validators.
+func getMultisigScript(validators []*keys.PublicKey) ([]byte, error) {
+	n := len(validators)
+	return smartcontract.CreateMultiSigRedeemScript(n-(n-1)/3, sortValidators(validators))
+}
+
+// getNextConsensus returns the NextConsensus address for the given validators.
+func getNextConsensus(validators []*keys.PublicKey) (util.Uint160, error) {
+	script, err := getMultisigScript(validators)
+	if err != nil {
+		return util.Uint160{}, err
+	}
+	return hash.Hash160(script), nil
+}
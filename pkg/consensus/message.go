@@ -0,0 +1,98 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/infinitete/neo-go-inf/pkg/io"
+)
+
+// messageType is the type of the consensus message.
+type messageType byte
+
+// Consensus message types.
+const (
+	changeViewType      messageType = 0x00
+	prepareRequestType  messageType = 0x20
+	prepareResponseType messageType = 0x21
+	commitType          messageType = 0x30
+	recoveryRequestType messageType = 0x40
+	recoveryMessageType messageType = 0x41
+)
+
+// message is a consensus message with its type specific body.
+type message struct {
+	Type       messageType
+	ViewNumber byte
+
+	payload io.Serializable
+}
+
+// String implements the Stringer interface.
+func (t messageType) String() string {
+	switch t {
+	case changeViewType:
+		return "ChangeView"
+	case prepareRequestType:
+		return "PrepareRequest"
+	case prepareResponseType:
+		return "PrepareResponse"
+	case commitType:
+		return "Commit"
+	case recoveryRequestType:
+		return "RecoveryRequest"
+	case recoveryMessageType:
+		return "RecoveryMessage"
+	default:
+		return fmt.Sprintf("UNKNOWN(0x%02x)", byte(t))
+	}
+}
+
+// EncodeBinary implements Serializable interface.
+func (m *message) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(m.Type)
+	w.WriteLE(m.ViewNumber)
+	m.payload.EncodeBinary(w)
+}
+
+// DecodeBinary implements Serializable interface.
+func (m *message) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&m.Type)
+	r.ReadLE(&m.ViewNumber)
+	if r.Err != nil {
+		return
+	}
+
+	switch m.Type {
+	case changeViewType:
+		m.payload = new(changeView)
+	case prepareRequestType:
+		m.payload = new(prepareRequest)
+	case prepareResponseType:
+		m.payload = new(prepareResponse)
+	case commitType:
+		m.payload = new(commit)
+	case recoveryRequestType:
+		m.payload = new(recoveryRequest)
+	case recoveryMessageType:
+		m.payload = new(recoveryMessage)
+	default:
+		r.Err = fmt.Errorf("invalid consensus message type: %s", m.Type)
+		return
+	}
+	m.payload.DecodeBinary(r)
+}
+
+// changeView returns the message body as a ChangeView.
+func (m *message) changeView() *changeView { return m.payload.(*changeView) }
+
+// prepareRequest returns the message body as a PrepareRequest.
+func (m *message) prepareRequest() *prepareRequest { return m.payload.(*prepareRequest) }
+
+// prepareResponse returns the message body as a PrepareResponse.
+func (m *message) prepareResponse() *prepareResponse { return m.payload.(*prepareResponse) }
+
+// commit returns the message body as a Commit.
+func (m *message) commit() *commit { return m.payload.(*commit) }
+
+// recoveryMessage returns the message body as a RecoveryMessage.
+func (m *message) recoveryMessage() *recoveryMessage { return m.payload.(*recoveryMessage) }
@@ -0,0 +1,147 @@
+package consensus
+
+import (
+	"errors"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+// signatureSize is the size of ECDSA signature used by validators.
+const signatureSize = 64
+
+// Payload is a signed consensus message sent between validators. It's
+// transferred by the network inside of the 'consensus' command.
+type Payload struct {
+	// Version of the consensus protocol (currently 0).
+	Version uint32
+	// PrevHash is the hash of the current block the consensus is built upon.
+	PrevHash util.Uint256
+	// BlockIndex is the index of the block the consensus is held for.
+	BlockIndex uint32
+	// ValidatorIndex is the index of the sender in the validators list.
+	ValidatorIndex uint16
+	// Data is the serialized consensus message.
+	Data []byte
+	// Witness is the signature of the sender.
+	Witness transaction.Witness
+
+	// Decoded consensus message, filled lazily.
+	message *message
+
+	// Hash of the payload, created when binary encoded (double SHA256).
+	hash util.Uint256
+}
+
+// EncodeBinaryUnsigned writes the payload without its witness to the given
+// BinWriter.
+func (p *Payload) EncodeBinaryUnsigned(w *io.BinWriter) {
+	w.WriteLE(p.Version)
+	w.WriteLE(p.PrevHash)
+	w.WriteLE(p.BlockIndex)
+	w.WriteLE(p.ValidatorIndex)
+	w.WriteBytes(p.Data)
+}
+
+// DecodeBinaryUnsigned reads the payload without its witness from the given
+// BinReader.
+func (p *Payload) DecodeBinaryUnsigned(r *io.BinReader) {
+	r.ReadLE(&p.Version)
+	r.ReadLE(&p.PrevHash)
+	r.ReadLE(&p.BlockIndex)
+	r.ReadLE(&p.ValidatorIndex)
+	p.Data = r.ReadBytes()
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *Payload) EncodeBinary(w *io.BinWriter) {
+	p.EncodeBinaryUnsigned(w)
+	w.WriteLE(uint8(1))
+	p.Witness.EncodeBinary(w)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *Payload) DecodeBinary(r *io.BinReader) {
+	p.DecodeBinaryUnsigned(r)
+
+	var count uint8
+	r.ReadLE(&count)
+	if r.Err == nil && count != 1 {
+		r.Err = errors.New("invalid witness count")
+		return
+	}
+	p.Witness.DecodeBinary(r)
+}
+
+// GetSignedPart returns the serialized unsigned part of the payload, this
+// is the data validators sign.
+func (p *Payload) GetSignedPart() []byte {
+	w := io.NewBufBinWriter()
+	p.EncodeBinaryUnsigned(w.BinWriter)
+	return w.Bytes()
+}
+
+// Hash returns the hash of the payload.
+func (p *Payload) Hash() util.Uint256 {
+	if p.hash.Equals(util.Uint256{}) {
+		p.hash = hash.DoubleSha256(p.GetSignedPart())
+	}
+	return p.hash
+}
+
+// Sign signs the payload with the given private key and sets its witness.
+func (p *Payload) Sign(key *keys.PrivateKey) error {
+	sig, err := key.Sign(p.GetSignedPart())
+	if err != nil {
+		return err
+	}
+	verif, err := smartcontract.CreateSignatureRedeemScript(key.PublicKey())
+	if err != nil {
+		return err
+	}
+	p.Witness.InvocationScript = append([]byte{byte(vm.PUSHBYTES64)}, sig...)
+	p.Witness.VerificationScript = verif
+	return nil
+}
+
+// Verify checks that the payload is signed by the given validator.
+func (p *Payload) Verify(key *keys.PublicKey) bool {
+	verif, err := smartcontract.CreateSignatureRedeemScript(key)
+	if err != nil || string(verif) != string(p.Witness.VerificationScript) {
+		return false
+	}
+	inv := p.Witness.InvocationScript
+	if len(inv) != signatureSize+1 || inv[0] != byte(vm.PUSHBYTES64) {
+		return false
+	}
+	return key.Verify(inv[1:], hash.Sha256(p.GetSignedPart()).Bytes())
+}
+
+// decodeData decodes the consensus message from the payload's data.
+func (p *Payload) decodeData() (*message, error) {
+	if p.message != nil {
+		return p.message, nil
+	}
+	m := new(message)
+	r := io.NewBinReaderFromBuf(p.Data)
+	m.DecodeBinary(r)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	p.message = m
+	return m, nil
+}
+
+// setMessage serializes the given message into the payload's data.
+func (p *Payload) setMessage(m *message) {
+	w := io.NewBufBinWriter()
+	m.EncodeBinary(w.BinWriter)
+	p.Data = w.Bytes()
+	p.message = m
+	p.hash = util.Uint256{}
+}
@@ -0,0 +1,218 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestKey(t *testing.T) *keys.PrivateKey {
+	priv, err := keys.NewPrivateKeyFromWIF(testWIFs[0])
+	require.NoError(t, err)
+	return priv
+}
+
+func newTestMinerTx() *transaction.Transaction {
+	return &transaction.Transaction{
+		Type:       transaction.MinerType,
+		Data:       &transaction.MinerTX{Nonce: 42},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{},
+		Scripts:    []*transaction.Witness{},
+	}
+}
+
+func testEncodeDecodeMessage(t *testing.T, m *message) *message {
+	buf := io.NewBufBinWriter()
+	m.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	actual := new(message)
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, m.Type, actual.Type)
+	assert.Equal(t, m.ViewNumber, actual.ViewNumber)
+	return actual
+}
+
+func TestMessageEncodeDecode(t *testing.T) {
+	miner := newTestMinerTx()
+	prepHash := util.Uint256{1, 2, 3}
+
+	t.Run("ChangeView", func(t *testing.T) {
+		m := &message{Type: changeViewType, ViewNumber: 2, payload: &changeView{Timestamp: 123}}
+		actual := testEncodeDecodeMessage(t, m)
+		assert.Equal(t, m.changeView(), actual.changeView())
+		assert.Equal(t, byte(3), actual.newViewNumber())
+	})
+
+	t.Run("PrepareRequest", func(t *testing.T) {
+		m := &message{
+			Type: prepareRequestType,
+			payload: &prepareRequest{
+				Timestamp:         123,
+				Nonce:             456,
+				NextConsensus:     util.Uint160{7, 8, 9},
+				TransactionHashes: []util.Uint256{miner.Hash(), {1}},
+				MinerTransaction:  *miner,
+			},
+		}
+		actual := testEncodeDecodeMessage(t, m)
+		expected, req := m.prepareRequest(), actual.prepareRequest()
+		assert.Equal(t, expected.Timestamp, req.Timestamp)
+		assert.Equal(t, expected.Nonce, req.Nonce)
+		assert.Equal(t, expected.NextConsensus, req.NextConsensus)
+		assert.Equal(t, expected.TransactionHashes, req.TransactionHashes)
+		assert.Equal(t, miner.Hash(), req.MinerTransaction.Hash())
+	})
+
+	t.Run("PrepareRequest/BadMinerHash", func(t *testing.T) {
+		m := &message{
+			Type: prepareRequestType,
+			payload: &prepareRequest{
+				TransactionHashes: []util.Uint256{{1}},
+				MinerTransaction:  *miner,
+			},
+		}
+		buf := io.NewBufBinWriter()
+		m.EncodeBinary(buf.BinWriter)
+		require.NoError(t, buf.Err)
+
+		r := io.NewBinReaderFromBuf(buf.Bytes())
+		new(message).DecodeBinary(r)
+		assert.Error(t, r.Err)
+	})
+
+	t.Run("PrepareRequest/DuplicateHashes", func(t *testing.T) {
+		m := &message{
+			Type: prepareRequestType,
+			payload: &prepareRequest{
+				TransactionHashes: []util.Uint256{miner.Hash(), {1}, {1}},
+				MinerTransaction:  *miner,
+			},
+		}
+		buf := io.NewBufBinWriter()
+		m.EncodeBinary(buf.BinWriter)
+		require.NoError(t, buf.Err)
+
+		r := io.NewBinReaderFromBuf(buf.Bytes())
+		new(message).DecodeBinary(r)
+		assert.Error(t, r.Err)
+	})
+
+	t.Run("PrepareResponse", func(t *testing.T) {
+		m := &message{Type: prepareResponseType, ViewNumber: 1, payload: &prepareResponse{PreparationHash: prepHash}}
+		actual := testEncodeDecodeMessage(t, m)
+		assert.Equal(t, m.prepareResponse(), actual.prepareResponse())
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		c := new(commit)
+		for i := range c.Signature {
+			c.Signature[i] = byte(i)
+		}
+		m := &message{Type: commitType, payload: c}
+		actual := testEncodeDecodeMessage(t, m)
+		assert.Equal(t, m.commit(), actual.commit())
+	})
+
+	t.Run("RecoveryRequest", func(t *testing.T) {
+		m := &message{Type: recoveryRequestType, payload: &recoveryRequest{Timestamp: 321}}
+		actual := testEncodeDecodeMessage(t, m)
+		assert.Equal(t, m.payload, actual.payload)
+	})
+
+	t.Run("RecoveryMessage/PreparationHash", func(t *testing.T) {
+		m := &message{
+			Type: recoveryMessageType,
+			payload: &recoveryMessage{
+				PreparationHash: &prepHash,
+				PreparationPayloads: []preparationCompact{
+					{ValidatorIndex: 1, InvocationScript: []byte{1, 2}},
+				},
+				CommitPayloads: []commitCompact{
+					{ViewNumber: 0, ValidatorIndex: 2, InvocationScript: []byte{3}},
+				},
+				ChangeViewPayloads: []changeViewCompact{
+					{ValidatorIndex: 3, OriginalViewNumber: 0, Timestamp: 5, InvocationScript: []byte{4}},
+				},
+			},
+		}
+		actual := testEncodeDecodeMessage(t, m)
+		assert.Equal(t, m.recoveryMessage(), actual.recoveryMessage())
+	})
+
+	t.Run("RecoveryMessage/PrepareRequest", func(t *testing.T) {
+		req := &message{
+			Type: prepareRequestType,
+			payload: &prepareRequest{
+				TransactionHashes: []util.Uint256{miner.Hash()},
+				MinerTransaction:  *miner,
+			},
+		}
+		m := &message{
+			Type:    recoveryMessageType,
+			payload: &recoveryMessage{PrepareRequest: req},
+		}
+		actual := testEncodeDecodeMessage(t, m).recoveryMessage()
+		require.NotNil(t, actual.PrepareRequest)
+		assert.Nil(t, actual.PreparationHash)
+		assert.Equal(t, prepareRequestType, actual.PrepareRequest.Type)
+		assert.Equal(t, miner.Hash(), actual.PrepareRequest.prepareRequest().MinerTransaction.Hash())
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		r := io.NewBinReaderFromBuf([]byte{0xff, 0})
+		new(message).DecodeBinary(r)
+		assert.Error(t, r.Err)
+	})
+}
+
+func TestPayloadEncodeDecode(t *testing.T) {
+	priv := getTestKey(t)
+	p := &Payload{
+		PrevHash:       util.Uint256{1, 2, 3},
+		BlockIndex:     10,
+		ValidatorIndex: 1,
+	}
+	p.setMessage(&message{Type: changeViewType, payload: &changeView{Timestamp: 1}})
+	require.NoError(t, p.Sign(priv))
+
+	buf := io.NewBufBinWriter()
+	p.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	actual := new(Payload)
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, p.Hash(), actual.Hash())
+	assert.Equal(t, p.Witness, actual.Witness)
+
+	m, err := actual.decodeData()
+	require.NoError(t, err)
+	assert.Equal(t, changeViewType, m.Type)
+}
+
+func TestPayloadSignVerify(t *testing.T) {
+	priv := getTestKey(t)
+	p := &Payload{BlockIndex: 1}
+	p.setMessage(&message{Type: recoveryRequestType, payload: &recoveryRequest{Timestamp: 1}})
+	require.NoError(t, p.Sign(priv))
+	assert.True(t, p.Verify(priv.PublicKey()))
+
+	other, err := keys.NewPrivateKeyFromWIF(testWIFs[1])
+	require.NoError(t, err)
+	assert.False(t, p.Verify(other.PublicKey()))
+
+	p.BlockIndex = 2
+	p.hash = util.Uint256{}
+	assert.False(t, p.Verify(priv.PublicKey()))
+}
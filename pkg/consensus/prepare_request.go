@@ -0,0 +1,68 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// maxTransactionHashes is the maximum number of transaction hashes in a
+// single PrepareRequest.
+const maxTransactionHashes = 0xffff
+
+// prepareRequest is a block proposal sent by the primary node.
+type prepareRequest struct {
+	Timestamp         uint32
+	Nonce             uint64
+	NextConsensus     util.Uint160
+	TransactionHashes []util.Uint256
+	MinerTransaction  transaction.Transaction
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *prepareRequest) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(p.Timestamp)
+	w.WriteLE(p.Nonce)
+	w.WriteLE(p.NextConsensus)
+	w.WriteVarUint(uint64(len(p.TransactionHashes)))
+	for _, h := range p.TransactionHashes {
+		w.WriteLE(h)
+	}
+	p.MinerTransaction.EncodeBinary(w)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *prepareRequest) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&p.Timestamp)
+	r.ReadLE(&p.Nonce)
+	r.ReadLE(&p.NextConsensus)
+
+	n := r.ReadVarUint()
+	if r.Err != nil {
+		return
+	}
+	if n == 0 || n > maxTransactionHashes {
+		r.Err = fmt.Errorf("invalid number of transaction hashes: %d", n)
+		return
+	}
+	p.TransactionHashes = make([]util.Uint256, n)
+	seen := make(map[util.Uint256]bool, n)
+	for i := range p.TransactionHashes {
+		r.ReadLE(&p.TransactionHashes[i])
+		if r.Err != nil {
+			return
+		}
+		if seen[p.TransactionHashes[i]] {
+			r.Err = fmt.Errorf("duplicate transaction hash: %s", p.TransactionHashes[i])
+			return
+		}
+		seen[p.TransactionHashes[i]] = true
+	}
+	p.MinerTransaction.DecodeBinary(r)
+	if r.Err == nil && !p.MinerTransaction.Hash().Equals(p.TransactionHashes[0]) {
+		r.Err = errors.New("miner transaction hash mismatch")
+	}
+}
@@ -0,0 +1,22 @@
+package consensus
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// prepareResponse is an approval of the primary's block proposal.
+type prepareResponse struct {
+	// PreparationHash is the hash of the PrepareRequest payload.
+	PreparationHash util.Uint256
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *prepareResponse) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(p.PreparationHash)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *prepareResponse) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&p.PreparationHash)
+}
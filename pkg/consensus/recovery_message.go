@@ -0,0 +1,275 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// maxValidators is the maximum number of validators, it's limited by the
+// size of the multisignature verification script.
+const maxValidators = 1024
+
+type (
+	// recoveryMessage contains the consensus state known to the sender. It's
+	// used by validators which missed some messages to catch up.
+	recoveryMessage struct {
+		PreparationHash     *util.Uint256
+		PreparationPayloads []preparationCompact
+		CommitPayloads      []commitCompact
+		ChangeViewPayloads  []changeViewCompact
+		PrepareRequest      *message
+	}
+
+	// changeViewCompact is a ChangeView payload without the fields that
+	// can be restored from the RecoveryMessage payload.
+	changeViewCompact struct {
+		ValidatorIndex     uint16
+		OriginalViewNumber byte
+		Timestamp          uint32
+		InvocationScript   []byte
+	}
+
+	// commitCompact is a Commit payload without the fields that can be
+	// restored from the RecoveryMessage payload.
+	commitCompact struct {
+		ViewNumber       byte
+		ValidatorIndex   uint16
+		Signature        [signatureSize]byte
+		InvocationScript []byte
+	}
+
+	// preparationCompact is a PrepareRequest or PrepareResponse payload
+	// without the fields that can be restored from the RecoveryMessage
+	// payload.
+	preparationCompact struct {
+		ValidatorIndex   uint16
+		InvocationScript []byte
+	}
+)
+
+// EncodeBinary implements Serializable interface.
+func (m *recoveryMessage) EncodeBinary(w *io.BinWriter) {
+	w.WriteVarUint(uint64(len(m.ChangeViewPayloads)))
+	for i := range m.ChangeViewPayloads {
+		m.ChangeViewPayloads[i].EncodeBinary(w)
+	}
+
+	hasReq := m.PrepareRequest != nil
+	w.WriteLE(hasReq)
+	if hasReq {
+		m.PrepareRequest.EncodeBinary(w)
+	} else if m.PreparationHash != nil {
+		w.WriteBytes(m.PreparationHash.Bytes())
+	} else {
+		w.WriteVarUint(0)
+	}
+
+	w.WriteVarUint(uint64(len(m.PreparationPayloads)))
+	for i := range m.PreparationPayloads {
+		m.PreparationPayloads[i].EncodeBinary(w)
+	}
+
+	w.WriteVarUint(uint64(len(m.CommitPayloads)))
+	for i := range m.CommitPayloads {
+		m.CommitPayloads[i].EncodeBinary(w)
+	}
+}
+
+// DecodeBinary implements Serializable interface.
+func (m *recoveryMessage) DecodeBinary(r *io.BinReader) {
+	n := readCount(r)
+	m.ChangeViewPayloads = make([]changeViewCompact, n)
+	for i := range m.ChangeViewPayloads {
+		m.ChangeViewPayloads[i].DecodeBinary(r)
+	}
+
+	var hasReq bool
+	r.ReadLE(&hasReq)
+	if hasReq {
+		m.PrepareRequest = new(message)
+		m.PrepareRequest.DecodeBinary(r)
+		if r.Err == nil && m.PrepareRequest.Type != prepareRequestType {
+			r.Err = errors.New("recovery message contains invalid prepare request")
+			return
+		}
+	} else {
+		b := r.ReadBytes()
+		if r.Err != nil {
+			return
+		}
+		switch len(b) {
+		case 0:
+		case 32:
+			m.PreparationHash = new(util.Uint256)
+			copy(m.PreparationHash[:], b)
+		default:
+			r.Err = fmt.Errorf("invalid preparation hash length: %d", len(b))
+			return
+		}
+	}
+
+	n = readCount(r)
+	m.PreparationPayloads = make([]preparationCompact, n)
+	for i := range m.PreparationPayloads {
+		m.PreparationPayloads[i].DecodeBinary(r)
+	}
+
+	n = readCount(r)
+	m.CommitPayloads = make([]commitCompact, n)
+	for i := range m.CommitPayloads {
+		m.CommitPayloads[i].DecodeBinary(r)
+	}
+}
+
+// readCount reads the number of compact payloads in the RecoveryMessage.
+func readCount(r *io.BinReader) int {
+	n := r.ReadVarUint()
+	if r.Err != nil {
+		return 0
+	}
+	if n > maxValidators {
+		r.Err = fmt.Errorf("too many payloads in recovery message: %d", n)
+		return 0
+	}
+	return int(n)
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *changeViewCompact) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(p.ValidatorIndex)
+	w.WriteLE(p.OriginalViewNumber)
+	w.WriteLE(p.Timestamp)
+	w.WriteBytes(p.InvocationScript)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *changeViewCompact) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&p.ValidatorIndex)
+	r.ReadLE(&p.OriginalViewNumber)
+	r.ReadLE(&p.Timestamp)
+	p.InvocationScript = r.ReadBytes()
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *commitCompact) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(p.ViewNumber)
+	w.WriteLE(p.ValidatorIndex)
+	w.WriteLE(p.Signature)
+	w.WriteBytes(p.InvocationScript)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *commitCompact) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&p.ViewNumber)
+	r.ReadLE(&p.ValidatorIndex)
+	r.ReadLE(&p.Signature)
+	p.InvocationScript = r.ReadBytes()
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *preparationCompact) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(p.ValidatorIndex)
+	w.WriteBytes(p.InvocationScript)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *preparationCompact) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&p.ValidatorIndex)
+	p.InvocationScript = r.ReadBytes()
+}
+
+// changeViewPayloads restores ChangeView payloads from the RecoveryMessage
+// sent in the given payload.
+func (m *recoveryMessage) changeViewPayloads(p *Payload, validators []*keys.PublicKey) []*Payload {
+	var result []*Payload
+	for _, cv := range m.ChangeViewPayloads {
+		msg := &message{
+			Type:       changeViewType,
+			ViewNumber: cv.OriginalViewNumber,
+			payload:    &changeView{Timestamp: cv.Timestamp},
+		}
+		if cp := restorePayload(p, cv.ValidatorIndex, msg, cv.InvocationScript, validators); cp != nil {
+			result = append(result, cp)
+		}
+	}
+	return result
+}
+
+// prepareRequestPayload restores the PrepareRequest payload from the
+// RecoveryMessage sent in the given payload.
+func (m *recoveryMessage) prepareRequestPayload(p *Payload, primary uint16, validators []*keys.PublicKey) *Payload {
+	if m.PrepareRequest == nil {
+		return nil
+	}
+	for _, prep := range m.PreparationPayloads {
+		if prep.ValidatorIndex == primary {
+			return restorePayload(p, primary, m.PrepareRequest, prep.InvocationScript, validators)
+		}
+	}
+	return nil
+}
+
+// prepareResponsePayloads restores PrepareResponse payloads from the
+// RecoveryMessage sent in the given payload, preparationHash is the hash
+// of the PrepareRequest payload the responses are for.
+func (m *recoveryMessage) prepareResponsePayloads(p *Payload, viewNumber byte, primary uint16, preparationHash util.Uint256, validators []*keys.PublicKey) []*Payload {
+	var result []*Payload
+	for _, prep := range m.PreparationPayloads {
+		if prep.ValidatorIndex == primary {
+			continue
+		}
+		msg := &message{
+			Type:       prepareResponseType,
+			ViewNumber: viewNumber,
+			payload:    &prepareResponse{PreparationHash: preparationHash},
+		}
+		if cp := restorePayload(p, prep.ValidatorIndex, msg, prep.InvocationScript, validators); cp != nil {
+			result = append(result, cp)
+		}
+	}
+	return result
+}
+
+// commitPayloads restores Commit payloads from the RecoveryMessage sent in
+// the given payload.
+func (m *recoveryMessage) commitPayloads(p *Payload, validators []*keys.PublicKey) []*Payload {
+	var result []*Payload
+	for _, c := range m.CommitPayloads {
+		msg := &message{
+			Type:       commitType,
+			ViewNumber: c.ViewNumber,
+			payload:    &commit{Signature: c.Signature},
+		}
+		if cp := restorePayload(p, c.ValidatorIndex, msg, c.InvocationScript, validators); cp != nil {
+			result = append(result, cp)
+		}
+	}
+	return result
+}
+
+// restorePayload creates the payload of the given validator with the given
+// message and invocation script, all other fields are taken from p.
+func restorePayload(p *Payload, index uint16, msg *message, invocation []byte, validators []*keys.PublicKey) *Payload {
+	if int(index) >= len(validators) {
+		return nil
+	}
+	verif, err := smartcontract.CreateSignatureRedeemScript(validators[index])
+	if err != nil {
+		return nil
+	}
+	cp := &Payload{
+		Version:        p.Version,
+		PrevHash:       p.PrevHash,
+		BlockIndex:     p.BlockIndex,
+		ValidatorIndex: index,
+	}
+	cp.setMessage(msg)
+	cp.Witness.InvocationScript = invocation
+	cp.Witness.VerificationScript = verif
+	return cp
+}
@@ -0,0 +1,18 @@
+package consensus
+
+import "github.com/infinitete/neo-go-inf/pkg/io"
+
+// recoveryRequest asks other validators to send the current consensus state.
+type recoveryRequest struct {
+	Timestamp uint32
+}
+
+// EncodeBinary implements Serializable interface.
+func (rr *recoveryRequest) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(rr.Timestamp)
+}
+
+// DecodeBinary implements Serializable interface.
+func (rr *recoveryRequest) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&rr.Timestamp)
+}
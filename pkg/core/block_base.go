@@ -47,12 +47,6 @@ type BlockBase struct {
 	verificationHash util.Uint256
 }
 
-// Verify verifies the integrity of the BlockBase.
-func (b *BlockBase) Verify() bool {
-	// TODO: Need a persisted blockchain for this.
-	return true
-}
-
 // Hash returns the hash of the block.
 func (b *BlockBase) Hash() util.Uint256 {
 	if b.hash.Equals(util.Uint256{}) {
@@ -93,8 +87,9 @@ func (b *BlockBase) EncodeBinary(bw *io.BinWriter) {
 	b.Script.EncodeBinary(bw)
 }
 
-// getHashableData returns serialized hashable data of the block.
-func (b *BlockBase) getHashableData() ([]byte, error) {
+// GetHashableData returns serialized hashable data of the block. This is the
+// data block signatures are created for.
+func (b *BlockBase) GetHashableData() ([]byte, error) {
 	buf := io.NewBufBinWriter()
 	b.encodeHashableFields(buf.BinWriter)
 	if buf.Err != nil {
@@ -110,7 +105,7 @@ func (b *BlockBase) getHashableData() ([]byte, error) {
 // Since MerkleRoot already contains the hash value of all transactions,
 // the modification of transaction will influence the hash value of the block.
 func (b *BlockBase) createHash() error {
-	bb, err := b.getHashableData()
+	bb, err := b.GetHashableData()
 	if err != nil {
 		return err
 	}
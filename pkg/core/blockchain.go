@@ -12,6 +12,7 @@ import (
 	"github.com/infinitete/neo-go-inf/config"
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
 	"github.com/infinitete/neo-go-inf/pkg/util"
@@ -23,7 +24,7 @@ import (
 // Tuning parameters.
 const (
 	headerBatchCount = 2000
-	version          = "0.0.1"
+	version          = "0.0.4"
 
 	// This one comes from C# code and it's different from the constant used
 	// when creating an asset with Neo.Asset.Create interop call. It looks
@@ -66,10 +67,26 @@ type Blockchain struct {
 	headersOpDone chan struct{}
 
 	memPool MemPool
+
+	// Event feed, events are only generated if there are subscribers and
+	// are delivered to them by the notificationDispatcher.
+	events          chan interface{}
+	subCh           chan interface{}
+	unsubCh         chan interface{}
+	stopCh          chan struct{}
+	subscriberCount int32
 }
 
 type headersOpFunc func(headerList *HeaderHashList)
 
+// eventsBufferSize is the number of events that can be queued for the
+// notificationDispatcher without blocking the block processing.
+const eventsBufferSize = 256
+
+// ErrOOM is returned when the transaction can't be added to the memory pool
+// because it's full.
+var ErrOOM = errors.New("no space left in the memory pool")
+
 // NewBlockchain returns a new blockchain object the will use the
 // given Store as its underlying storage.
 func NewBlockchain(s storage.Store, cfg config.ProtocolConfiguration) (*Blockchain, error) {
@@ -79,6 +96,10 @@ func NewBlockchain(s storage.Store, cfg config.ProtocolConfiguration) (*Blockcha
 		headersOp:     make(chan headersOpFunc),
 		headersOpDone: make(chan struct{}),
 		memPool:       NewMemPool(50000),
+		events:        make(chan interface{}, eventsBufferSize),
+		subCh:         make(chan interface{}),
+		unsubCh:       make(chan interface{}),
+		stopCh:        make(chan struct{}),
 	}
 
 	if err := bc.init(); err != nil {
@@ -167,9 +188,6 @@ func (bc *Blockchain) init() error {
 		}
 		headerSliceReverse(headers)
 		for _, h := range headers {
-			if !h.Verify() {
-				return fmt.Errorf("bad header %d/%s in the storage", h.Index, h.Hash())
-			}
 			bc.headerList.Add(h.Hash())
 		}
 	}
@@ -180,7 +198,9 @@ func (bc *Blockchain) init() error {
 // Run runs chain loop.
 func (bc *Blockchain) Run(ctx context.Context) {
 	persistTimer := time.NewTimer(persistInterval)
+	go bc.notificationDispatcher()
 	defer func() {
+		close(bc.stopCh)
 		persistTimer.Stop()
 		if err := bc.persist(); err != nil {
 			log.Warnf("failed to persist: %s", err)
@@ -216,13 +236,11 @@ func (bc *Blockchain) AddBlock(block *Block) error {
 		return fmt.Errorf("expected block %d, but passed block %d", expectedHeight, block.Index)
 	}
 	if bc.config.VerifyBlocks {
-		err := block.Verify()
-		if err == nil {
-			err = bc.VerifyBlock(block)
-		}
-		if err != nil {
+		if err := block.Verify(); err != nil {
 			return fmt.Errorf("block %s is invalid: %s", block.Hash().ReverseString(), err)
 		}
+		// Transactions are verified first, so that the validators
+		// are only computed from the valid ones.
 		if bc.config.VerifyTransactions {
 			for _, tx := range block.Transactions {
 				err := bc.VerifyTx(tx, block)
@@ -231,10 +249,14 @@ func (bc *Blockchain) AddBlock(block *Block) error {
 				}
 			}
 		}
+		if err := bc.VerifyBlock(block); err != nil {
+			return fmt.Errorf("block %s is invalid: %s", block.Hash().ReverseString(), err)
+		}
 	}
 	headerLen := bc.headerListLen()
 	if int(block.Index) == headerLen {
-		err := bc.AddHeaders(block.Header())
+		// The header is already verified as a part of the block.
+		err := bc.addHeaders(false, block.Header())
 		if err != nil {
 			return err
 		}
@@ -243,13 +265,24 @@ func (bc *Blockchain) AddBlock(block *Block) error {
 }
 
 // AddHeaders processes the given headers and add them to the
-// HeaderHashList.
-func (bc *Blockchain) AddHeaders(headers ...*Header) (err error) {
+// HeaderHashList. Headers are verified against the chain if VerifyBlocks
+// is enabled in the configuration.
+func (bc *Blockchain) AddHeaders(headers ...*Header) error {
+	return bc.addHeaders(bc.config.VerifyBlocks, headers...)
+}
+
+func (bc *Blockchain) addHeaders(verify bool, headers ...*Header) (err error) {
 	var (
 		start = time.Now()
 		batch = bc.store.Batch()
 	)
 
+	if verify {
+		if err = bc.verifyHeaders(headers); err != nil {
+			return err
+		}
+	}
+
 	bc.headersOp <- func(headerList *HeaderHashList) {
 		oldlen := headerList.Len()
 		for _, h := range headers {
@@ -263,8 +296,8 @@ func (bc *Blockchain) AddHeaders(headers ...*Header) (err error) {
 			if int(h.Index) < headerList.Len() {
 				continue
 			}
-			if !h.Verify() {
-				err = fmt.Errorf("header %v is invalid", h)
+			if verify && !h.PrevHash.Equals(headerList.Get(int(h.Index)-1)) {
+				err = fmt.Errorf("header %d doesn't follow the current header chain", h.Index)
 				return
 			}
 			if err = bc.processHeader(h, batch, headerList); err != nil {
@@ -288,6 +321,33 @@ func (bc *Blockchain) AddHeaders(headers ...*Header) (err error) {
 	return err
 }
 
+// verifyHeaders verifies the headers not yet in the header list against the
+// previous ones. It's done outside of the headers operation, because witness
+// checks can access the header list.
+func (bc *Blockchain) verifyHeaders(headers []*Header) error {
+	var (
+		known      = bc.headerListLen()
+		prevHeader *Header
+	)
+	for _, h := range headers {
+		if int(h.Index) < known {
+			continue
+		}
+		if prevHeader == nil || !prevHeader.Hash().Equals(h.PrevHash) {
+			var err error
+			prevHeader, err = bc.GetHeader(h.PrevHash)
+			if err != nil {
+				return errors.Wrapf(err, "unable to get previous header of %d", h.Index)
+			}
+		}
+		if err := bc.verifyHeader(h, prevHeader); err != nil {
+			return errors.Wrapf(err, "header %d is invalid", h.Index)
+		}
+		prevHeader = h
+	}
+	return nil
+}
+
 // processHeader processes the given header. Note that this is only thread safe
 // if executed in headers operation.
 func (bc *Blockchain) processHeader(h *Header, batch storage.Batch, headerList *HeaderHashList) error {
@@ -305,6 +365,8 @@ func (bc *Blockchain) processHeader(h *Header, batch storage.Batch, headerList *
 	}
 
 	buf.Reset()
+	// System fee is not known until the block itself is stored.
+	buf.WriteLE(uint32(0))
 	h.EncodeBinary(buf.BinWriter)
 	if buf.Err != nil {
 		return buf.Err
@@ -329,9 +391,24 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 		accounts     = make(Accounts)
 		assets       = make(Assets)
 		contracts    = make(Contracts)
+		validators   = make(Validators)
+		nep5Balances = make(nep5Balances)
+		nep5Index    uint16
+		execResults  []*AppExecResult
 	)
 
-	if err := storeAsBlock(tmpStore, block, 0); err != nil {
+	validatorsCount, err := getValidatorsCountFromStore(bc.store)
+	if err != nil {
+		return err
+	}
+
+	var sysFee util.Fixed8
+	for _, tx := range block.Transactions {
+		sysFee += bc.SystemFee(tx)
+	}
+	// The amount stored is the total of all system fees paid up to this block.
+	sysFeeAmount := bc.GetSystemFeeAmount(block.PrevHash) + uint32(sysFee.Int64Value())
+	if err := storeAsBlock(tmpStore, block, sysFeeAmount); err != nil {
 		return err
 	}
 
@@ -347,16 +424,25 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 		unspentCoins[tx.Hash()] = NewUnspentCoinState(len(tx.Outputs))
 
 		// Process TX outputs.
-		for _, output := range tx.Outputs {
+		for index, output := range tx.Outputs {
 			account, err := accounts.getAndUpdate(bc.store, output.ScriptHash)
 			if err != nil {
 				return err
 			}
+			if err := putUnspentIntoIndex(tmpStore, tx.Hash(), uint16(index), output); err != nil {
+				return err
+			}
 			if _, ok := account.Balances[output.AssetID]; ok {
 				account.Balances[output.AssetID] += output.Amount
 			} else {
 				account.Balances[output.AssetID] = output.Amount
 			}
+			if output.AssetID.Equals(governingTokenTX().Hash()) {
+				err = modAccountVotes(bc.store, account, output.Amount, validators, validatorsCount)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 		// Process TX inputs that are grouped by previous hash.
@@ -377,11 +463,25 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 				if err != nil {
 					return err
 				}
+				if err := deleteUnspentFromIndex(tmpStore, input.PrevHash, input.PrevIndex, prevTXOutput); err != nil {
+					return err
+				}
 
 				if prevTXOutput.AssetID.Equals(governingTokenTX().Hash()) {
-					spentCoin := NewSpentCoinState(input.PrevHash, prevTXHeight)
+					spentCoin, err := spentCoins.getAndUpdate(bc.store, input.PrevHash)
+					if err != nil {
+						return err
+					}
+					spentCoin.txHash = input.PrevHash
+					spentCoin.txHeight = prevTXHeight
 					spentCoin.items[input.PrevIndex] = block.Index
-					spentCoins[input.PrevHash] = spentCoin
+					if err := putSpentIntoIndex(tmpStore, input.PrevHash, input.PrevIndex, prevTXOutput, block.Index); err != nil {
+						return err
+					}
+					err = modAccountVotes(bc.store, account, -prevTXOutput.Amount, validators, validatorsCount)
+					if err != nil {
+						return err
+					}
 				}
 
 				account.Balances[prevTXOutput.AssetID] -= prevTXOutput.Amount
@@ -418,8 +518,32 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 				}
 			}
 		case *transaction.ClaimTX:
+			// Remove claimed coins from the spent ones, so they can't be claimed again.
+			for _, input := range t.Claims {
+				spentCoin, err := spentCoins.getAndUpdate(bc.store, input.PrevHash)
+				if err != nil {
+					return err
+				}
+				delete(spentCoin.items, input.PrevIndex)
+				prevTX, _, err := bc.GetTransaction(input.PrevHash)
+				if err != nil {
+					return fmt.Errorf("could not find previous TX: %s", input.PrevHash)
+				}
+				if int(input.PrevIndex) >= len(prevTX.Outputs) {
+					return fmt.Errorf("wrong claimed output index %d for TX %s", input.PrevIndex, input.PrevHash)
+				}
+				if err := deleteSpentFromIndex(tmpStore, input.PrevHash, input.PrevIndex, prevTX.Outputs[input.PrevIndex]); err != nil {
+					return err
+				}
+			}
 		case *transaction.EnrollmentTX:
+			if err := processEnrollmentTX(bc.store, t, validators); err != nil {
+				return err
+			}
 		case *transaction.StateTX:
+			if err := processStateTX(bc.store, t, accounts, validators, validatorsCount); err != nil {
+				return err
+			}
 		case *transaction.PublishTX:
 			var properties smartcontract.PropertyState
 			if t.NeedStorage {
@@ -442,6 +566,7 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 			systemInterop := newInteropContext(0x10, bc, tmpStore, block, tx)
 			vm := bc.spawnVMWithInterops(systemInterop)
 			vm.SetCheckedHash(tx.VerificationHash().Bytes())
+			vm.SetGasLimit(FreeGAS + t.Gas)
 			vm.LoadScript(t.Script)
 			err := vm.Run()
 			if !vm.HasFailed() {
@@ -456,6 +581,25 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 					"err":   err,
 				}).Warn("contract invocation failed")
 			}
+			aer := &AppExecResult{
+				TxHash:      tx.Hash(),
+				Trigger:     systemInterop.trigger,
+				VMState:     vm.State(),
+				GasConsumed: vm.GasConsumed(),
+				Stack:       vm.Estack().ToArray(),
+				Events:      systemInterop.notifications,
+			}
+			err = putAppExecResultIntoStore(tmpStore, aer)
+			if err != nil {
+				return errors.Wrap(err, "failed to store notifications")
+			}
+			execResults = append(execResults, aer)
+			if !vm.HasFailed() {
+				err = processNEP5Transfers(tmpStore, block, tx.Hash(), systemInterop.notifications, nep5Balances, &nep5Index)
+				if err != nil {
+					return errors.Wrap(err, "failed to process NEP-5 transfers")
+				}
+			}
 		}
 	}
 
@@ -475,6 +619,18 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 	if err := contracts.commit(tmpStore); err != nil {
 		return err
 	}
+	if err := validators.commit(tmpStore); err != nil {
+		return err
+	}
+	if err := putValidatorsCountIntoStore(tmpStore, validatorsCount); err != nil {
+		return err
+	}
+	if err := bc.updateNEP5Balances(tmpStore, block, nep5Balances); err != nil {
+		return errors.Wrap(err, "failed to update NEP-5 balances")
+	}
+	if err := nep5Balances.commit(tmpStore); err != nil {
+		return err
+	}
 	if _, err := tmpStore.Persist(); err != nil {
 		return err
 	}
@@ -484,9 +640,141 @@ func (bc *Blockchain) storeBlock(block *Block) error {
 	for _, tx := range block.Transactions {
 		bc.memPool.Remove(tx.Hash())
 	}
+	if atomic.LoadInt32(&bc.subscriberCount) > 0 {
+		bc.sendEvent(block)
+		for _, aer := range execResults {
+			bc.sendEvent(aer)
+		}
+	}
 	return nil
 }
 
+// sendEvent passes the given event to the notificationDispatcher, it doesn't
+// block if the Blockchain is stopped.
+func (bc *Blockchain) sendEvent(event interface{}) {
+	select {
+	case bc.events <- event:
+	case <-bc.stopCh:
+	}
+}
+
+// notificationDispatcher manages subscriptions and delivers events to the
+// subscribed channels. It's started by Run and exits when it's done.
+func (bc *Blockchain) notificationDispatcher() {
+	var (
+		blockFeed     = make(map[chan<- *Block]bool)
+		txFeed        = make(map[chan<- *transaction.Transaction]bool)
+		executionFeed = make(map[chan<- *AppExecResult]bool)
+	)
+	for {
+		select {
+		case <-bc.stopCh:
+			return
+		case sub := <-bc.subCh:
+			switch ch := sub.(type) {
+			case chan<- *Block:
+				blockFeed[ch] = true
+			case chan<- *transaction.Transaction:
+				txFeed[ch] = true
+			case chan<- *AppExecResult:
+				executionFeed[ch] = true
+			default:
+				panic(fmt.Sprintf("bad subscription: %T", sub))
+			}
+			atomic.StoreInt32(&bc.subscriberCount, int32(len(blockFeed)+len(txFeed)+len(executionFeed)))
+		case unsub := <-bc.unsubCh:
+			switch ch := unsub.(type) {
+			case chan<- *Block:
+				delete(blockFeed, ch)
+			case chan<- *transaction.Transaction:
+				delete(txFeed, ch)
+			case chan<- *AppExecResult:
+				delete(executionFeed, ch)
+			default:
+				panic(fmt.Sprintf("bad unsubscription: %T", unsub))
+			}
+			atomic.StoreInt32(&bc.subscriberCount, int32(len(blockFeed)+len(txFeed)+len(executionFeed)))
+		case event := <-bc.events:
+			switch e := event.(type) {
+			case *Block:
+				for ch := range blockFeed {
+					ch <- e
+				}
+			case *transaction.Transaction:
+				for ch := range txFeed {
+					ch <- e
+				}
+			case *AppExecResult:
+				for ch := range executionFeed {
+					ch <- e
+				}
+			}
+		}
+	}
+}
+
+// subscribe passes the given subscription channel to the
+// notificationDispatcher, it's a no-op if the Blockchain is stopped.
+func (bc *Blockchain) subscribe(ch interface{}) {
+	select {
+	case bc.subCh <- ch:
+	case <-bc.stopCh:
+	}
+}
+
+// unsubscribe passes the given channel to the notificationDispatcher for
+// removal, it's a no-op if the Blockchain is stopped.
+func (bc *Blockchain) unsubscribe(ch interface{}) {
+	select {
+	case bc.unsubCh <- ch:
+	case <-bc.stopCh:
+	}
+}
+
+// SubscribeForBlocks adds the given channel to the new block event
+// broadcasting, so when there is a new block added to the chain you'll
+// receive it via this channel. Make sure it's read from regularly as not
+// reading these events might affect other Blockchain functions. Subscriptions
+// only work when the Blockchain is running (see Run).
+func (bc *Blockchain) SubscribeForBlocks(ch chan<- *Block) {
+	bc.subscribe(ch)
+}
+
+// SubscribeForTransactions adds the given channel to the new mempool
+// transaction event broadcasting. The same reading rules as for
+// SubscribeForBlocks apply.
+func (bc *Blockchain) SubscribeForTransactions(ch chan<- *transaction.Transaction) {
+	bc.subscribe(ch)
+}
+
+// SubscribeForExecutions adds the given channel to the new script execution
+// event broadcasting, every invocation transaction of the accepted block
+// generates an event with its AppExecResult (containing all the
+// notifications). The same reading rules as for SubscribeForBlocks apply.
+func (bc *Blockchain) SubscribeForExecutions(ch chan<- *AppExecResult) {
+	bc.subscribe(ch)
+}
+
+// UnsubscribeFromBlocks unsubscribes the given channel from new block
+// notifications, you can close it afterwards. Passing non-subscribed
+// channel is a no-op. The channel must still be read from until this call
+// returns as the event might be in flight.
+func (bc *Blockchain) UnsubscribeFromBlocks(ch chan<- *Block) {
+	bc.unsubscribe(ch)
+}
+
+// UnsubscribeFromTransactions unsubscribes the given channel from new
+// mempool transaction notifications.
+func (bc *Blockchain) UnsubscribeFromTransactions(ch chan<- *transaction.Transaction) {
+	bc.unsubscribe(ch)
+}
+
+// UnsubscribeFromExecutions unsubscribes the given channel from new script
+// execution notifications.
+func (bc *Blockchain) UnsubscribeFromExecutions(ch chan<- *AppExecResult) {
+	bc.unsubscribe(ch)
+}
+
 // persist flushes current in-memory store contents to the persistent storage.
 func (bc *Blockchain) persist() error {
 	var (
@@ -564,6 +852,12 @@ func getTransactionFromStore(s storage.Store, hash util.Uint256) (*transaction.T
 	return tx, height, nil
 }
 
+// GetAppExecResult returns application execution result by the given
+// tx hash.
+func (bc *Blockchain) GetAppExecResult(hash util.Uint256) (*AppExecResult, error) {
+	return getAppExecResultFromStore(bc.store, hash)
+}
+
 // GetStorageItem returns an item from storage.
 func (bc *Blockchain) GetStorageItem(scripthash util.Uint160, key []byte) *StorageItem {
 	return getStorageItemFromStore(bc.store, scripthash, key)
@@ -596,6 +890,28 @@ func (bc *Blockchain) GetStorageItems(hash util.Uint160) (map[string]*StorageIte
 	return siMap, nil
 }
 
+// SeekStorageItems calls f for the storage items of the given contract with
+// keys starting with the given prefix and not less than start in ascending
+// key order, it stops once f returns false. Keys are passed to f without the
+// contract hash.
+func (bc *Blockchain) SeekStorageItems(hash util.Uint160, prefix, start []byte, f func(k []byte, si *StorageItem) bool) error {
+	var (
+		err     error
+		hashLen = len(makeStorageItemKey(hash, nil))
+	)
+	bc.store.SeekFrom(makeStorageItemKey(hash, prefix), makeStorageItemKey(hash, start), func(k, v []byte) bool {
+		r := io.NewBinReaderFromBuf(v)
+		si := &StorageItem{}
+		si.DecodeBinary(r)
+		if r.Err != nil {
+			err = r.Err
+			return false
+		}
+		return f(k[hashLen:], si)
+	})
+	return err
+}
+
 // GetBlock returns a Block by the given hash.
 func (bc *Blockchain) GetBlock(hash util.Uint256) (*Block, error) {
 	block, err := getBlockFromStore(bc.store, hash)
@@ -622,13 +938,35 @@ func getBlockFromStore(s storage.Store, hash util.Uint256) (*Block, error) {
 	if err != nil {
 		return nil, err
 	}
-	block, err := NewBlockFromTrimmedBytes(b)
+	if len(b) < 4 {
+		return nil, errors.New("bad DataBlock entry")
+	}
+	// Skip the system fee amount.
+	block, err := NewBlockFromTrimmedBytes(b[4:])
 	if err != nil {
 		return nil, err
 	}
 	return block, err
 }
 
+// GetSystemFeeAmount returns the total amount of system fees (in whole GAS
+// units) paid by all blocks up to and including the block identified by the
+// given hash, zero is returned for unknown blocks.
+func (bc *Blockchain) GetSystemFeeAmount(hash util.Uint256) uint32 {
+	key := storage.AppendPrefix(storage.DataBlock, hash.BytesReverse())
+	b, err := bc.store.Get(key)
+	if err != nil {
+		return 0
+	}
+	var sysFee uint32
+	r := io.NewBinReaderFromBuf(b)
+	r.ReadLE(&sysFee)
+	if r.Err != nil {
+		return 0
+	}
+	return sysFee
+}
+
 // GetHeader returns data block header identified with the given hash value.
 func (bc *Blockchain) GetHeader(hash util.Uint256) (*Header, error) {
 	return getHeaderFromStore(bc.store, hash)
@@ -762,6 +1100,22 @@ func (bc *Blockchain) GetAccountState(scriptHash util.Uint160) *AccountState {
 	return as
 }
 
+// GetUnspents returns unspent outputs belonging to the given account.
+func (bc *Blockchain) GetUnspents(scriptHash util.Uint160) ([]*UnspentOutput, error) {
+	return getUnspentsFromIndex(bc.store, scriptHash)
+}
+
+// GetNEP5Balances returns NEP-5 token balances of the given account.
+func (bc *Blockchain) GetNEP5Balances(account util.Uint160) ([]*NEP5Balance, error) {
+	return getNEP5BalancesFromStore(bc.store, account)
+}
+
+// GetNEP5Transfers returns NEP-5 transfers of the given account made in the
+// given time range (block timestamps, inclusive).
+func (bc *Blockchain) GetNEP5Transfers(account util.Uint160, start, end uint32) ([]*NEP5Transfer, error) {
+	return getNEP5TransfersFromStore(bc.store, account, start, end)
+}
+
 // GetUnspentCoinState returns unspent coin state for given tx hash.
 func (bc *Blockchain) GetUnspentCoinState(hash util.Uint256) *UnspentCoinState {
 	ucs, err := getUnspentCoinStateFromStore(bc.store, hash)
@@ -771,6 +1125,423 @@ func (bc *Blockchain) GetUnspentCoinState(hash util.Uint256) *UnspentCoinState {
 	return ucs
 }
 
+// CalculateClaimable returns the amount of GAS generated by the given value
+// of governing tokens held from startHeight till endHeight, the first
+// value returned is the amount coming from block rewards and the second
+// one is the amount coming from the system fees.
+func (bc *Blockchain) CalculateClaimable(value util.Fixed8, startHeight, endHeight uint32) (util.Fixed8, util.Fixed8) {
+	if endHeight <= startHeight {
+		return 0, 0
+	}
+
+	var amount int64
+	ustart := int(startHeight) / decrementInterval
+	if ustart < len(genAmount) {
+		istart := int(startHeight) % decrementInterval
+		uend := int(endHeight) / decrementInterval
+		iend := int(endHeight) % decrementInterval
+		if uend >= len(genAmount) {
+			uend = len(genAmount)
+			iend = 0
+		}
+		if iend == 0 {
+			uend--
+			iend = decrementInterval
+		}
+		for ustart < uend {
+			amount += int64((decrementInterval - istart) * genAmount[ustart])
+			ustart++
+			istart = 0
+		}
+		amount += int64((iend - istart) * genAmount[ustart])
+	}
+
+	var startFee uint32
+	if startHeight > 0 {
+		startFee = bc.GetSystemFeeAmount(bc.GetHeaderHash(int(startHeight - 1)))
+	}
+	sysFee := int64(bc.GetSystemFeeAmount(bc.GetHeaderHash(int(endHeight-1))) - startFee)
+
+	neo := value.Int64Value()
+	return util.Fixed8(neo * amount), util.Fixed8(neo * sysFee)
+}
+
+// CalculateBonus returns the amount of GAS that can be claimed for the given
+// governing token outputs. All of them should be spent and not yet claimed.
+func (bc *Blockchain) CalculateBonus(claims []*transaction.Input) (util.Fixed8, error) {
+	var bonus util.Fixed8
+
+	clGroups := make(map[util.Uint256][]*transaction.Input)
+	for _, in := range claims {
+		clGroups[in.PrevHash] = append(clGroups[in.PrevHash], in)
+	}
+	for prevHash, inputs := range clGroups {
+		unclaimed, err := bc.getUnclaimed(prevHash)
+		if err != nil {
+			return 0, err
+		}
+		for _, in := range inputs {
+			coin, ok := unclaimed[in.PrevIndex]
+			if !ok {
+				return 0, fmt.Errorf("output %s:%d is not spent or already claimed", prevHash.ReverseString(), in.PrevIndex)
+			}
+			generated, sysFee := bc.CalculateClaimable(coin.Output.Amount, coin.StartHeight, coin.EndHeight)
+			bonus += generated + sysFee
+		}
+	}
+	return bonus, nil
+}
+
+// getUnclaimed returns spent but not yet claimed governing token outputs of
+// the transaction with the given hash mapped by their indexes.
+func (bc *Blockchain) getUnclaimed(hash util.Uint256) (map[uint16]*SpentCoin, error) {
+	tx, height, err := getTransactionFromStore(bc.store, hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not find previous TX: %s", hash.ReverseString())
+	}
+	unclaimed := make(map[uint16]*SpentCoin)
+	scs, err := getSpentCoinStateFromStore(bc.store, hash)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return unclaimed, nil
+		}
+		return nil, err
+	}
+	for index, endHeight := range scs.items {
+		if int(index) >= len(tx.Outputs) {
+			return nil, fmt.Errorf("wrong spent coin index %d for TX %s", index, hash.ReverseString())
+		}
+		unclaimed[index] = &SpentCoin{
+			TxHash:      hash,
+			Index:       index,
+			Output:      tx.Outputs[index],
+			StartHeight: height,
+			EndHeight:   endHeight,
+		}
+	}
+	return unclaimed, nil
+}
+
+// GetClaimable returns spent but not yet claimed governing token outputs
+// belonging to the given account.
+func (bc *Blockchain) GetClaimable(scriptHash util.Uint160) ([]*SpentCoin, error) {
+	coins, err := getSpentsFromIndex(bc.store, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, coin := range coins {
+		tx, height, err := getTransactionFromStore(bc.store, coin.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("could not find previous TX: %s", coin.TxHash.ReverseString())
+		}
+		if int(coin.Index) >= len(tx.Outputs) {
+			return nil, fmt.Errorf("wrong spent coin index %d for TX %s", coin.Index, coin.TxHash.ReverseString())
+		}
+		coin.Output = tx.Outputs[coin.Index]
+		coin.StartHeight = height
+	}
+	sort.Slice(coins, func(i, j int) bool {
+		if coins[i].StartHeight != coins[j].StartHeight {
+			return coins[i].StartHeight < coins[j].StartHeight
+		}
+		if coins[i].TxHash != coins[j].TxHash {
+			return coins[i].TxHash.CompareTo(coins[j].TxHash) < 0
+		}
+		return coins[i].Index < coins[j].Index
+	})
+	return coins, nil
+}
+
+// GetUnclaimed returns the amount of GAS generated for the given account. The
+// first value returned is the amount available for claim (generated by
+// spent governing token outputs), the second one is the amount that is not
+// yet available (generated by unspent outputs up to the current height).
+func (bc *Blockchain) GetUnclaimed(scriptHash util.Uint160) (util.Fixed8, util.Fixed8, error) {
+	var available, unavailable util.Fixed8
+
+	claimable, err := bc.GetClaimable(scriptHash)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, coin := range claimable {
+		generated, sysFee := bc.CalculateClaimable(coin.Output.Amount, coin.StartHeight, coin.EndHeight)
+		available += generated + sysFee
+	}
+
+	unspents, err := getUnspentsFromIndex(bc.store, scriptHash)
+	if err != nil {
+		return 0, 0, err
+	}
+	endHeight := bc.BlockHeight() + 1
+	for _, unspent := range unspents {
+		if unspent.Output.AssetID != governingTokenTX().Hash() {
+			continue
+		}
+		_, height, err := getTransactionFromStore(bc.store, unspent.TxHash)
+		if err != nil {
+			return 0, 0, err
+		}
+		generated, sysFee := bc.CalculateClaimable(unspent.Output.Amount, height, endHeight)
+		unavailable += generated + sysFee
+	}
+	return available, unavailable, nil
+}
+
+// modAccountVotes adds the given amount to the votes of all validators the
+// account voted for and to the validators count.
+func modAccountVotes(s storage.Store, account *AccountState, amount util.Fixed8, validators Validators, vc *ValidatorsCount) error {
+	if len(account.Votes) == 0 {
+		return nil
+	}
+	for _, pub := range account.Votes {
+		validator, err := validators.getAndUpdate(s, pub)
+		if err != nil {
+			return err
+		}
+		validator.Votes += amount
+	}
+	vc[len(account.Votes)-1] += amount
+	return nil
+}
+
+// processEnrollmentTX registers the validator enrolled by the transaction.
+func processEnrollmentTX(s storage.Store, tx *transaction.EnrollmentTX, validators Validators) error {
+	validator, err := validators.getAndUpdate(s, tx.PublicKey)
+	if err != nil {
+		return err
+	}
+	validator.Registered = true
+	return nil
+}
+
+// processStateTX applies the state changes described by the transaction.
+func processStateTX(s storage.Store, tx *transaction.StateTX, accounts Accounts, validators Validators, vc *ValidatorsCount) error {
+	for _, desc := range tx.Descriptors {
+		var err error
+		switch desc.Type {
+		case transaction.Account:
+			err = processAccountStateDescriptor(s, desc, accounts, validators, vc)
+		case transaction.Validator:
+			err = processValidatorStateDescriptor(s, desc, validators)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processAccountStateDescriptor changes the votes of the account, votes of
+// the validators are updated accordingly. Repeated votes for the same key are
+// counted once and unknown fields are ignored.
+func processAccountStateDescriptor(s storage.Store, desc *transaction.StateDescriptor, accounts Accounts, validators Validators, vc *ValidatorsCount) error {
+	hash, err := util.Uint160DecodeBytes(desc.Key)
+	if err != nil {
+		return err
+	}
+	if desc.Field != "Votes" {
+		return nil
+	}
+
+	r := io.NewBinReaderFromBuf(desc.Value)
+	n := r.ReadVarUint()
+	if r.Err != nil {
+		return r.Err
+	}
+	if n > MaxValidatorsVoted {
+		return fmt.Errorf("too many votes: %d", n)
+	}
+	votes := make([]*keys.PublicKey, 0, n)
+	seen := make(map[string]bool, n)
+	for i := uint64(0); i < n; i++ {
+		key := &keys.PublicKey{}
+		key.DecodeBinary(r)
+		if r.Err != nil {
+			return r.Err
+		}
+		if k := string(key.Bytes()); !seen[k] {
+			seen[k] = true
+			votes = append(votes, key)
+		}
+	}
+
+	account, err := accounts.getAndUpdate(s, hash)
+	if err != nil {
+		return err
+	}
+	balance := account.Balances[governingTokenTX().Hash()]
+	if err = modAccountVotes(s, account, -balance, validators, vc); err != nil {
+		return err
+	}
+	account.Votes = votes
+	return modAccountVotes(s, account, balance, validators, vc)
+}
+
+// processValidatorStateDescriptor changes the registration of the validator,
+// unknown fields are ignored.
+func processValidatorStateDescriptor(s storage.Store, desc *transaction.StateDescriptor, validators Validators) error {
+	publicKey := &keys.PublicKey{}
+	if err := publicKey.DecodeBytes(desc.Key); err != nil {
+		return err
+	}
+	if desc.Field != "Registered" {
+		return nil
+	}
+	validator, err := validators.getAndUpdate(s, publicKey)
+	if err != nil {
+		return err
+	}
+	validator.Registered = len(desc.Value) != 0 && desc.Value[0] != 0
+	return nil
+}
+
+// GetValidators returns the list of validators for the next block. The
+// given transactions are taken into account as if they were already
+// persisted, which is needed to calculate the validators of the block
+// being created.
+func (bc *Blockchain) GetValidators(txes ...*transaction.Transaction) ([]*keys.PublicKey, error) {
+	var (
+		store      = storage.NewMemCachedStore(bc.store)
+		accounts   = make(Accounts)
+		validators = make(Validators)
+		known      = make(map[util.Uint256]*transaction.Transaction, len(txes))
+	)
+	validatorsCount, err := getValidatorsCountFromStore(store)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range txes {
+		known[tx.Hash()] = tx
+	}
+	for _, tx := range txes {
+		for _, output := range tx.Outputs {
+			if !output.AssetID.Equals(governingTokenTX().Hash()) {
+				continue
+			}
+			account, err := accounts.getAndUpdate(store, output.ScriptHash)
+			if err != nil {
+				return nil, err
+			}
+			if err = modAccountVotes(store, account, output.Amount, validators, validatorsCount); err != nil {
+				return nil, err
+			}
+		}
+		for prevHash, inputs := range tx.GroupInputsByPrevHash() {
+			prevTX, ok := known[prevHash]
+			if !ok {
+				prevTX, _, err = bc.GetTransaction(prevHash)
+				if err != nil {
+					return nil, fmt.Errorf("could not find previous TX: %s", prevHash)
+				}
+			}
+			for _, input := range inputs {
+				if int(input.PrevIndex) >= len(prevTX.Outputs) {
+					return nil, fmt.Errorf("wrong PrevIndex reference: %s:%d", prevHash, input.PrevIndex)
+				}
+				prevOutput := prevTX.Outputs[input.PrevIndex]
+				if !prevOutput.AssetID.Equals(governingTokenTX().Hash()) {
+					continue
+				}
+				account, err := accounts.getAndUpdate(store, prevOutput.ScriptHash)
+				if err != nil {
+					return nil, err
+				}
+				if err = modAccountVotes(store, account, -prevOutput.Amount, validators, validatorsCount); err != nil {
+					return nil, err
+				}
+			}
+		}
+		switch t := tx.Data.(type) {
+		case *transaction.EnrollmentTX:
+			err = processEnrollmentTX(store, t, validators)
+		case *transaction.StateTX:
+			err = processStateTX(store, t, accounts, validators, validatorsCount)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = validators.commit(store); err != nil {
+		return nil, err
+	}
+
+	standbyValidators, err := getValidators(bc.config)
+	if err != nil {
+		return nil, err
+	}
+	count := validatorsCount.GetWeightedAverage()
+	if count < len(standbyValidators) {
+		count = len(standbyValidators)
+	}
+
+	isStandby := make(map[string]bool, len(standbyValidators))
+	for _, pub := range standbyValidators {
+		isStandby[string(pub.Bytes())] = true
+	}
+	var candidates []*ValidatorState
+	for _, validator := range getValidatorsFromStore(store) {
+		if validator.RegisteredAndHasVotes() || isStandby[string(validator.PublicKey.Bytes())] {
+			candidates = append(candidates, validator)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Votes != candidates[j].Votes {
+			return candidates[i].Votes > candidates[j].Votes
+		}
+		return keys.PublicKeys{candidates[i].PublicKey, candidates[j].PublicKey}.Less(0, 1)
+	})
+
+	result := make(keys.PublicKeys, 0, count)
+	selected := make(map[string]bool, count)
+	for _, validator := range candidates {
+		if len(result) == count {
+			break
+		}
+		result = append(result, validator.PublicKey)
+		selected[string(validator.PublicKey.Bytes())] = true
+	}
+	for _, pub := range standbyValidators {
+		if len(result) == count {
+			break
+		}
+		if !selected[string(pub.Bytes())] {
+			result = append(result, pub)
+			selected[string(pub.Bytes())] = true
+		}
+	}
+	sort.Sort(result)
+	return result, nil
+}
+
+// GetEnrollments returns all registered validators and standby validators
+// with their votes.
+func (bc *Blockchain) GetEnrollments() ([]*ValidatorState, error) {
+	standbyValidators, err := getValidators(bc.config)
+	if err != nil {
+		return nil, err
+	}
+	isStandby := make(map[string]bool, len(standbyValidators))
+	for _, pub := range standbyValidators {
+		isStandby[string(pub.Bytes())] = true
+	}
+
+	var result []*ValidatorState
+	for _, validator := range getValidatorsFromStore(bc.store) {
+		key := string(validator.PublicKey.Bytes())
+		if validator.Registered || isStandby[key] {
+			result = append(result, validator)
+			delete(isStandby, key)
+		}
+	}
+	for _, pub := range standbyValidators {
+		if isStandby[string(pub.Bytes())] {
+			result = append(result, &ValidatorState{PublicKey: pub})
+		}
+	}
+	return result, nil
+}
+
 // GetConfig returns the config stored in the blockchain.
 func (bc *Blockchain) GetConfig() config.ProtocolConfiguration {
 	return bc.config
@@ -824,6 +1595,34 @@ func (bc *Blockchain) NetworkFee(t *transaction.Transaction) util.Fixed8 {
 
 // SystemFee returns system fee.
 func (bc *Blockchain) SystemFee(t *transaction.Transaction) util.Fixed8 {
+	switch data := t.Data.(type) {
+	case *transaction.InvocationTX:
+		// Invocation pays for the GAS it's allowed to spend rounded up
+		// to the whole GAS.
+		gas := data.Gas
+		if rem := gas % util.Fixed8FromInt64(1); rem > 0 {
+			gas += util.Fixed8FromInt64(1) - rem
+		}
+		return gas
+	case *transaction.RegisterTX:
+		if data.AssetType == transaction.GoverningToken || data.AssetType == transaction.UtilityToken {
+			return util.Fixed8(0)
+		}
+	case *transaction.IssueTX:
+		if t.Version >= 1 {
+			return util.Fixed8(0)
+		}
+		systemAssetsOnly := true
+		for _, out := range t.Outputs {
+			if out.AssetID != governingTokenTX().Hash() && out.AssetID != utilityTokenTX().Hash() {
+				systemAssetsOnly = false
+				break
+			}
+		}
+		if systemAssetsOnly {
+			return util.Fixed8(0)
+		}
+	}
 	return bc.GetConfig().SystemFee.TryGetValue(t.Type)
 }
 
@@ -838,24 +1637,80 @@ func (bc *Blockchain) GetMemPool() MemPool {
 	return bc.memPool
 }
 
-// VerifyBlock verifies block against its current state.
+// PoolTx adds the given (already verified) transaction to the memory pool
+// and notifies transaction subscribers about it.
+func (bc *Blockchain) PoolTx(t *transaction.Transaction) error {
+	if ok := bc.memPool.TryAdd(t.Hash(), NewPoolItem(t, bc)); !ok {
+		return ErrOOM
+	}
+	if atomic.LoadInt32(&bc.subscriberCount) > 0 {
+		bc.sendEvent(t)
+	}
+	return nil
+}
+
+// VerifyBlock verifies block against its current state. Its header is
+// checked against the previous one and NextConsensus is checked against
+// the validators calculated with the block's transactions.
 func (bc *Blockchain) VerifyBlock(block *Block) error {
 	prevHeader, err := bc.GetHeader(block.PrevHash)
 	if err != nil {
 		return errors.Wrap(err, "unable to get previous header")
 	}
-	if prevHeader.Index+1 != block.Index {
+	if err = bc.verifyHeader(block.Header(), prevHeader); err != nil {
+		return err
+	}
+	validators, err := bc.GetValidators(block.Transactions...)
+	if err != nil {
+		return errors.Wrap(err, "unable to get validators")
+	}
+	nextConsensus, err := getNextConsensusAddress(validators)
+	if err != nil {
+		return err
+	}
+	if !block.NextConsensus.Equals(nextConsensus) {
+		return errors.New("NextConsensus doesn't match the validators")
+	}
+	return nil
+}
+
+// verifyHeader verifies the header against the previous one: it has to be
+// linked to it, be newer and be signed by its NextConsensus.
+func (bc *Blockchain) verifyHeader(currHeader, prevHeader *Header) error {
+	if !prevHeader.Hash().Equals(currHeader.PrevHash) {
+		return errors.New("previous header hash doesn't match")
+	}
+	if prevHeader.Index+1 != currHeader.Index {
 		return errors.New("previous header index doesn't match")
 	}
-	if prevHeader.Timestamp >= block.Timestamp {
+	if prevHeader.Timestamp >= currHeader.Timestamp {
 		return errors.New("block is not newer than the previous one")
 	}
-	return bc.verifyBlockWitnesses(block, prevHeader)
+	return bc.verifyHeaderWitnesses(currHeader, prevHeader)
+}
+
+// verifyBlockConflicts checks that none of the transaction inputs is spent by
+// some other transaction of the block.
+func verifyBlockConflicts(t *transaction.Transaction, block *Block) error {
+	h := t.Hash()
+	for _, other := range block.Transactions {
+		if other.Hash().Equals(h) {
+			continue
+		}
+		for _, in := range t.Inputs {
+			for _, otherIn := range other.Inputs {
+				if in.PrevHash.Equals(otherIn.PrevHash) && in.PrevIndex == otherIn.PrevIndex {
+					return errors.Errorf("invalid transaction due to conflicts with block transaction %s", other.Hash().ReverseString())
+				}
+			}
+		}
+	}
+	return nil
 }
 
 // VerifyTx verifies whether a transaction is bonafide or not. Block parameter
 // is used for easy interop access and can be omitted for transactions that are
-// not yet added into any block.
+// not yet added into any block, only those are checked against the memory pool.
 // Golang implementation of Verify method in C# (https://github.com/neo-project/neo/blob/master/neo/Network/P2P/Payloads/Transaction.cs#L270).
 func (bc *Blockchain) VerifyTx(t *transaction.Transaction, block *Block) error {
 	if io.GetVarSize(t) > transaction.MaxTransactionSize {
@@ -864,8 +1719,15 @@ func (bc *Blockchain) VerifyTx(t *transaction.Transaction, block *Block) error {
 	if ok := bc.verifyInputs(t); !ok {
 		return errors.New("invalid transaction's inputs")
 	}
-	if ok := bc.memPool.Verify(t); !ok {
-		return errors.New("invalid transaction due to conflicts with the memory pool")
+	// Block transactions can be in the pool already, conflicts with
+	// the pool only matter for the ones not yet included in any block,
+	// block transactions are checked against each other instead.
+	if block == nil {
+		if ok := bc.memPool.Verify(t); !ok {
+			return errors.New("invalid transaction due to conflicts with the memory pool")
+		}
+	} else if err := verifyBlockConflicts(t, block); err != nil {
+		return err
 	}
 	if IsDoubleSpend(bc.store, t) {
 		return errors.New("invalid transaction caused by double spending")
@@ -876,6 +1738,11 @@ func (bc *Blockchain) VerifyTx(t *transaction.Transaction, block *Block) error {
 	if err := bc.verifyResults(t); err != nil {
 		return err
 	}
+	if t.Type == transaction.ClaimType {
+		if err := bc.verifyClaims(t, block); err != nil {
+			return err
+		}
+	}
 
 	for _, a := range t.Attributes {
 		if a.Usage == transaction.ECDH02 || a.Usage == transaction.ECDH03 {
@@ -887,9 +1754,14 @@ func (bc *Blockchain) VerifyTx(t *transaction.Transaction, block *Block) error {
 }
 
 func (bc *Blockchain) verifyInputs(t *transaction.Transaction) bool {
-	for i := 1; i < len(t.Inputs); i++ {
+	return inputsAreUnique(t.Inputs)
+}
+
+// inputsAreUnique checks that there are no duplicates among the given inputs.
+func inputsAreUnique(inputs []*transaction.Input) bool {
+	for i := 1; i < len(inputs); i++ {
 		for j := 0; j < i; j++ {
-			if t.Inputs[i].PrevHash == t.Inputs[j].PrevHash && t.Inputs[i].PrevIndex == t.Inputs[j].PrevIndex {
+			if inputs[i].PrevHash == inputs[j].PrevHash && inputs[i].PrevIndex == inputs[j].PrevIndex {
 				return false
 			}
 		}
@@ -898,6 +1770,65 @@ func (bc *Blockchain) verifyInputs(t *transaction.Transaction) bool {
 	return true
 }
 
+// otherTransactions returns the transactions the given one is checked for
+// conflicts with: the memory pool for the transactions not yet in any block
+// and the block transactions otherwise (just like C# does passing them as the
+// memory pool). The transaction itself has to be skipped by the caller.
+func (bc *Blockchain) otherTransactions(block *Block) []*transaction.Transaction {
+	if block == nil {
+		return bc.memPool.GetVerifiedTransactions()
+	}
+	return block.Transactions
+}
+
+// verifyClaims checks that the claim transaction doesn't claim the same coins
+// twice (including the claims of other transactions in the memory pool or,
+// for block transactions, in the same block) and that the amount of GAS it
+// issues equals the bonus for the claimed coins.
+func (bc *Blockchain) verifyClaims(t *transaction.Transaction, block *Block) error {
+	claim, ok := t.Data.(*transaction.ClaimTX)
+	if !ok {
+		return errors.New("wrong claim tx data")
+	}
+	if !inputsAreUnique(claim.Claims) {
+		return errors.New("claim tx has duplicate claims")
+	}
+	for _, ptx := range bc.otherTransactions(block) {
+		if ptx.Type != transaction.ClaimType || ptx.Hash().Equals(t.Hash()) {
+			continue
+		}
+		pclaim, ok := ptx.Data.(*transaction.ClaimTX)
+		if !ok {
+			continue
+		}
+		for _, pin := range pclaim.Claims {
+			for _, in := range claim.Claims {
+				if *pin == *in {
+					return fmt.Errorf("claim %s:%d conflicts with transaction %s", in.PrevHash.ReverseString(), in.PrevIndex, ptx.Hash().ReverseString())
+				}
+			}
+		}
+	}
+
+	var issued util.Fixed8
+	for _, r := range bc.GetTransactionResults(t) {
+		if r.Amount.LessThan(util.Fixed8(0)) && r.AssetID == utilityTokenTX().Hash() {
+			issued = -r.Amount
+		}
+	}
+	if issued == 0 {
+		return errors.New("claim tx doesn't issue GAS")
+	}
+	bonus, err := bc.CalculateBonus(claim.Claims)
+	if err != nil {
+		return errors.Wrap(err, "invalid claims")
+	}
+	if issued != bonus {
+		return fmt.Errorf("claim tx issues %s GAS, but %s is claimable", issued, bonus)
+	}
+	return nil
+}
+
 func (bc *Blockchain) verifyOutputs(t *transaction.Transaction) error {
 	for assetID, outputs := range t.GroupOutputByAssetID() {
 		assetState := bc.GetAssetState(assetID)
@@ -1118,16 +2049,19 @@ func (bc *Blockchain) spawnVMWithInterops(interopCtx *interopContext) *vm.VM {
 		}
 		return cs.Script
 	})
+	vm.SetPriceGetter(getPrice)
 	vm.RegisterInteropFuncs(interopCtx.getSystemInteropMap())
 	vm.RegisterInteropFuncs(interopCtx.getNeoInteropMap())
 	return vm
 }
 
 // GetTestVM returns a VM and a Store setup for a test run of some sort of code.
+// VM's GAS limit is set to FreeGAS.
 func (bc *Blockchain) GetTestVM() (*vm.VM, storage.Store) {
 	tmpStore := storage.NewMemCachedStore(bc.store)
 	systemInterop := newInteropContext(0x10, bc, tmpStore, nil, nil)
 	vm := bc.spawnVMWithInterops(systemInterop)
+	vm.SetGasLimit(FreeGAS)
 	return vm, tmpStore
 }
 
@@ -1148,8 +2082,12 @@ func (bc *Blockchain) verifyHashAgainstScript(hash util.Uint160, witness *transa
 		}
 	}
 
+	if !vm.IsPushOnly(witness.InvocationScript) {
+		return errors.New("invocation script is not push-only")
+	}
 	vm := bc.spawnVMWithInterops(interopCtx)
 	vm.SetCheckedHash(checkedHash.Bytes())
+	vm.SetGasLimit(FreeGAS)
 	vm.LoadScript(verification)
 	vm.LoadScript(witness.InvocationScript)
 	err := vm.Run()
@@ -1175,7 +2113,7 @@ func (bc *Blockchain) verifyHashAgainstScript(hash util.Uint160, witness *transa
 // transaction. It can reorder them by ScriptHash, because that's required to
 // match a slice of script hashes from the Blockchain. Block parameter
 // is used for easy interop access and can be omitted for transactions that are
-// not yet added into any block.
+// not yet added into any block, only those are checked against the memory pool.
 // Golang implementation of VerifyWitnesses method in C# (https://github.com/neo-project/neo/blob/master/neo/SmartContract/Helper.cs#L87).
 // Unfortunately the IVerifiable interface could not be implemented because we can't move the References method in blockchain.go to the transaction.go file.
 func (bc *Blockchain) verifyTxWitnesses(t *transaction.Transaction, block *Block) error {
@@ -1202,16 +2140,16 @@ func (bc *Blockchain) verifyTxWitnesses(t *transaction.Transaction, block *Block
 	return nil
 }
 
-// verifyBlockWitnesses is a block-specific implementation of VerifyWitnesses logic.
-func (bc *Blockchain) verifyBlockWitnesses(block *Block, prevHeader *Header) error {
+// verifyHeaderWitnesses is a block-specific implementation of VerifyWitnesses logic.
+func (bc *Blockchain) verifyHeaderWitnesses(currHeader, prevHeader *Header) error {
 	var hash util.Uint160
-	if prevHeader == nil && block.PrevHash.Equals(util.Uint256{}) {
-		hash = block.Script.ScriptHash()
+	if prevHeader == nil && currHeader.PrevHash.Equals(util.Uint256{}) {
+		hash = currHeader.Script.ScriptHash()
 	} else {
 		hash = prevHeader.NextConsensus
 	}
 	interopCtx := newInteropContext(0, bc, bc.store, nil, nil)
-	return bc.verifyHashAgainstScript(hash, block.Script, block.VerificationHash(), interopCtx)
+	return bc.verifyHashAgainstScript(hash, currHeader.Script, currHeader.VerificationHash(), interopCtx)
 }
 
 func hashAndIndexToBytes(h util.Uint256, index uint32) []byte {
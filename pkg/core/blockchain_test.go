@@ -1,10 +1,19 @@
 package core
 
 import (
+	"bytes"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -155,3 +164,643 @@ func TestGetTransaction(t *testing.T) {
 		assert.NoError(t, bc.persist())
 	}
 }
+
+func TestGetValidators(t *testing.T) {
+	bc := newTestChain(t)
+
+	standby, err := getValidators(bc.config)
+	require.NoError(t, err)
+	validators, err := bc.GetValidators()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, standby, validators)
+
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	pub := priv.PublicKey()
+
+	account := NewAccountState(randomUint160())
+	account.Balances[governingTokenTX().Hash()] = util.Fixed8FromInt64(1000)
+	require.NoError(t, putAccountStateIntoStore(bc.store, account))
+
+	buf := io.NewBufBinWriter()
+	buf.WriteVarUint(1)
+	pub.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+	enroll := &transaction.Transaction{
+		Type: transaction.EnrollmentType,
+		Data: &transaction.EnrollmentTX{PublicKey: pub},
+	}
+	vote := &transaction.Transaction{
+		Type: transaction.StateType,
+		Data: &transaction.StateTX{Descriptors: []*transaction.StateDescriptor{{
+			Type:  transaction.Account,
+			Key:   account.ScriptHash.Bytes(),
+			Value: buf.Bytes(),
+			Field: "Votes",
+		}}},
+	}
+
+	validators, err = bc.GetValidators(enroll, vote)
+	require.NoError(t, err)
+	require.Equal(t, len(standby), len(validators))
+	assert.Contains(t, validators, pub)
+	assert.NotContains(t, validators, standby[len(standby)-1])
+	assert.True(t, sort.IsSorted(keys.PublicKeys(validators)))
+
+	// Transactions passed to GetValidators are not persisted.
+	validators, err = bc.GetValidators()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, standby, validators)
+
+	// References to missing outputs are errors.
+	bad := &transaction.Transaction{
+		Type: transaction.ContractType,
+		Data: &transaction.ContractTX{},
+		Inputs: []*transaction.Input{{
+			PrevHash:  governingTokenTX().Hash(),
+			PrevIndex: 5,
+		}},
+	}
+	_, err = bc.GetValidators(bad)
+	require.Error(t, err)
+
+	bc.config.VerifyTransactions = false
+	require.Error(t, bc.AddBlock(newBlock(1, newMinerTX(), bad)))
+}
+
+func TestAddHeadersVerification(t *testing.T) {
+	bc := newTestChain(t)
+	h1 := newBlock(1).Header()
+
+	t.Run("BadPrevHash", func(t *testing.T) {
+		b := newBlock(2)
+		b.Index = 1
+		signBlock(b)
+		assert.Error(t, bc.AddHeaders(b.Header()))
+	})
+
+	t.Run("OldTimestamp", func(t *testing.T) {
+		b := newBlock(1)
+		b.PrevHash = h1.PrevHash
+		b.Timestamp = 1
+		signBlock(b)
+		assert.Error(t, bc.AddHeaders(b.Header()))
+	})
+
+	t.Run("BadWitness", func(t *testing.T) {
+		b := newBlock(1)
+		b.PrevHash = h1.PrevHash
+		b.ConsensusData++
+		_ = b.createHash()
+		assert.Error(t, bc.AddHeaders(b.Header()))
+	})
+
+	t.Run("NotPushOnly", func(t *testing.T) {
+		b := newBlock(1)
+		b.PrevHash = h1.PrevHash
+		signBlock(b)
+		script := new(bytes.Buffer)
+		require.NoError(t, vm.EmitInt(script, 0))
+		require.NoError(t, vm.EmitSyscall(script, "System.Blockchain.GetHeader"))
+		require.NoError(t, vm.EmitOpcode(script, vm.DROP))
+		b.Script.InvocationScript = append(script.Bytes(), b.Script.InvocationScript...)
+		assert.Error(t, bc.AddHeaders(b.Header()))
+	})
+
+	require.NoError(t, bc.AddHeaders(h1))
+	assert.Equal(t, uint32(1), bc.HeaderHeight())
+}
+
+func TestVerifyBlockNextConsensus(t *testing.T) {
+	bc := newTestChain(t)
+
+	b := newBlock(1, newMinerTX())
+	b.NextConsensus = util.Uint160{1, 2, 3}
+	signBlock(b)
+	assert.Error(t, bc.VerifyBlock(b))
+	assert.Error(t, bc.AddBlock(b))
+
+	newBlockPrevHash = bc.CurrentBlockHash()
+	b = newBlock(1, newMinerTX())
+	assert.NoError(t, bc.VerifyBlock(b))
+	assert.NoError(t, bc.AddBlock(b))
+}
+
+func TestClaims(t *testing.T) {
+	bc := newTestChain(t)
+	// Transactions are not signed here, only the state is checked.
+	bc.config.VerifyTransactions = false
+
+	genesis, err := bc.GetBlock(bc.GetHeaderHash(0))
+	require.NoError(t, err)
+	var issue *transaction.Transaction
+	for _, tx := range genesis.Transactions {
+		if tx.Type == transaction.IssueType {
+			issue = tx
+		}
+	}
+	require.NotNil(t, issue)
+	neo := issue.Outputs[0]
+	require.Equal(t, governingTokenTX().Hash(), neo.AssetID)
+
+	acc1, acc2 := randomUint160(), randomUint160()
+	tx1 := &transaction.Transaction{
+		Type:    transaction.ContractType,
+		Data:    &transaction.ContractTX{},
+		Inputs:  []*transaction.Input{{PrevHash: issue.Hash(), PrevIndex: 0}},
+		Outputs: []*transaction.Output{{AssetID: neo.AssetID, Amount: neo.Amount, ScriptHash: acc1}},
+	}
+	require.NoError(t, bc.AddBlock(newBlock(1, newMinerTX(), tx1)))
+	for i := uint32(2); i < 5; i++ {
+		require.NoError(t, bc.AddBlock(newBlock(i, newMinerTX())))
+	}
+	tx2 := &transaction.Transaction{
+		Type:    transaction.ContractType,
+		Data:    &transaction.ContractTX{},
+		Inputs:  []*transaction.Input{{PrevHash: tx1.Hash(), PrevIndex: 0}},
+		Outputs: []*transaction.Output{{AssetID: neo.AssetID, Amount: neo.Amount, ScriptHash: acc2}},
+	}
+	require.NoError(t, bc.AddBlock(newBlock(5, newMinerTX(), tx2)))
+
+	// 8 GAS per block for every NEO held from block 1 to block 5.
+	expected := util.Fixed8(neo.Amount.Int64Value() * 8 * 4)
+	claims := []*transaction.Input{{PrevHash: tx1.Hash(), PrevIndex: 0}}
+	bonus, err := bc.CalculateBonus(claims)
+	require.NoError(t, err)
+	assert.Equal(t, expected, bonus)
+
+	_, err = bc.CalculateBonus([]*transaction.Input{{PrevHash: tx2.Hash(), PrevIndex: 0}})
+	assert.Error(t, err)
+
+	coins, err := bc.GetClaimable(acc1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(coins))
+	assert.Equal(t, tx1.Hash(), coins[0].TxHash)
+	assert.Equal(t, uint32(1), coins[0].StartHeight)
+	assert.Equal(t, uint32(5), coins[0].EndHeight)
+	assert.Equal(t, tx1.Outputs[0], coins[0].Output)
+
+	// Only the account's own coins are indexed.
+	coins, err = bc.GetClaimable(acc2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(coins))
+
+	available, unavailable, err := bc.GetUnclaimed(acc1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, available)
+	assert.Equal(t, util.Fixed8(0), unavailable)
+
+	available, unavailable, err = bc.GetUnclaimed(acc2)
+	require.NoError(t, err)
+	assert.Equal(t, util.Fixed8(0), available)
+	assert.Equal(t, util.Fixed8(neo.Amount.Int64Value()*8), unavailable)
+
+	newClaim := func(amount util.Fixed8, claims ...*transaction.Input) *transaction.Transaction {
+		return &transaction.Transaction{
+			Type:    transaction.ClaimType,
+			Data:    &transaction.ClaimTX{Claims: claims},
+			Outputs: []*transaction.Output{{AssetID: utilityTokenTX().Hash(), Amount: amount, ScriptHash: acc1}},
+		}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.NoError(t, bc.verifyClaims(newClaim(expected, claims...), nil))
+	})
+
+	t.Run("TooMuch", func(t *testing.T) {
+		assert.Error(t, bc.verifyClaims(newClaim(expected+1, claims...), nil))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		assert.Error(t, bc.verifyClaims(newClaim(expected*2, claims[0], claims[0]), nil))
+	})
+
+	t.Run("Unspent", func(t *testing.T) {
+		in := &transaction.Input{PrevHash: tx2.Hash(), PrevIndex: 0}
+		assert.Error(t, bc.verifyClaims(newClaim(expected, in), nil))
+	})
+
+	t.Run("MemPoolConflict", func(t *testing.T) {
+		pooled := newClaim(expected, claims...)
+		pooled.Outputs[0].ScriptHash = acc2
+		require.True(t, bc.memPool.TryAdd(pooled.Hash(), NewPoolItem(pooled, bc)))
+		defer bc.memPool.Remove(pooled.Hash())
+		assert.Error(t, bc.verifyClaims(newClaim(expected, claims...), nil))
+
+		// Block transactions are not checked against the memory pool.
+		claim := newClaim(expected, claims...)
+		block := &Block{Transactions: []*transaction.Transaction{newMinerTX(), claim}}
+		assert.NoError(t, bc.verifyClaims(claim, block))
+	})
+
+	t.Run("BlockConflict", func(t *testing.T) {
+		claim := newClaim(expected, claims...)
+		other := newClaim(expected, claims...)
+		other.Outputs[0].ScriptHash = acc2
+		block := &Block{Transactions: []*transaction.Transaction{newMinerTX(), claim, other}}
+		assert.Error(t, bc.verifyClaims(claim, block))
+		assert.Error(t, bc.verifyClaims(other, block))
+	})
+
+	claim := newClaim(expected, claims...)
+	require.NoError(t, bc.AddBlock(newBlock(6, newMinerTX(), claim)))
+
+	_, err = bc.CalculateBonus(claims)
+	assert.Error(t, err)
+	coins, err = bc.GetClaimable(acc1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(coins))
+	assert.Error(t, bc.verifyClaims(newClaim(expected, claims...), nil))
+}
+
+func TestSystemFee(t *testing.T) {
+	bc := newTestChain(t)
+
+	t.Run("Invocation", func(t *testing.T) {
+		tx := transaction.NewInvocationTX([]byte{byte(vm.PUSH1)})
+		tx.Data.(*transaction.InvocationTX).Gas = util.Fixed8FromFloat(1.5)
+		assert.Equal(t, util.Fixed8FromInt64(2), bc.SystemFee(tx))
+	})
+
+	t.Run("RegisterSystemAsset", func(t *testing.T) {
+		assert.Equal(t, util.Fixed8(0), bc.SystemFee(governingTokenTX()))
+		assert.Equal(t, util.Fixed8(0), bc.SystemFee(utilityTokenTX()))
+	})
+
+	t.Run("IssueSystemAsset", func(t *testing.T) {
+		tx := &transaction.Transaction{
+			Type:    transaction.IssueType,
+			Data:    &transaction.IssueTX{},
+			Outputs: []*transaction.Output{{AssetID: governingTokenTX().Hash()}},
+		}
+		assert.Equal(t, util.Fixed8(0), bc.SystemFee(tx))
+		tx.Outputs[0].AssetID = randomUint256()
+		assert.Equal(t, util.Fixed8FromInt64(500), bc.SystemFee(tx))
+	})
+
+	t.Run("Enrollment", func(t *testing.T) {
+		tx := &transaction.Transaction{Type: transaction.EnrollmentType, Data: &transaction.EnrollmentTX{}}
+		assert.Equal(t, util.Fixed8FromInt64(1000), bc.SystemFee(tx))
+	})
+}
+
+func TestGetSystemFeeAmount(t *testing.T) {
+	bc := newTestChain(t)
+	// Transactions are not signed here, only the state is checked.
+	bc.config.VerifyTransactions = false
+
+	assert.Equal(t, uint32(0), bc.GetSystemFeeAmount(bc.GetHeaderHash(0)))
+
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	enroll := &transaction.Transaction{
+		Type: transaction.EnrollmentType,
+		Data: &transaction.EnrollmentTX{PublicKey: priv.PublicKey()},
+	}
+	b1 := newBlock(1, newMinerTX(), enroll)
+	require.NoError(t, bc.AddBlock(b1))
+	b2 := newBlock(2, newMinerTX())
+	require.NoError(t, bc.AddBlock(b2))
+
+	assert.Equal(t, uint32(1000), bc.GetSystemFeeAmount(b1.Hash()))
+	assert.Equal(t, uint32(1000), bc.GetSystemFeeAmount(b2.Hash()))
+	assert.Equal(t, uint32(0), bc.GetSystemFeeAmount(randomUint256()))
+
+	// Stored system fees don't affect the blocks themselves.
+	block, err := bc.GetBlock(b1.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, b1.Hash(), block.Hash())
+	assert.Equal(t, 2, len(block.Transactions))
+}
+
+func TestGetUnspents(t *testing.T) {
+	bc := newTestChain(t)
+	// Transactions are not signed here, only the state is checked.
+	bc.config.VerifyTransactions = false
+
+	genesis, err := bc.GetBlock(bc.GetHeaderHash(0))
+	require.NoError(t, err)
+	var issue *transaction.Transaction
+	for _, tx := range genesis.Transactions {
+		if tx.Type == transaction.IssueType {
+			issue = tx
+		}
+	}
+	require.NotNil(t, issue)
+	neo := issue.Outputs[0]
+
+	unspents, err := bc.GetUnspents(neo.ScriptHash)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(unspents))
+	assert.Equal(t, issue.Hash(), unspents[0].TxHash)
+	assert.Equal(t, *neo, *unspents[0].Output)
+
+	acc1, acc2 := randomUint160(), randomUint160()
+	half := neo.Amount.Div(2)
+	tx1 := &transaction.Transaction{
+		Type:   transaction.ContractType,
+		Data:   &transaction.ContractTX{},
+		Inputs: []*transaction.Input{{PrevHash: issue.Hash(), PrevIndex: 0}},
+		Outputs: []*transaction.Output{
+			{AssetID: neo.AssetID, Amount: half, ScriptHash: acc1},
+			{AssetID: neo.AssetID, Amount: neo.Amount - half, ScriptHash: acc1},
+		},
+	}
+	tx2 := &transaction.Transaction{
+		Type:    transaction.ContractType,
+		Data:    &transaction.ContractTX{},
+		Inputs:  []*transaction.Input{{PrevHash: tx1.Hash(), PrevIndex: 1}},
+		Outputs: []*transaction.Output{{AssetID: neo.AssetID, Amount: neo.Amount - half, ScriptHash: acc2}},
+	}
+	require.NoError(t, bc.AddBlock(newBlock(1, newMinerTX(), tx1)))
+	require.NoError(t, bc.AddBlock(newBlock(2, newMinerTX(), tx2)))
+
+	unspents, err = bc.GetUnspents(neo.ScriptHash)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(unspents))
+
+	unspents, err = bc.GetUnspents(acc1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(unspents))
+	assert.Equal(t, tx1.Hash(), unspents[0].TxHash)
+	assert.Equal(t, uint16(0), unspents[0].Index)
+	assert.Equal(t, half, unspents[0].Output.Amount)
+
+	unspents, err = bc.GetUnspents(acc2)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(unspents))
+	assert.Equal(t, tx2.Hash(), unspents[0].TxHash)
+}
+
+// newNEP5TransferTX returns an invocation transaction that emits NEP-5
+// transfer notification with the given parameters, the script fails
+// afterwards if fail is true.
+func newNEP5TransferTX(t *testing.T, from, to []byte, amount int64, fail bool) *transaction.Transaction {
+	script := new(bytes.Buffer)
+	require.NoError(t, vm.EmitInt(script, amount))
+	require.NoError(t, vm.EmitBytes(script, to))
+	require.NoError(t, vm.EmitBytes(script, from))
+	require.NoError(t, vm.EmitString(script, "transfer"))
+	require.NoError(t, vm.EmitInt(script, 4))
+	require.NoError(t, vm.EmitOpcode(script, vm.PACK))
+	require.NoError(t, vm.EmitSyscall(script, "Neo.Runtime.Notify"))
+	if fail {
+		require.NoError(t, vm.EmitOpcode(script, vm.THROW))
+	}
+	return transaction.NewInvocationTX(script.Bytes())
+}
+
+// newNEP5Contract returns the script of a simple NEP-5 token contract that
+// only supports balanceOf and transfer (without any checks, so that it can
+// also be used to mint tokens).
+func newNEP5Contract(t *testing.T) []byte {
+	balanceOf := new(bytes.Buffer)
+	require.NoError(t, vm.EmitOpcode(balanceOf, vm.DROP))
+	require.NoError(t, vm.EmitInt(balanceOf, 0))
+	require.NoError(t, vm.EmitOpcode(balanceOf, vm.PICKITEM))
+	require.NoError(t, vm.EmitSyscall(balanceOf, "Neo.Storage.GetContext"))
+	require.NoError(t, vm.EmitSyscall(balanceOf, "Neo.Storage.Get"))
+	require.NoError(t, vm.EmitOpcode(balanceOf, vm.RET))
+
+	script := new(bytes.Buffer)
+	require.NoError(t, vm.EmitOpcode(script, vm.DUP))
+	require.NoError(t, vm.EmitString(script, "balanceOf"))
+	require.NoError(t, vm.EmitOpcode(script, vm.EQUAL))
+	require.NoError(t, vm.EmitJmp(script, vm.JMPIFNOT, int16(3+balanceOf.Len())))
+	_, err := balanceOf.WriteTo(script)
+	require.NoError(t, err)
+
+	// transfer: args are [from, to, amount].
+	require.NoError(t, vm.EmitOpcode(script, vm.DROP))
+	for _, upd := range []struct {
+		index int64
+		op    vm.Instruction
+	}{{1, vm.ADD}, {0, vm.SUB}} {
+		require.NoError(t, vm.EmitOpcode(script, vm.DUP))
+		require.NoError(t, vm.EmitInt(script, upd.index))
+		require.NoError(t, vm.EmitOpcode(script, vm.PICKITEM))
+		require.NoError(t, vm.EmitOpcode(script, vm.DUP))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Storage.GetContext"))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Storage.Get"))
+		require.NoError(t, vm.EmitInt(script, 2))
+		require.NoError(t, vm.EmitOpcode(script, vm.PICK))
+		require.NoError(t, vm.EmitInt(script, 2))
+		require.NoError(t, vm.EmitOpcode(script, vm.PICKITEM))
+		require.NoError(t, vm.EmitOpcode(script, upd.op))
+		require.NoError(t, vm.EmitOpcode(script, vm.SWAP))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Storage.GetContext"))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Storage.Put"))
+	}
+	for i := int64(2); i >= 0; i-- {
+		require.NoError(t, vm.EmitInt(script, 2-i))
+		require.NoError(t, vm.EmitOpcode(script, vm.PICK))
+		require.NoError(t, vm.EmitInt(script, i))
+		require.NoError(t, vm.EmitOpcode(script, vm.PICKITEM))
+	}
+	require.NoError(t, vm.EmitString(script, "transfer"))
+	require.NoError(t, vm.EmitInt(script, 4))
+	require.NoError(t, vm.EmitOpcode(script, vm.PACK))
+	require.NoError(t, vm.EmitSyscall(script, "Neo.Runtime.Notify"))
+	require.NoError(t, vm.EmitOpcode(script, vm.DROP))
+	require.NoError(t, vm.EmitBool(script, true))
+	require.NoError(t, vm.EmitOpcode(script, vm.RET))
+	return script.Bytes()
+}
+
+// newDeployTX returns an invocation transaction that deploys the given
+// contract with storage.
+func newDeployTX(t *testing.T, contract []byte) *transaction.Transaction {
+	script := new(bytes.Buffer)
+	for _, s := range []string{"description", "email", "author", "1.0", "name"} {
+		require.NoError(t, vm.EmitString(script, s))
+	}
+	require.NoError(t, vm.EmitInt(script, int64(smartcontract.HasStorage)))
+	require.NoError(t, vm.EmitInt(script, int64(smartcontract.ByteArrayType)))
+	require.NoError(t, vm.EmitBytes(script, []byte{byte(smartcontract.StringType), byte(smartcontract.ArrayType)}))
+	require.NoError(t, vm.EmitBytes(script, contract))
+	require.NoError(t, vm.EmitSyscall(script, "Neo.Contract.Create"))
+	tx := transaction.NewInvocationTX(script.Bytes())
+	tx.Data.(*transaction.InvocationTX).Gas = util.Fixed8FromInt64(500)
+	return tx
+}
+
+// newNEP5ContractTransferTX returns an invocation transaction that calls
+// transfer method of the given token contract, the script fails afterwards
+// if fail is true.
+func newNEP5ContractTransferTX(t *testing.T, asset util.Uint160, from, to []byte, amount int64, fail bool) *transaction.Transaction {
+	script := new(bytes.Buffer)
+	require.NoError(t, vm.EmitInt(script, amount))
+	require.NoError(t, vm.EmitBytes(script, to))
+	require.NoError(t, vm.EmitBytes(script, from))
+	require.NoError(t, vm.EmitInt(script, 3))
+	require.NoError(t, vm.EmitOpcode(script, vm.PACK))
+	require.NoError(t, vm.EmitString(script, "transfer"))
+	require.NoError(t, vm.EmitAppCall(script, asset, false))
+	if fail {
+		require.NoError(t, vm.EmitOpcode(script, vm.THROW))
+	}
+	return transaction.NewInvocationTX(script.Bytes())
+}
+
+func TestNEP5Tracking(t *testing.T) {
+	bc := newTestChain(t)
+	// Transactions are not signed here, only the state is checked.
+	bc.config.VerifyTransactions = false
+
+	contract := newNEP5Contract(t)
+	asset := hash.Hash160(contract)
+	require.NoError(t, bc.AddBlock(newBlock(1, newMinerTX(), newDeployTX(t, contract))))
+	require.NotNil(t, bc.GetContractState(asset))
+
+	acc1, acc2 := randomUint160(), randomUint160()
+	mint := newNEP5ContractTransferTX(t, asset, []byte{}, acc1.Bytes(), 100, false)
+	// Transfer notifications from the scripts without balanceOf don't
+	// produce balances.
+	fake := newNEP5TransferTX(t, []byte{}, acc1.Bytes(), 5, false)
+	b2 := newBlock(2, newMinerTX(), mint, fake)
+	require.NoError(t, bc.AddBlock(b2))
+
+	transfer := newNEP5ContractTransferTX(t, asset, acc1.Bytes(), acc2.Bytes(), 30, false)
+	failed := newNEP5ContractTransferTX(t, asset, acc1.Bytes(), acc2.Bytes(), 50, true)
+	b3 := newBlock(3, newMinerTX(), transfer, failed)
+	require.NoError(t, bc.AddBlock(b3))
+
+	balances, err := bc.GetNEP5Balances(acc1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(balances))
+	assert.Equal(t, acc1, balances[0].Account)
+	assert.Equal(t, asset, balances[0].Asset)
+	assert.Equal(t, int64(70), balances[0].Amount.Int64())
+	assert.Equal(t, uint32(3), balances[0].LastUpdatedBlock)
+
+	balances, err = bc.GetNEP5Balances(acc2)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(balances))
+	assert.Equal(t, asset, balances[0].Asset)
+	assert.Equal(t, int64(30), balances[0].Amount.Int64())
+
+	transfers, err := bc.GetNEP5Transfers(acc1, 0, b3.Timestamp)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(transfers))
+	assert.Equal(t, util.Uint160{}, transfers[0].From)
+	assert.Equal(t, acc1, transfers[0].To)
+	assert.Equal(t, mint.Hash(), transfers[0].TxHash)
+	assert.Equal(t, uint32(2), transfers[0].Block)
+	assert.Equal(t, b2.Timestamp, transfers[0].Timestamp)
+	assert.Equal(t, fake.Hash(), transfers[1].TxHash)
+	assert.Equal(t, uint16(1), transfers[1].NotifyIndex)
+	assert.Equal(t, acc1, transfers[2].From)
+	assert.Equal(t, acc2, transfers[2].To)
+	assert.Equal(t, int64(30), transfers[2].Amount.Int64())
+	assert.Equal(t, uint16(0), transfers[2].NotifyIndex)
+
+	transfers, err = bc.GetNEP5Transfers(acc1, b3.Timestamp, b3.Timestamp)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(transfers))
+	assert.Equal(t, transfer.Hash(), transfers[0].TxHash)
+
+	transfers, err = bc.GetNEP5Transfers(acc2, 0, b2.Timestamp)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(transfers))
+
+	// Zero balances are dropped.
+	transfer = newNEP5ContractTransferTX(t, asset, acc1.Bytes(), acc2.Bytes(), 70, false)
+	require.NoError(t, bc.AddBlock(newBlock(4, newMinerTX(), transfer)))
+	balances, err = bc.GetNEP5Balances(acc1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(balances))
+	balances, err = bc.GetNEP5Balances(acc2)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(balances))
+	assert.Equal(t, int64(100), balances[0].Amount.Int64())
+	assert.Equal(t, uint32(4), balances[0].LastUpdatedBlock)
+}
+
+func TestNEP5TransferFromNotification(t *testing.T) {
+	from, to := randomUint160(), randomUint160()
+	newEvent := func(items ...vm.StackItem) NotificationEvent {
+		return NotificationEvent{ScriptHash: util.Uint160{1}, Item: vm.NewArrayItem(items)}
+	}
+
+	tr := nep5TransferFromNotification(newEvent(
+		vm.NewByteArrayItem([]byte("transfer")),
+		vm.NewByteArrayItem(from.Bytes()),
+		vm.NewByteArrayItem(to.Bytes()),
+		vm.NewByteArrayItem([]byte{0x10, 0x27}),
+	))
+	require.NotNil(t, tr)
+	assert.Equal(t, util.Uint160{1}, tr.Asset)
+	assert.Equal(t, from, tr.From)
+	assert.Equal(t, to, tr.To)
+	assert.Equal(t, int64(10000), tr.Amount.Int64())
+
+	tr = nep5TransferFromNotification(newEvent(
+		vm.NewByteArrayItem([]byte("transfer")),
+		vm.NewByteArrayItem(from.Bytes()),
+		vm.NewBoolItem(false),
+		vm.NewBigIntegerItem(1),
+	))
+	require.NotNil(t, tr)
+	assert.Equal(t, util.Uint160{}, tr.To)
+
+	assert.Nil(t, nep5TransferFromNotification(newEvent(
+		vm.NewByteArrayItem([]byte("approve")),
+		vm.NewByteArrayItem(from.Bytes()),
+		vm.NewByteArrayItem(to.Bytes()),
+		vm.NewBigIntegerItem(1),
+	)))
+	assert.Nil(t, nep5TransferFromNotification(newEvent(
+		vm.NewByteArrayItem([]byte("transfer")),
+		vm.NewByteArrayItem([]byte{1, 2, 3}),
+		vm.NewByteArrayItem(to.Bytes()),
+		vm.NewBigIntegerItem(1),
+	)))
+	assert.Nil(t, nep5TransferFromNotification(NotificationEvent{Item: vm.NewByteArrayItem([]byte("transfer"))}))
+}
+
+func TestSubscriptions(t *testing.T) {
+	bc := newTestChain(t)
+	bc.config.VerifyTransactions = false
+
+	blockCh := make(chan *Block, 1)
+	txCh := make(chan *transaction.Transaction, 1)
+	executionCh := make(chan *AppExecResult, 1)
+	bc.SubscribeForBlocks(blockCh)
+	bc.SubscribeForTransactions(txCh)
+	bc.SubscribeForExecutions(executionCh)
+
+	poolTx := newNEP5TransferTX(t, []byte{}, randomUint160().Bytes(), 1, false)
+	require.NoError(t, bc.PoolTx(poolTx))
+	select {
+	case tx := <-txCh:
+		assert.Equal(t, poolTx.Hash(), tx.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("no transaction event")
+	}
+
+	invocation := newNEP5TransferTX(t, []byte{}, randomUint160().Bytes(), 100, false)
+	b1 := newBlock(1, newMinerTX(), invocation)
+	require.NoError(t, bc.AddBlock(b1))
+	select {
+	case b := <-blockCh:
+		assert.Equal(t, b1.Hash(), b.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("no block event")
+	}
+	select {
+	case aer := <-executionCh:
+		assert.Equal(t, invocation.Hash(), aer.TxHash)
+		assert.Equal(t, "HALT", aer.VMState)
+		assert.Equal(t, 1, len(aer.Events))
+	case <-time.After(time.Second):
+		t.Fatal("no execution event")
+	}
+
+	bc.UnsubscribeFromBlocks(blockCh)
+	bc.UnsubscribeFromTransactions(txCh)
+	bc.UnsubscribeFromExecutions(executionCh)
+	require.NoError(t, bc.AddBlock(newBlock(2, newMinerTX())))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, len(blockCh))
+	assert.Equal(t, 0, len(executionCh))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/infinitete/neo-go-inf/config"
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/infinitete/neo-go-inf/pkg/vm"
 )
@@ -15,6 +16,8 @@ type Blockchainer interface {
 	AddHeaders(...*Header) error
 	AddBlock(*Block) error
 	BlockHeight() uint32
+	CalculateBonus(claims []*transaction.Input) (util.Fixed8, error)
+	CalculateClaimable(value util.Fixed8, startHeight, endHeight uint32) (util.Fixed8, util.Fixed8)
 	HeaderHeight() uint32
 	GetBlock(hash util.Uint256) (*Block, error)
 	GetContractState(hash util.Uint160) *ContractState
@@ -26,14 +29,32 @@ type Blockchainer interface {
 	HasTransaction(util.Uint256) bool
 	GetAssetState(util.Uint256) *AssetState
 	GetAccountState(util.Uint160) *AccountState
+	GetAppExecResult(util.Uint256) (*AppExecResult, error)
+	GetClaimable(util.Uint160) ([]*SpentCoin, error)
 	GetScriptHashesForVerifying(*transaction.Transaction) ([]util.Uint160, error)
 	GetStorageItem(scripthash util.Uint160, key []byte) *StorageItem
 	GetStorageItems(hash util.Uint160) (map[string]*StorageItem, error)
+	SeekStorageItems(hash util.Uint160, prefix, start []byte, f func(k []byte, si *StorageItem) bool) error
+	GetSystemFeeAmount(h util.Uint256) uint32
 	GetTestVM() (*vm.VM, storage.Store)
 	GetTransaction(util.Uint256) (*transaction.Transaction, uint32, error)
+	GetValidators(txes ...*transaction.Transaction) ([]*keys.PublicKey, error)
+	GetEnrollments() ([]*ValidatorState, error)
+	GetNEP5Balances(util.Uint160) ([]*NEP5Balance, error)
+	GetNEP5Transfers(account util.Uint160, start, end uint32) ([]*NEP5Transfer, error)
+	GetUnclaimed(util.Uint160) (util.Fixed8, util.Fixed8, error)
 	GetUnspentCoinState(util.Uint256) *UnspentCoinState
+	GetUnspents(util.Uint160) ([]*UnspentOutput, error)
+	PoolTx(*transaction.Transaction) error
 	References(t *transaction.Transaction) map[transaction.Input]*transaction.Output
 	Feer // fee interface
+	SubscribeForBlocks(ch chan<- *Block)
+	SubscribeForExecutions(ch chan<- *AppExecResult)
+	SubscribeForTransactions(ch chan<- *transaction.Transaction)
+	UnsubscribeFromBlocks(ch chan<- *Block)
+	UnsubscribeFromExecutions(ch chan<- *AppExecResult)
+	UnsubscribeFromTransactions(ch chan<- *transaction.Transaction)
+	VerifyBlock(*Block) error
 	VerifyTx(*transaction.Transaction, *Block) error
 	GetMemPool() MemPool
 }
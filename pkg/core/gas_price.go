@@ -0,0 +1,106 @@
+package core
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+const (
+	// interopGasRatio is the ratio between GAS and opcode/interop prices
+	// which are specified in 0.001 GAS.
+	interopGasRatio = 100000
+
+	// FreeGAS is the amount of GAS every script can consume for free.
+	FreeGAS = util.Fixed8(10 * 100000000)
+)
+
+// getPrice returns the price of executing the given opcode with the given
+// parameter. Some SYSCALLs have variable price depending on their arguments.
+func getPrice(v *vm.VM, op vm.Instruction, parameter []byte) util.Fixed8 {
+	if op <= vm.NOP {
+		return 0
+	}
+
+	switch op {
+	case vm.APPCALL, vm.TAILCALL:
+		return toFixed8(10)
+	case vm.SYSCALL:
+		return getSyscallPrice(v, string(parameter))
+	case vm.SHA1, vm.SHA256:
+		return toFixed8(10)
+	case vm.HASH160, vm.HASH256:
+		return toFixed8(20)
+	case vm.CHECKSIG, vm.VERIFY:
+		return toFixed8(100)
+	case vm.CHECKMULTISIG:
+		estack := v.Estack()
+		if estack.Len() == 0 {
+			return toFixed8(1)
+		}
+
+		var n int
+		item := estack.Peek(0)
+		switch item.Item().(type) {
+		case *vm.ArrayItem, *vm.StructItem:
+			n = len(item.Array())
+		default:
+			n = int(item.BigInt().Int64())
+		}
+		if n < 1 {
+			return toFixed8(1)
+		}
+		return toFixed8(int64(100 * n))
+	default:
+		return toFixed8(1)
+	}
+}
+
+// getSyscallPrice returns the price of the syscall with the given name. If
+// the interop function has no static price it's calculated from its
+// arguments.
+func getSyscallPrice(v *vm.VM, name string) util.Fixed8 {
+	ifunc := v.GetInterop(name)
+	if ifunc != nil && ifunc.Price > 0 {
+		return toFixed8(int64(ifunc.Price))
+	}
+
+	estack := v.Estack()
+	switch name {
+	case "Neo.Asset.Create", "AntShares.Asset.Create":
+		return util.Fixed8FromInt64(5000)
+	case "Neo.Asset.Renew", "AntShares.Asset.Renew":
+		years := byte(estack.Peek(1).BigInt().Int64())
+		return util.Fixed8FromInt64(int64(years) * 5000)
+	case "Neo.Contract.Create", "Neo.Contract.Migrate",
+		"AntShares.Contract.Create", "AntShares.Contract.Migrate":
+		properties := smartcontract.PropertyState(estack.Peek(3).BigInt().Int64())
+		return getDeploymentPrice(properties)
+	case "System.Storage.Put", "System.Storage.PutEx",
+		"Neo.Storage.Put", "AntShares.Storage.Put":
+		// 1 GAS per every started KiB of key and value.
+		keySize := len(estack.Peek(1).Bytes())
+		valSize := len(estack.Peek(2).Bytes())
+		return util.Fixed8FromInt64(int64((keySize+valSize-1)/1024 + 1))
+	default:
+		return toFixed8(1)
+	}
+}
+
+// getDeploymentPrice returns the price of contract deployment with the given
+// properties.
+func getDeploymentPrice(properties smartcontract.PropertyState) util.Fixed8 {
+	fee := int64(100)
+	if properties&smartcontract.HasStorage != 0 {
+		fee += 400
+	}
+	if properties&smartcontract.HasDynamicInvoke != 0 {
+		fee += 500
+	}
+	return util.Fixed8FromInt64(fee)
+}
+
+// toFixed8 converts the price in 0.001 GAS units into GAS.
+func toFixed8(n int64) util.Fixed8 {
+	return util.Fixed8(n * interopGasRatio)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPrice(t *testing.T) {
+	bc := newTestChain(t)
+	systemInterop := newInteropContext(0x10, bc, storage.NewMemoryStore(), nil, nil)
+	v := bc.spawnVMWithInterops(systemInterop)
+
+	t.Run("Opcodes", func(t *testing.T) {
+		require.EqualValues(t, 0, getPrice(v, vm.PUSH16, nil))
+		require.EqualValues(t, 0, getPrice(v, vm.NOP, nil))
+		require.EqualValues(t, toFixed8(1), getPrice(v, vm.ADD, nil))
+		require.EqualValues(t, toFixed8(10), getPrice(v, vm.APPCALL, nil))
+		require.EqualValues(t, toFixed8(10), getPrice(v, vm.SHA256, nil))
+		require.EqualValues(t, toFixed8(20), getPrice(v, vm.HASH160, nil))
+		require.EqualValues(t, toFixed8(100), getPrice(v, vm.CHECKSIG, nil))
+	})
+
+	t.Run("CheckMultisig", func(t *testing.T) {
+		v.Estack().Clear()
+		require.EqualValues(t, toFixed8(1), getPrice(v, vm.CHECKMULTISIG, nil))
+
+		v.Estack().PushVal(3)
+		require.EqualValues(t, toFixed8(300), getPrice(v, vm.CHECKMULTISIG, nil))
+
+		v.Estack().PushVal([]vm.StackItem{vm.NewByteArrayItem([]byte{1}), vm.NewByteArrayItem([]byte{2})})
+		require.EqualValues(t, toFixed8(200), getPrice(v, vm.CHECKMULTISIG, nil))
+	})
+
+	t.Run("SyscallStatic", func(t *testing.T) {
+		require.EqualValues(t, toFixed8(200), getPrice(v, vm.SYSCALL, []byte("Neo.Runtime.CheckWitness")))
+		require.EqualValues(t, toFixed8(1), getPrice(v, vm.SYSCALL, []byte("Unknown.Syscall")))
+	})
+
+	t.Run("StoragePut", func(t *testing.T) {
+		v.Estack().Clear()
+		v.Estack().PushVal(make([]byte, 1000)) // value
+		v.Estack().PushVal(make([]byte, 24))   // key
+		v.Estack().PushVal(vm.NewInteropItem(nil))
+		require.EqualValues(t, util.Fixed8FromInt64(1), getPrice(v, vm.SYSCALL, []byte("Neo.Storage.Put")))
+
+		v.Estack().Clear()
+		v.Estack().PushVal(make([]byte, 1001))
+		v.Estack().PushVal(make([]byte, 24))
+		v.Estack().PushVal(vm.NewInteropItem(nil))
+		require.EqualValues(t, util.Fixed8FromInt64(2), getPrice(v, vm.SYSCALL, []byte("System.Storage.Put")))
+	})
+
+	t.Run("ContractCreate", func(t *testing.T) {
+		v.Estack().Clear()
+		v.Estack().PushVal(int(smartcontract.HasStorage | smartcontract.HasDynamicInvoke))
+		v.Estack().PushVal(0)
+		v.Estack().PushVal([]byte{})
+		v.Estack().PushVal([]byte{})
+		require.EqualValues(t, util.Fixed8FromInt64(1000), getPrice(v, vm.SYSCALL, []byte("Neo.Contract.Create")))
+	})
+
+	t.Run("AssetRenew", func(t *testing.T) {
+		v.Estack().Clear()
+		v.Estack().PushVal(2)
+		v.Estack().PushVal(vm.NewInteropItem(nil))
+		require.EqualValues(t, util.Fixed8FromInt64(10000), getPrice(v, vm.SYSCALL, []byte("Neo.Asset.Renew")))
+	})
+}
@@ -71,8 +71,15 @@ func newBlock(index uint32, txs ...*transaction.Transaction) *Block {
 		Transactions: txs,
 	}
 	_ = b.rebuildMerkleRoot()
-	b.createHash()
+	signBlock(b)
 	newBlockPrevHash = b.Hash()
+	return b
+}
+
+// signBlock recalculates the hash of the block and signs it with the
+// validators keys.
+func signBlock(b *Block) {
+	_ = b.createHash()
 
 	invScript := make([]byte, 0)
 	for _, wif := range privNetKeys {
@@ -80,7 +87,7 @@ func newBlock(index uint32, txs ...*transaction.Transaction) *Block {
 		if err != nil {
 			panic(err)
 		}
-		b, err := b.getHashableData()
+		b, err := b.GetHashableData()
 		if err != nil {
 			panic(err)
 		}
@@ -93,7 +100,6 @@ func newBlock(index uint32, txs ...*transaction.Transaction) *Block {
 		invScript = append(invScript, sig...)
 	}
 	b.Script.InvocationScript = invScript
-	return b
 }
 
 func makeBlocks(n int) []*Block {
@@ -326,6 +326,20 @@ func (ic *interopContext) bcGetAsset(v *vm.VM) error {
 	return nil
 }
 
+// bcGetValidators returns validators of the next block.
+func (ic *interopContext) bcGetValidators(v *vm.VM) error {
+	validators, err := ic.bc.GetValidators()
+	if err != nil {
+		return err
+	}
+	items := make([]vm.StackItem, 0, len(validators))
+	for _, key := range validators {
+		items = append(items, vm.NewByteArrayItem(key.Bytes()))
+	}
+	v.Estack().PushVal(items)
+	return nil
+}
+
 // accountGetBalance returns balance for a given account.
 func (ic *interopContext) accountGetBalance(v *vm.VM) error {
 	accInterface := v.Estack().Pop().Value()
@@ -340,11 +340,13 @@ func (ic *interopContext) runtimeCheckWitness(v *vm.VM) error {
 	return nil
 }
 
-// runtimeNotify should pass stack item to the notify plugin to handle it, but
-// in neo-go the only meaningful thing to do here is to log.
+// runtimeNotify saves the notification (which can be just about anything) along
+// with the emitting script hash to be stored as a part of the execution result.
 func (ic *interopContext) runtimeNotify(v *vm.VM) error {
-	msg := fmt.Sprintf("%q", v.Estack().Pop().Bytes())
-	log.Infof("script %s notifies: %s", getContextScriptHash(v, 0), msg)
+	item := v.Estack().Pop().Item()
+	ne := NotificationEvent{ScriptHash: getContextScriptHash(v, 0), Item: item}
+	ic.notifications = append(ic.notifications, ne)
+	log.Debugf("script %s notifies: %s", ne.ScriptHash, item)
 	return nil
 }
 
@@ -280,6 +280,20 @@ func (mp *MemPool) GetVerifiedTransactions() []*transaction.Transaction {
 	return t
 }
 
+// GetUnverifiedTransactions returns a slice of all the unverified
+// transactions in the memory pool.
+func (mp *MemPool) GetUnverifiedTransactions() []*transaction.Transaction {
+	var t []*transaction.Transaction
+
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	for _, p := range mp.unverifiedTxn {
+		t = append(t, p.txn)
+	}
+
+	return t
+}
+
 // Verify verifies if the inputs of a transaction tx are already used in any other transaction in the memory pool.
 // If yes, the transaction tx is not a valid transaction and the function return false.
 // If no, the transaction tx is a valid transaction and the function return true.
@@ -0,0 +1,396 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+// nep5TransferEvent is the name of the notification emitted by NEP-5
+// contracts on token transfer.
+const nep5TransferEvent = "transfer"
+
+// NEP5Balance is the balance of the NEP-5 token held by some account.
+type NEP5Balance struct {
+	Account          util.Uint160
+	Asset            util.Uint160
+	Amount           *big.Int
+	LastUpdatedBlock uint32
+}
+
+// NEP5Transfer is a NEP-5 token transfer made by the contract notification.
+// From is empty for minted tokens and To is empty for burnt ones.
+type NEP5Transfer struct {
+	Asset       util.Uint160
+	From        util.Uint160
+	To          util.Uint160
+	Amount      *big.Int
+	Block       uint32
+	Timestamp   uint32
+	TxHash      util.Uint256
+	NotifyIndex uint16
+}
+
+type nep5BalanceKey struct {
+	account util.Uint160
+	asset   util.Uint160
+}
+
+// nep5Balances is a mapping between accounts/tokens and their balances.
+type nep5Balances map[nep5BalanceKey]*NEP5Balance
+
+// touch marks the balance of the given account for the given asset as
+// changed, the actual amount is retrieved from the contract later.
+func (b nep5Balances) touch(account, asset util.Uint160) {
+	key := nep5BalanceKey{account: account, asset: asset}
+	if _, ok := b[key]; !ok {
+		b[key] = &NEP5Balance{Account: account, Asset: asset}
+	}
+}
+
+// commit writes all NEP-5 balances to the given Store, zero balances are
+// deleted.
+func (b nep5Balances) commit(store storage.Store) error {
+	for _, balance := range b {
+		if balance.Amount.Sign() == 0 {
+			if err := store.Delete(nep5BalanceKeyBytes(balance.Account, balance.Asset)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := putNEP5BalanceIntoStore(store, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nep5BalanceKeyBytes(account, asset util.Uint160) []byte {
+	key := make([]byte, 0, 2*len(account))
+	key = append(key, account.Bytes()...)
+	key = append(key, asset.Bytes()...)
+	return storage.AppendPrefix(storage.IXNEP5Balances, key)
+}
+
+// getNEP5BalanceFromStore returns NEP5Balance of the given account for the
+// given asset from the given Store.
+func getNEP5BalanceFromStore(s storage.Store, account, asset util.Uint160) (*NEP5Balance, error) {
+	b, err := s.Get(nep5BalanceKeyBytes(account, asset))
+	if err != nil {
+		return nil, err
+	}
+	balance := &NEP5Balance{Account: account, Asset: asset}
+	r := io.NewBinReaderFromBuf(b)
+	balance.DecodeBinary(r)
+	if r.Err != nil {
+		return nil, fmt.Errorf("failed to decode (NEP5Balance): %s", r.Err)
+	}
+	return balance, nil
+}
+
+// getNEP5BalancesFromStore returns all NEP-5 balances of the given account
+// sorted by asset.
+func getNEP5BalancesFromStore(s storage.Store, account util.Uint160) ([]*NEP5Balance, error) {
+	var (
+		balances []*NEP5Balance
+		err      error
+	)
+	prefix := storage.AppendPrefix(storage.IXNEP5Balances, account.Bytes())
+	s.Seek(prefix, func(k, v []byte) {
+		if err != nil || len(k) != len(prefix)+len(account) {
+			return
+		}
+		balance := &NEP5Balance{Account: account}
+		copy(balance.Asset[:], k[len(prefix):])
+		r := io.NewBinReaderFromBuf(v)
+		balance.DecodeBinary(r)
+		if r.Err != nil {
+			err = fmt.Errorf("failed to decode (NEP5Balance): %s", r.Err)
+			return
+		}
+		balances = append(balances, balance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Asset.Less(balances[j].Asset)
+	})
+	return balances, nil
+}
+
+// putNEP5BalanceIntoStore puts given NEP5Balance into the given store.
+func putNEP5BalanceIntoStore(store storage.Store, balance *NEP5Balance) error {
+	buf := io.NewBufBinWriter()
+	balance.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	return store.Put(nep5BalanceKeyBytes(balance.Account, balance.Asset), buf.Bytes())
+}
+
+// EncodeBinary encodes NEP5Balance amount and last updated block to the
+// given BinWriter, account and asset are a part of the key.
+func (b *NEP5Balance) EncodeBinary(w *io.BinWriter) {
+	encodeBigInt(w, b.Amount)
+	w.WriteLE(b.LastUpdatedBlock)
+}
+
+// DecodeBinary decodes NEP5Balance amount and last updated block from the
+// given BinReader.
+func (b *NEP5Balance) DecodeBinary(r *io.BinReader) {
+	b.Amount = decodeBigInt(r)
+	r.ReadLE(&b.LastUpdatedBlock)
+}
+
+// nep5TransferKey returns the key for the transfer stored for the given
+// account. Timestamp goes first (BE) to keep transfers ordered by time.
+func nep5TransferKey(account util.Uint160, tr *NEP5Transfer) []byte {
+	key := make([]byte, len(account)+10)
+	copy(key, account.Bytes())
+	binary.BigEndian.PutUint32(key[len(account):], tr.Timestamp)
+	binary.BigEndian.PutUint32(key[len(account)+4:], tr.Block)
+	binary.BigEndian.PutUint16(key[len(account)+8:], tr.NotifyIndex)
+	return storage.AppendPrefix(storage.IXNEP5Transfers, key)
+}
+
+// putNEP5TransferIntoStore puts given NEP5Transfer into the given store for
+// both of its participants.
+func putNEP5TransferIntoStore(store storage.Store, tr *NEP5Transfer) error {
+	buf := io.NewBufBinWriter()
+	tr.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	for _, account := range []util.Uint160{tr.From, tr.To} {
+		if account.Equals(util.Uint160{}) {
+			continue
+		}
+		if err := store.Put(nep5TransferKey(account, tr), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getNEP5TransfersFromStore returns all NEP-5 transfers of the given account
+// made from start till end (inclusive) sorted by time.
+func getNEP5TransfersFromStore(s storage.Store, account util.Uint160, start, end uint32) ([]*NEP5Transfer, error) {
+	var (
+		transfers []*NEP5Transfer
+		err       error
+	)
+	prefix := storage.AppendPrefix(storage.IXNEP5Transfers, account.Bytes())
+	s.Seek(prefix, func(k, v []byte) {
+		if err != nil || len(k) < len(prefix)+4 {
+			return
+		}
+		ts := binary.BigEndian.Uint32(k[len(prefix):])
+		if ts < start || ts > end {
+			return
+		}
+		tr := new(NEP5Transfer)
+		r := io.NewBinReaderFromBuf(v)
+		tr.DecodeBinary(r)
+		if r.Err != nil {
+			err = fmt.Errorf("failed to decode (NEP5Transfer): %s", r.Err)
+			return
+		}
+		transfers = append(transfers, tr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].Block != transfers[j].Block {
+			return transfers[i].Block < transfers[j].Block
+		}
+		return transfers[i].NotifyIndex < transfers[j].NotifyIndex
+	})
+	return transfers, nil
+}
+
+// EncodeBinary implements the Serializable interface.
+func (tr *NEP5Transfer) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(tr.Asset)
+	w.WriteLE(tr.From)
+	w.WriteLE(tr.To)
+	encodeBigInt(w, tr.Amount)
+	w.WriteLE(tr.Block)
+	w.WriteLE(tr.Timestamp)
+	w.WriteLE(tr.TxHash)
+	w.WriteLE(tr.NotifyIndex)
+}
+
+// DecodeBinary implements the Serializable interface.
+func (tr *NEP5Transfer) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&tr.Asset)
+	r.ReadLE(&tr.From)
+	r.ReadLE(&tr.To)
+	tr.Amount = decodeBigInt(r)
+	r.ReadLE(&tr.Block)
+	r.ReadLE(&tr.Timestamp)
+	r.ReadLE(&tr.TxHash)
+	r.ReadLE(&tr.NotifyIndex)
+}
+
+// encodeBigInt writes the absolute value of the given integer as a byte
+// array followed by its sign.
+func encodeBigInt(w *io.BinWriter, i *big.Int) {
+	w.WriteBytes(i.Bytes())
+	w.WriteLE(i.Sign() < 0)
+}
+
+// decodeBigInt reads the integer written by encodeBigInt.
+func decodeBigInt(r *io.BinReader) *big.Int {
+	var neg bool
+	i := new(big.Int).SetBytes(r.ReadBytes())
+	r.ReadLE(&neg)
+	if neg {
+		i.Neg(i)
+	}
+	return i
+}
+
+// nep5TransferFromNotification returns the NEP-5 transfer if the given
+// notification is a NEP-5 `transfer` event (an array of the event name,
+// sender, receiver and amount), nil is returned otherwise.
+func nep5TransferFromNotification(ne NotificationEvent) *NEP5Transfer {
+	var items []vm.StackItem
+	switch t := ne.Item.(type) {
+	case *vm.ArrayItem:
+		items = t.Value().([]vm.StackItem)
+	case *vm.StructItem:
+		items = t.Value().([]vm.StackItem)
+	default:
+		return nil
+	}
+	if len(items) != 4 {
+		return nil
+	}
+	name, ok := items[0].Value().([]byte)
+	if !ok || string(name) != nep5TransferEvent {
+		return nil
+	}
+	from, ok := nep5AddressFromStackItem(items[1])
+	if !ok {
+		return nil
+	}
+	to, ok := nep5AddressFromStackItem(items[2])
+	if !ok {
+		return nil
+	}
+	var amount *big.Int
+	switch items[3].(type) {
+	case *vm.BigIntegerItem, *vm.ByteArrayItem:
+		amount = vm.NewElement(items[3]).BigInt()
+	default:
+		return nil
+	}
+	if amount.Sign() < 0 {
+		return nil
+	}
+	return &NEP5Transfer{
+		Asset:  ne.ScriptHash,
+		From:   from,
+		To:     to,
+		Amount: amount,
+	}
+}
+
+// nep5AddressFromStackItem decodes transfer participant address, empty
+// byte arrays (and false) are used for token minting and burning.
+func nep5AddressFromStackItem(item vm.StackItem) (util.Uint160, bool) {
+	switch t := item.(type) {
+	case *vm.BoolItem:
+		return util.Uint160{}, !t.Value().(bool)
+	case *vm.ByteArrayItem:
+		b := t.Value().([]byte)
+		if len(b) == 0 {
+			return util.Uint160{}, true
+		}
+		u, err := util.Uint160DecodeBytes(b)
+		return u, err == nil
+	default:
+		return util.Uint160{}, false
+	}
+}
+
+// processNEP5Transfers finds NEP-5 transfers in the given notifications of
+// the transaction, marks balances of the accounts involved as changed and
+// stores the transfers. notifyIndex is the number of transfers already
+// processed in this block.
+func processNEP5Transfers(store storage.Store, block *Block, txHash util.Uint256, events []NotificationEvent, balances nep5Balances, notifyIndex *uint16) error {
+	for _, ne := range events {
+		tr := nep5TransferFromNotification(ne)
+		if tr == nil {
+			continue
+		}
+		tr.Block = block.Index
+		tr.Timestamp = block.Timestamp
+		tr.TxHash = txHash
+		tr.NotifyIndex = *notifyIndex
+		*notifyIndex++
+
+		for _, account := range []util.Uint160{tr.From, tr.To} {
+			if !account.Equals(util.Uint160{}) {
+				balances.touch(account, tr.Asset)
+			}
+		}
+		if err := putNEP5TransferIntoStore(store, tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateNEP5Balances sets the changed balances to the ones returned by the
+// balanceOf method of the token contracts (invoked with the given store
+// state), just like C# RpcNep5Tracker does. Balances that can't be retrieved
+// are left as is.
+func (bc *Blockchain) updateNEP5Balances(store storage.Store, block *Block, balances nep5Balances) error {
+	for key, balance := range balances {
+		script := new(bytes.Buffer)
+		if err := vm.EmitBytes(script, balance.Account.Bytes()); err != nil {
+			return err
+		}
+		if err := vm.EmitInt(script, 1); err != nil {
+			return err
+		}
+		if err := vm.EmitOpcode(script, vm.PACK); err != nil {
+			return err
+		}
+		if err := vm.EmitString(script, "balanceOf"); err != nil {
+			return err
+		}
+		if err := vm.EmitAppCall(script, balance.Asset, false); err != nil {
+			return err
+		}
+
+		systemInterop := newInteropContext(0x10, bc, store, block, nil)
+		v := bc.spawnVMWithInterops(systemInterop)
+		// C# node gives 1 GAS on top of the free amount for this call.
+		v.SetGasLimit(FreeGAS + util.Fixed8FromInt64(1))
+		v.LoadScript(script.Bytes())
+		_ = v.Run()
+		if v.HasFailed() || v.Estack().Len() == 0 {
+			delete(balances, key)
+			continue
+		}
+		switch item := v.Estack().Pop(); item.Item().(type) {
+		case *vm.BigIntegerItem, *vm.ByteArrayItem:
+			balance.Amount = item.BigInt()
+			balance.LastUpdatedBlock = block.Index
+		default:
+			delete(balances, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+// NotificationEvent is a tuple of scripthash that emitted the StackItem as a
+// notification and that item itself.
+type NotificationEvent struct {
+	ScriptHash util.Uint160
+	Item       vm.StackItem
+}
+
+// AppExecResult represents the result of the script execution, gathering
+// together all resulting notifications, state, stack and other metadata.
+type AppExecResult struct {
+	TxHash      util.Uint256
+	Trigger     byte
+	VMState     string
+	GasConsumed util.Fixed8
+	Stack       []vm.StackItem
+	Events      []NotificationEvent
+}
+
+// putAppExecResultIntoStore puts given application execution result into the
+// given store.
+func putAppExecResultIntoStore(s storage.Store, aer *AppExecResult) error {
+	buf := io.NewBufBinWriter()
+	aer.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	key := storage.AppendPrefix(storage.STNotification, aer.TxHash.Bytes())
+	return s.Put(key, buf.Bytes())
+}
+
+// getAppExecResultFromStore gets application execution result from the
+// given store.
+func getAppExecResultFromStore(s storage.Store, hash util.Uint256) (*AppExecResult, error) {
+	aer := &AppExecResult{}
+	key := storage.AppendPrefix(storage.STNotification, hash.Bytes())
+	if b, err := s.Get(key); err == nil {
+		r := io.NewBinReaderFromBuf(b)
+		aer.DecodeBinary(r)
+		if r.Err != nil {
+			return nil, r.Err
+		}
+	} else {
+		return nil, err
+	}
+	return aer, nil
+}
+
+// EncodeBinary implements the Serializable interface.
+func (ne *NotificationEvent) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(ne.ScriptHash)
+	encodeStackItem(ne.Item, w)
+}
+
+// encodeStackItem encodes the item with vm.EncodeBinaryStackItem, items too
+// big to be encoded are stored as (empty) interop items, so that they don't
+// prevent the block from being stored.
+func encodeStackItem(item vm.StackItem, w *io.BinWriter) {
+	buf := io.NewBufBinWriter()
+	vm.EncodeBinaryStackItem(item, buf.BinWriter)
+	if buf.Err != nil {
+		vm.EncodeBinaryStackItem(vm.NewInteropItem(nil), w)
+		return
+	}
+	w.WriteLE(buf.Bytes())
+}
+
+// DecodeBinary implements the Serializable interface.
+func (ne *NotificationEvent) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&ne.ScriptHash)
+	ne.Item = vm.DecodeBinaryStackItem(r)
+}
+
+// EncodeBinary implements the Serializable interface.
+func (aer *AppExecResult) EncodeBinary(w *io.BinWriter) {
+	w.WriteLE(aer.TxHash)
+	w.WriteLE(aer.Trigger)
+	w.WriteString(aer.VMState)
+	w.WriteLE(aer.GasConsumed)
+	w.WriteVarUint(uint64(len(aer.Stack)))
+	for _, item := range aer.Stack {
+		encodeStackItem(item, w)
+	}
+	w.WriteVarUint(uint64(len(aer.Events)))
+	for i := range aer.Events {
+		aer.Events[i].EncodeBinary(w)
+	}
+}
+
+// DecodeBinary implements the Serializable interface.
+func (aer *AppExecResult) DecodeBinary(r *io.BinReader) {
+	r.ReadLE(&aer.TxHash)
+	r.ReadLE(&aer.Trigger)
+	aer.VMState = r.ReadString()
+	r.ReadLE(&aer.GasConsumed)
+	lenStack := r.ReadVarUint()
+	aer.Stack = make([]vm.StackItem, lenStack)
+	for i := range aer.Stack {
+		aer.Stack[i] = vm.DecodeBinaryStackItem(r)
+	}
+	lenEvents := r.ReadVarUint()
+	aer.Events = make([]NotificationEvent, lenEvents)
+	for i := range aer.Events {
+		aer.Events[i].DecodeBinary(r)
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeNotificationEvent(t *testing.T) {
+	event := &NotificationEvent{
+		ScriptHash: randomUint160(),
+		Item:       vm.NewBoolItem(true),
+	}
+
+	buf := io.NewBufBinWriter()
+	event.EncodeBinary(buf.BinWriter)
+	assert.Nil(t, buf.Err)
+
+	eventDecoded := &NotificationEvent{}
+	reader := io.NewBinReaderFromBuf(buf.Bytes())
+	eventDecoded.DecodeBinary(reader)
+	assert.Nil(t, reader.Err)
+	assert.Equal(t, event, eventDecoded)
+}
+
+func TestEncodeDecodeAppExecResult(t *testing.T) {
+	appExecResult := &AppExecResult{
+		TxHash:      randomUint256(),
+		Trigger:     0x10,
+		VMState:     "HALT",
+		GasConsumed: 10,
+		Stack:       []vm.StackItem{vm.NewByteArrayItem([]byte{1, 2, 3})},
+		Events: []NotificationEvent{{
+			ScriptHash: randomUint160(),
+			Item:       vm.NewByteArrayItem([]byte("transfer")),
+		}},
+	}
+
+	buf := io.NewBufBinWriter()
+	appExecResult.EncodeBinary(buf.BinWriter)
+	assert.Nil(t, buf.Err)
+
+	appExecResultDecoded := &AppExecResult{}
+	reader := io.NewBinReaderFromBuf(buf.Bytes())
+	appExecResultDecoded.DecodeBinary(reader)
+	assert.Nil(t, reader.Err)
+	assert.Equal(t, appExecResult, appExecResultDecoded)
+}
+
+func TestEncodeDecodeNotificationEventTooBig(t *testing.T) {
+	item := vm.StackItem(vm.NewByteArrayItem([]byte{1, 2, 3}))
+	for i := 0; i < 64; i++ {
+		item = vm.NewArrayItem([]vm.StackItem{item, item})
+	}
+	event := &NotificationEvent{ScriptHash: randomUint160(), Item: item}
+
+	buf := io.NewBufBinWriter()
+	event.EncodeBinary(buf.BinWriter)
+	assert.Nil(t, buf.Err)
+
+	eventDecoded := &NotificationEvent{}
+	reader := io.NewBinReaderFromBuf(buf.Bytes())
+	eventDecoded.DecodeBinary(reader)
+	assert.Nil(t, reader.Err)
+	assert.Equal(t, event.ScriptHash, eventDecoded.ScriptHash)
+	assert.IsType(t, (*vm.InteropItem)(nil), eventDecoded.Item)
+}
+
+func TestPutGetAppExecResult(t *testing.T) {
+	s := storage.NewMemoryStore()
+	appExecResult := &AppExecResult{
+		TxHash:  randomUint256(),
+		Trigger: 0x10,
+		VMState: "FAULT",
+		Stack:   []vm.StackItem{},
+		Events:  []NotificationEvent{},
+	}
+	assert.NoError(t, putAppExecResultIntoStore(s, appExecResult))
+	aer, err := getAppExecResultFromStore(s, appExecResult.TxHash)
+	assert.NoError(t, err)
+	assert.Equal(t, appExecResult, aer)
+
+	_, err = getAppExecResultFromStore(s, randomUint256())
+	assert.Equal(t, storage.ErrKeyNotFound, err)
+}
@@ -1,9 +1,11 @@
 package core
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 )
@@ -12,20 +14,19 @@ import (
 // coin state.
 type SpentCoins map[util.Uint256]*SpentCoinState
 
+// getAndUpdate retrieves SpentCoinState from temporary or persistent Store
+// and return it. If it's not present in both stores, returns a new
+// SpentCoinState.
 func (s SpentCoins) getAndUpdate(store storage.Store, hash util.Uint256) (*SpentCoinState, error) {
 	if spent, ok := s[hash]; ok {
 		return spent, nil
 	}
 
-	spent := &SpentCoinState{}
-	key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
-	if b, err := store.Get(key); err == nil {
-		r := io.NewBinReaderFromBuf(b)
-		spent.DecodeBinary(r)
-		if r.Err != nil {
-			return nil, fmt.Errorf("failed to decode (UnspentCoinState): %s", r.Err)
+	spent, err := getSpentCoinStateFromStore(store, hash)
+	if err != nil {
+		if err != storage.ErrKeyNotFound {
+			return nil, err
 		}
-	} else {
 		spent = &SpentCoinState{
 			items: make(map[uint16]uint32),
 		}
@@ -35,6 +36,22 @@ func (s SpentCoins) getAndUpdate(store storage.Store, hash util.Uint256) (*Spent
 	return spent, nil
 }
 
+// getSpentCoinStateFromStore retrieves SpentCoinState from the given store.
+func getSpentCoinStateFromStore(store storage.Store, hash util.Uint256) (*SpentCoinState, error) {
+	key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
+	b, err := store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	spent := &SpentCoinState{}
+	r := io.NewBinReaderFromBuf(b)
+	spent.DecodeBinary(r)
+	if r.Err != nil {
+		return nil, fmt.Errorf("failed to decode (SpentCoinState): %s", r.Err)
+	}
+	return spent, nil
+}
+
 // putSpentCoinStateIntoStore puts given SpentCoinState into the given store.
 func putSpentCoinStateIntoStore(store storage.Store, hash util.Uint256, scs *SpentCoinState) error {
 	buf := io.NewBufBinWriter()
@@ -46,8 +63,17 @@ func putSpentCoinStateIntoStore(store storage.Store, hash util.Uint256, scs *Spe
 	return store.Put(key, buf.Bytes())
 }
 
+// commit writes all spent coin states to the given Store. States that have
+// no unclaimed items left are removed.
 func (s SpentCoins) commit(store storage.Store) error {
 	for hash, state := range s {
+		if len(state.items) == 0 {
+			key := storage.AppendPrefix(storage.STSpentCoin, hash.BytesReverse())
+			if err := store.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := putSpentCoinStateIntoStore(store, hash, state); err != nil {
 			return err
 		}
@@ -55,6 +81,73 @@ func (s SpentCoins) commit(store storage.Store) error {
 	return nil
 }
 
+// SpentCoin represents a spent governing token output along with the heights
+// it was created and spent at.
+type SpentCoin struct {
+	TxHash      util.Uint256
+	Index       uint16
+	Output      *transaction.Output
+	StartHeight uint32
+	EndHeight   uint32
+}
+
+// spentIndexKey returns the key of the given output in the index of the
+// spent but not yet claimed outputs of the account it belongs to.
+func spentIndexKey(scriptHash util.Uint160, hash util.Uint256, index uint16) []byte {
+	key := storage.AppendPrefix(storage.IXSpentCoins, scriptHash.Bytes())
+	key = append(key, hash.BytesReverse()...)
+	return append(key, byte(index), byte(index>>8))
+}
+
+// putSpentIntoIndex adds the given output spent at the given height to the
+// index of the spent outputs of the account it belongs to.
+func putSpentIntoIndex(store storage.Store, hash util.Uint256, index uint16, out *transaction.Output, height uint32) error {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	return store.Put(spentIndexKey(out.ScriptHash, hash, index), value)
+}
+
+// deleteSpentFromIndex removes the given output from the index of the spent
+// outputs of the account it belongs to.
+func deleteSpentFromIndex(store storage.Store, hash util.Uint256, index uint16, out *transaction.Output) error {
+	return store.Delete(spentIndexKey(out.ScriptHash, hash, index))
+}
+
+// getSpentsFromIndex returns spent but not yet claimed outputs of the given
+// account. Only the transaction hash, output index and end height are set
+// for the coins returned.
+func getSpentsFromIndex(store storage.Store, scriptHash util.Uint160) ([]*SpentCoin, error) {
+	var (
+		coins   []*SpentCoin
+		err     error
+		prefix  = storage.AppendPrefix(storage.IXSpentCoins, scriptHash.Bytes())
+		hashLen = len(util.Uint256{})
+	)
+	store.Seek(prefix, func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		if len(k) != len(prefix)+hashLen+2 || len(v) != 4 {
+			err = fmt.Errorf("bad spent index entry length: %d, %d", len(k), len(v))
+			return
+		}
+		var hash util.Uint256
+		hash, err = util.Uint256DecodeReverseBytes(k[len(prefix) : len(prefix)+hashLen])
+		if err != nil {
+			return
+		}
+		coins = append(coins, &SpentCoin{
+			TxHash:    hash,
+			Index:     binary.LittleEndian.Uint16(k[len(k)-2:]),
+			EndHeight: binary.LittleEndian.Uint32(v),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
 // SpentCoinState represents the state of a spent coin.
 type SpentCoinState struct {
 	txHash   util.Uint256
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"bytes"
+
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -68,6 +70,21 @@ func (s *LevelDBStore) Seek(key []byte, f func(k, v []byte)) {
 	iter.Release()
 }
 
+// SeekFrom implements the Store interface.
+func (s *LevelDBStore) SeekFrom(prefix, start []byte, f func(k, v []byte) bool) {
+	rng := util.BytesPrefix(prefix)
+	if bytes.Compare(start, rng.Start) > 0 {
+		rng.Start = start
+	}
+	iter := s.db.NewIterator(rng, nil)
+	for iter.Next() {
+		if !f(iter.Key(), iter.Value()) {
+			break
+		}
+	}
+	iter.Release()
+}
+
 // Batch implements the Batch interface and returns a leveldb
 // compatible Batch.
 func (s *LevelDBStore) Batch() Batch {
@@ -50,6 +50,44 @@ func (s *MemCachedStore) Seek(key []byte, f func(k, v []byte)) {
 	})
 }
 
+// SeekFrom implements the Store interface.
+func (s *MemCachedStore) SeekFrom(prefix, start []byte, f func(k, v []byte) bool) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	// Cached keys are merged into the persistent ones keeping the order.
+	cached := s.sortedKeys(prefix, start)
+	stopped := false
+	s.ps.SeekFrom(prefix, start, func(k, v []byte) bool {
+		elem := string(k)
+		for len(cached) > 0 && cached[0] < elem {
+			if !f([]byte(cached[0]), s.mem[cached[0]]) {
+				stopped = true
+				return false
+			}
+			cached = cached[1:]
+		}
+		if _, present := s.mem[elem]; present {
+			return true
+		}
+		if _, present := s.del[elem]; present {
+			return true
+		}
+		if !f(k, v) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped {
+		return
+	}
+	for _, k := range cached {
+		if !f([]byte(k), s.mem[k]) {
+			return
+		}
+	}
+}
+
 // Persist flushes all the MemoryStore contents into the (supposedly) persistent
 // store ps.
 func (s *MemCachedStore) Persist() (int, error) {
@@ -136,6 +136,34 @@ func TestCachedSeek(t *testing.T) {
 	}
 }
 
+func TestCachedSeekFrom(t *testing.T) {
+	var (
+		ps = NewMemoryStore()
+		ts = NewMemCachedStore(ps)
+	)
+	for _, k := range []string{"fa", "fc", "fe", "fg"} {
+		require.NoError(t, ps.Put([]byte(k), []byte("lower")))
+	}
+	require.NoError(t, ts.Delete([]byte("fc")))
+	require.NoError(t, ts.Put([]byte("fe"), []byte("updated")))
+	require.NoError(t, ts.Put([]byte("fb"), []byte("new")))
+	require.NoError(t, ts.Put([]byte("fh"), []byte("new")))
+
+	var found []string
+	ts.SeekFrom([]byte("f"), []byte("fb"), func(k, v []byte) bool {
+		found = append(found, string(k)+"="+string(v))
+		return true
+	})
+	assert.Equal(t, []string{"fb=new", "fe=updated", "fg=lower", "fh=new"}, found)
+
+	found = nil
+	ts.SeekFrom([]byte("f"), nil, func(k, v []byte) bool {
+		found = append(found, string(k))
+		return len(found) < 3
+	})
+	assert.Equal(t, []string{"fa", "fb", "fe"}, found)
+}
+
 func newMemCachedStoreForTesting(t *testing.T) Store {
 	return NewMemCachedStore(NewMemoryStore())
 }
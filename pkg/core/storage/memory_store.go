@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"sort"
 	"strings"
 	"sync"
 )
@@ -104,6 +105,28 @@ func (s *MemoryStore) Seek(key []byte, f func(k, v []byte)) {
 	}
 }
 
+// SeekFrom implements the Store interface.
+func (s *MemoryStore) SeekFrom(prefix, start []byte, f func(k, v []byte) bool) {
+	for _, k := range s.sortedKeys(prefix, start) {
+		if !f([]byte(k), s.mem[k]) {
+			return
+		}
+	}
+}
+
+// sortedKeys returns the keys with the given prefix that are not less than
+// start in ascending order.
+func (s *MemoryStore) sortedKeys(prefix, start []byte) []string {
+	var keys []string
+	for k := range s.mem {
+		if strings.HasPrefix(k, string(prefix)) && k >= string(start) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Batch implements the Batch interface and returns a compatible Batch.
 func (s *MemoryStore) Batch() Batch {
 	return newMemoryBatch()
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/go-redis/redis"
 )
@@ -83,6 +84,24 @@ func (s *RedisStore) Seek(k []byte, f func(k, v []byte)) {
 	}
 }
 
+// SeekFrom implements the Store interface.
+func (s *RedisStore) SeekFrom(prefix, start []byte, f func(k, v []byte) bool) {
+	var keys []string
+	iter := s.client.Scan(0, fmt.Sprintf("%s*", prefix), 0).Iterator()
+	for iter.Next() {
+		if key := iter.Val(); key >= string(start) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val, _ := s.client.Get(key).Result()
+		if !f([]byte(key), []byte(val)) {
+			return
+		}
+	}
+}
+
 // Close implements the Store interface.
 func (s *RedisStore) Close() error {
 	return s.client.Close()
@@ -14,10 +14,15 @@ const (
 	STSpentCoin       KeyPrefix = 0x45
 	STValidator       KeyPrefix = 0x48
 	STAsset           KeyPrefix = 0x4c
+	STNotification    KeyPrefix = 0x4d
 	STContract        KeyPrefix = 0x50
 	STStorage         KeyPrefix = 0x70
 	IXHeaderHashList  KeyPrefix = 0x80
 	IXValidatorsCount KeyPrefix = 0x90
+	IXUnspentCoins    KeyPrefix = 0x91
+	IXNEP5Balances    KeyPrefix = 0x92
+	IXNEP5Transfers   KeyPrefix = 0x93
+	IXSpentCoins      KeyPrefix = 0x94
 	SYSCurrentBlock   KeyPrefix = 0xc0
 	SYSCurrentHeader  KeyPrefix = 0xc1
 	SYSVersion        KeyPrefix = 0xf0
@@ -37,6 +42,10 @@ type (
 		Put(k, v []byte) error
 		PutBatch(Batch) error
 		Seek(k []byte, f func(k, v []byte))
+		// SeekFrom calls f for the keys with the given prefix that are
+		// not less than start in ascending order, it stops once f
+		// returns false.
+		SeekFrom(prefix, start []byte, f func(k, v []byte) bool)
 		Close() error
 	}
 
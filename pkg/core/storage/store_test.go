@@ -18,6 +18,9 @@ var (
 		STStorage,
 		IXHeaderHashList,
 		IXValidatorsCount,
+		IXUnspentCoins,
+		IXNEP5Balances,
+		IXNEP5Transfers,
 		SYSCurrentBlock,
 		SYSCurrentHeader,
 		SYSVersion,
@@ -34,6 +37,9 @@ var (
 		0x70,
 		0x80,
 		0x90,
+		0x91,
+		0x92,
+		0x93,
 		0xc0,
 		0xc1,
 		0xf0,
@@ -121,6 +121,27 @@ func testStoreSeek(t *testing.T, s Store) {
 	require.NoError(t, s.Close())
 }
 
+func testStoreSeekFrom(t *testing.T, s Store) {
+	for _, k := range []string{"doo", "faa", "fee", "foo", "foox", "mew"} {
+		require.NoError(t, s.Put([]byte(k), []byte("v"+k)))
+	}
+	seek := func(prefix, start string, max int) []string {
+		var keys []string
+		s.SeekFrom([]byte(prefix), []byte(start), func(k, v []byte) bool {
+			assert.Equal(t, "v"+string(k), string(v))
+			keys = append(keys, string(k))
+			return len(keys) < max
+		})
+		return keys
+	}
+	assert.Equal(t, []string{"faa", "fee", "foo", "foox"}, seek("f", "", 10))
+	assert.Equal(t, []string{"faa", "fee"}, seek("f", "", 2))
+	assert.Equal(t, []string{"foo", "foox"}, seek("f", "fef", 10))
+	assert.Equal(t, []string{"foo"}, seek("f", "foo", 1))
+	assert.Equal(t, []string(nil), seek("f", "g", 10))
+	require.NoError(t, s.Close())
+}
+
 func testStoreDeleteNonExistent(t *testing.T, s Store) {
 	key := []byte("sparse")
 
@@ -227,6 +248,7 @@ func TestAllDBs(t *testing.T) {
 	}
 	var tests = []dbTestFunction{testStoreClose, testStorePutAndGet,
 		testStoreGetNonExistent, testStorePutBatch, testStoreSeek,
+		testStoreSeekFrom,
 		testStoreDeleteNonExistent, testStorePutAndDelete,
 		testStorePutBatchWithDelete}
 	for _, db := range DBs {
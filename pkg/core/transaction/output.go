@@ -56,3 +56,28 @@ func (out *Output) MarshalJSON() ([]byte, error) {
 		"n":       out.Position,
 	})
 }
+
+// outputAux is used for JSON unmarshalling of the Output.
+type outputAux struct {
+	AssetID    util.Uint256 `json:"asset"`
+	Amount     util.Fixed8  `json:"value"`
+	ScriptHash string       `json:"address"`
+	Position   int          `json:"n"`
+}
+
+// UnmarshalJSON implements the Unmarshaler interface.
+func (out *Output) UnmarshalJSON(data []byte) error {
+	aux := new(outputAux)
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(aux.ScriptHash)
+	if err != nil {
+		return err
+	}
+	out.AssetID = aux.AssetID
+	out.Amount = aux.Amount
+	out.ScriptHash = scriptHash
+	out.Position = aux.Position
+	return nil
+}
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/infinitete/neo-go-inf/pkg/crypto"
@@ -96,6 +97,31 @@ func TestDecodeEncodeInvocationTX(t *testing.T) {
 	assert.Equal(t, rawInvocationTX, hex.EncodeToString(buf.Bytes()))
 }
 
+func TestTransactionMarshalUnmarshalJSON(t *testing.T) {
+	tx := decodeTransaction(rawClaimTX, t)
+	for i, out := range tx.Outputs {
+		out.Position = i
+	}
+	tx.Attributes = append(tx.Attributes, &Attribute{Usage: Remark, Data: []byte("remark")})
+
+	data, err := json.Marshal(tx)
+	assert.Nil(t, err)
+
+	actual := new(Transaction)
+	assert.Nil(t, json.Unmarshal(data, actual))
+	assert.Equal(t, tx.Type, actual.Type)
+	assert.Equal(t, tx.Version, actual.Version)
+	assert.Equal(t, tx.Attributes, actual.Attributes)
+	assert.Equal(t, tx.Inputs, actual.Inputs)
+	assert.Equal(t, tx.Outputs, actual.Outputs)
+	assert.Equal(t, tx.Scripts, actual.Scripts)
+
+	var typ TXType
+	assert.NotNil(t, json.Unmarshal([]byte(`"NotATransaction"`), &typ))
+	attr := new(Attribute)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"usage":"NotAUsage","data":""}`), attr))
+}
+
 func TestNewInvocationTX(t *testing.T) {
 	script := []byte{0x51}
 	tx := NewInvocationTX(script)
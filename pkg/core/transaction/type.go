@@ -1,5 +1,10 @@
 package transaction
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // TXType is the type of a transaction.
 type TXType uint8
 
@@ -52,3 +57,20 @@ func (t TXType) String() string {
 func (t TXType) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + t.String() + `"`), nil
 }
+
+// UnmarshalJSON implements the json unmarshaller interface.
+func (t *TXType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, typ := range []TXType{MinerType, IssueType, ClaimType, EnrollmentType,
+		VotingType, RegisterType, ContractType, StateType, AgencyType,
+		PublishType, InvocationType} {
+		if typ.String() == s {
+			*t = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown transaction type: %s", s)
+}
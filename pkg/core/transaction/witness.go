@@ -37,6 +37,25 @@ func (w *Witness) MarshalJSON() ([]byte, error) {
 	return json.Marshal(data)
 }
 
+// UnmarshalJSON implements the json unmarshaller interface.
+func (w *Witness) UnmarshalJSON(data []byte) error {
+	m := map[string]string{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	inv, err := hex.DecodeString(m["invocation"])
+	if err != nil {
+		return err
+	}
+	ver, err := hex.DecodeString(m["verification"])
+	if err != nil {
+		return err
+	}
+	w.InvocationScript = inv
+	w.VerificationScript = ver
+	return nil
+}
+
 // ScriptHash returns the hash of the VerificationScript.
 func (w Witness) ScriptHash() util.Uint160 {
 	return hash.Hash160(w.VerificationScript)
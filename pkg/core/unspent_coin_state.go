@@ -1,7 +1,9 @@
 package core
 
 import (
+	"encoding/binary"
 	"fmt"
+	"sort"
 
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
@@ -78,6 +80,15 @@ func NewUnspentCoinState(n int) *UnspentCoinState {
 	return u
 }
 
+// IsSpent checks whether the output with the given index is spent, outputs
+// that don't exist are treated as spent.
+func (s *UnspentCoinState) IsSpent(index uint16) bool {
+	if int(index) >= len(s.states) {
+		return true
+	}
+	return s.states[index]&CoinStateSpent != 0
+}
+
 // commit writes all unspent coin states to the given Batch.
 func (u UnspentCoins) commit(store storage.Store) error {
 	for hash, state := range u {
@@ -136,3 +147,83 @@ func IsDoubleSpend(s storage.Store, tx *transaction.Transaction) bool {
 
 	return false
 }
+
+// UnspentOutput is an unspent transaction output along with the reference
+// to it.
+type UnspentOutput struct {
+	TxHash util.Uint256
+	Index  uint16
+	Output *transaction.Output
+}
+
+// unspentIndexKey returns the key of the given output in the index of the
+// unspent outputs of the account it belongs to.
+func unspentIndexKey(scriptHash util.Uint160, hash util.Uint256, index uint16) []byte {
+	key := storage.AppendPrefix(storage.IXUnspentCoins, scriptHash.Bytes())
+	key = append(key, hash.BytesReverse()...)
+	return append(key, byte(index), byte(index>>8))
+}
+
+// putUnspentIntoIndex adds the given output to the index of the unspent
+// outputs of the account it belongs to.
+func putUnspentIntoIndex(store storage.Store, hash util.Uint256, index uint16, out *transaction.Output) error {
+	buf := io.NewBufBinWriter()
+	out.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	return store.Put(unspentIndexKey(out.ScriptHash, hash, index), buf.Bytes())
+}
+
+// deleteUnspentFromIndex removes the given output from the index of the
+// unspent outputs of the account it belongs to.
+func deleteUnspentFromIndex(store storage.Store, hash util.Uint256, index uint16, out *transaction.Output) error {
+	return store.Delete(unspentIndexKey(out.ScriptHash, hash, index))
+}
+
+// getUnspentsFromIndex returns unspent outputs of the given account sorted
+// by transaction hash and output index.
+func getUnspentsFromIndex(store storage.Store, scriptHash util.Uint160) ([]*UnspentOutput, error) {
+	var (
+		unspents []*UnspentOutput
+		err      error
+		prefix   = storage.AppendPrefix(storage.IXUnspentCoins, scriptHash.Bytes())
+		hashLen  = len(util.Uint256{})
+	)
+	store.Seek(prefix, func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		if len(k) != len(prefix)+hashLen+2 {
+			err = fmt.Errorf("bad unspent index key length: %d", len(k))
+			return
+		}
+		var hash util.Uint256
+		hash, err = util.Uint256DecodeReverseBytes(k[len(prefix) : len(prefix)+hashLen])
+		if err != nil {
+			return
+		}
+		out := new(transaction.Output)
+		r := io.NewBinReaderFromBuf(v)
+		out.DecodeBinary(r)
+		if r.Err != nil {
+			err = fmt.Errorf("failed to decode (Output): %s", r.Err)
+			return
+		}
+		unspents = append(unspents, &UnspentOutput{
+			TxHash: hash,
+			Index:  binary.LittleEndian.Uint16(k[len(k)-2:]),
+			Output: out,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(unspents, func(i, j int) bool {
+		if unspents[i].TxHash != unspents[j].TxHash {
+			return unspents[i].TxHash.CompareTo(unspents[j].TxHash) < 0
+		}
+		return unspents[i].Index < unspents[j].Index
+	})
+	return unspents, nil
+}
@@ -57,3 +57,12 @@ func TestCommitUnspentCoins(t *testing.T) {
 
 	assert.Nil(t, unspentCoins.commit(store))
 }
+
+func TestUnspentCoinStateIsSpent(t *testing.T) {
+	unspent := &UnspentCoinState{
+		states: []CoinState{CoinStateConfirmed, CoinStateSpent},
+	}
+	assert.False(t, unspent.IsSpent(0))
+	assert.True(t, unspent.IsSpent(1))
+	assert.True(t, unspent.IsSpent(2))
+}
@@ -138,6 +138,11 @@ func utilityTokenTX() *transaction.Transaction {
 	return tx
 }
 
+// UtilityTokenID returns the ID of the utility token (GAS).
+func UtilityTokenID() util.Uint256 {
+	return utilityTokenTX().Hash()
+}
+
 func getValidators(cfg config.ProtocolConfiguration) ([]*keys.PublicKey, error) {
 	validators := make([]*keys.PublicKey, len(cfg.StandbyValidators))
 	for i, pubKeyStr := range cfg.StandbyValidators {
@@ -186,14 +191,14 @@ func storeAsCurrentBlock(store storage.Store, block *Block) error {
 	return store.Put(storage.SYSCurrentBlock.Bytes(), buf.Bytes())
 }
 
-// storeAsBlock stores the given block as DataBlock.
+// storeAsBlock stores the given block as DataBlock. The trimmed block is
+// prefixed with the system fee amount (in whole GAS units).
 func storeAsBlock(store storage.Store, block *Block, sysFee uint32) error {
 	var (
 		key = storage.AppendPrefix(storage.DataBlock, block.Hash().BytesReverse())
 		buf = io.NewBufBinWriter()
 	)
-	// sysFee needs to be handled somehow
-	//	buf.WriteLE(sysFee)
+	buf.WriteLE(sysFee)
 	b, err := block.Trim()
 	if err != nil {
 		return err
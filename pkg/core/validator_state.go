@@ -1,12 +1,101 @@
 package core
 
 import (
+	"fmt"
+	"math/big"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
 	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 )
 
-// Validators is a mapping between public keys and ValidatorState.
-type Validators map[*keys.PublicKey]*ValidatorState
+// MaxValidatorsVoted is the maximum number of validators an account can vote
+// for.
+const MaxValidatorsVoted = 1024
+
+// Validators is a mapping between serialized public keys and ValidatorState.
+type Validators map[string]*ValidatorState
+
+// getAndUpdate retrieves ValidatorState from temporary or persistent Store
+// or creates a new one if it doesn't exist.
+func (v Validators) getAndUpdate(s storage.Store, publicKey *keys.PublicKey) (*ValidatorState, error) {
+	key := string(publicKey.Bytes())
+	if validator, ok := v[key]; ok {
+		return validator, nil
+	}
+
+	validator, err := getValidatorStateFromStore(s, publicKey)
+	if err != nil {
+		if err != storage.ErrKeyNotFound {
+			return nil, err
+		}
+		validator = &ValidatorState{PublicKey: publicKey}
+	}
+
+	v[key] = validator
+	return validator, nil
+}
+
+// commit writes all validator states to the given Store. Validators that
+// are not registered and have no votes are removed.
+func (v Validators) commit(store storage.Store) error {
+	for _, state := range v {
+		if !state.Registered && state.Votes == 0 {
+			key := storage.AppendPrefix(storage.STValidator, state.PublicKey.Bytes())
+			if err := store.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := putValidatorStateIntoStore(store, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getValidatorStateFromStore returns ValidatorState for the given public key
+// from the given Store.
+func getValidatorStateFromStore(s storage.Store, publicKey *keys.PublicKey) (*ValidatorState, error) {
+	key := storage.AppendPrefix(storage.STValidator, publicKey.Bytes())
+	b, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	validator := new(ValidatorState)
+	r := io.NewBinReaderFromBuf(b)
+	validator.DecodeBinary(r)
+	if r.Err != nil {
+		return nil, fmt.Errorf("failed to decode (ValidatorState): %s", r.Err)
+	}
+	return validator, nil
+}
+
+// getValidatorsFromStore returns all validator states from the given Store.
+func getValidatorsFromStore(s storage.Store) []*ValidatorState {
+	var validators []*ValidatorState
+	s.Seek(storage.STValidator.Bytes(), func(k, v []byte) {
+		validator := new(ValidatorState)
+		r := io.NewBinReaderFromBuf(v)
+		validator.DecodeBinary(r)
+		if r.Err == nil {
+			validators = append(validators, validator)
+		}
+	})
+	return validators
+}
+
+// putValidatorStateIntoStore puts given ValidatorState into the given store.
+func putValidatorStateIntoStore(store storage.Store, vs *ValidatorState) error {
+	buf := io.NewBufBinWriter()
+	vs.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	key := storage.AppendPrefix(storage.STValidator, vs.PublicKey.Bytes())
+	return store.Put(key, buf.Bytes())
+}
 
 // ValidatorState holds the state of a validator.
 type ValidatorState struct {
@@ -14,3 +103,124 @@ type ValidatorState struct {
 	Registered bool
 	Votes      util.Fixed8
 }
+
+// RegisteredAndHasVotes returns true if the validator is registered and has
+// some votes.
+func (vs *ValidatorState) RegisteredAndHasVotes() bool {
+	return vs.Registered && vs.Votes > 0
+}
+
+// EncodeBinary encodes ValidatorState to the given BinWriter.
+func (vs *ValidatorState) EncodeBinary(bw *io.BinWriter) {
+	vs.PublicKey.EncodeBinary(bw)
+	bw.WriteLE(vs.Registered)
+	bw.WriteLE(vs.Votes)
+}
+
+// DecodeBinary decodes ValidatorState from the given BinReader.
+func (vs *ValidatorState) DecodeBinary(reader *io.BinReader) {
+	vs.PublicKey = &keys.PublicKey{}
+	vs.PublicKey.DecodeBinary(reader)
+	reader.ReadLE(&vs.Registered)
+	reader.ReadLE(&vs.Votes)
+}
+
+// ValidatorsCount holds the amount of governing tokens voted for the given
+// number of validators, the element i is for the accounts that voted for
+// i+1 validators.
+type ValidatorsCount [MaxValidatorsVoted]util.Fixed8
+
+// getValidatorsCountFromStore returns ValidatorsCount from the given Store,
+// an empty one is returned if it's not stored yet.
+func getValidatorsCountFromStore(s storage.Store) (*ValidatorsCount, error) {
+	vc := new(ValidatorsCount)
+	b, err := s.Get(storage.IXValidatorsCount.Bytes())
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return vc, nil
+		}
+		return nil, err
+	}
+	r := io.NewBinReaderFromBuf(b)
+	vc.DecodeBinary(r)
+	if r.Err != nil {
+		return nil, fmt.Errorf("failed to decode (ValidatorsCount): %s", r.Err)
+	}
+	return vc, nil
+}
+
+// putValidatorsCountIntoStore puts given ValidatorsCount into the given store.
+func putValidatorsCountIntoStore(store storage.Store, vc *ValidatorsCount) error {
+	buf := io.NewBufBinWriter()
+	vc.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	return store.Put(storage.IXValidatorsCount.Bytes(), buf.Bytes())
+}
+
+// EncodeBinary encodes ValidatorsCount to the given BinWriter.
+func (vc *ValidatorsCount) EncodeBinary(w *io.BinWriter) {
+	w.WriteVarUint(uint64(len(vc)))
+	for i := range vc {
+		w.WriteLE(vc[i])
+	}
+}
+
+// DecodeBinary decodes ValidatorsCount from the given BinReader.
+func (vc *ValidatorsCount) DecodeBinary(r *io.BinReader) {
+	n := r.ReadVarUint()
+	if r.Err != nil {
+		return
+	}
+	if n > MaxValidatorsVoted {
+		r.Err = fmt.Errorf("too many validators count elements: %d", n)
+		return
+	}
+	for i := 0; i < int(n); i++ {
+		r.ReadLE(&vc[i])
+	}
+}
+
+// GetWeightedAverage returns the number of validators that is the weighted
+// average of the votes cast for the validators count. Only the middle half
+// of all votes (from 25% to 75%) is taken into account.
+func (vc *ValidatorsCount) GetWeightedAverage() int {
+	var sum, current int64
+	for _, votes := range vc {
+		sum += int64(votes)
+	}
+	lower, upper := sum/4, sum*3/4
+
+	var weightedSum, weightsAmount = new(big.Int), new(big.Int)
+	for i, votes := range vc {
+		if votes <= 0 {
+			continue
+		}
+		if current >= upper {
+			break
+		}
+		weight := int64(votes)
+		old := current
+		current += weight
+		if current <= lower {
+			continue
+		}
+		if old < lower {
+			if current > upper {
+				weight = upper - lower
+			} else {
+				weight = current - lower
+			}
+		} else if current > upper {
+			weight = upper - old
+		}
+		w := big.NewInt(weight)
+		weightedSum.Add(weightedSum, new(big.Int).Mul(big.NewInt(int64(i)), w))
+		weightsAmount.Add(weightsAmount, w)
+	}
+	if weightsAmount.Sign() == 0 {
+		return 0
+	}
+	return int(weightedSum.Div(weightedSum, weightsAmount).Int64())
+}
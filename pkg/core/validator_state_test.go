@@ -0,0 +1,165 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeValidatorState(t *testing.T) {
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	state := &ValidatorState{
+		PublicKey:  priv.PublicKey(),
+		Registered: true,
+		Votes:      util.Fixed8(100500),
+	}
+
+	buf := io.NewBufBinWriter()
+	state.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	decoded := &ValidatorState{}
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	decoded.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, state, decoded)
+}
+
+func TestEncodeDecodeValidatorsCount(t *testing.T) {
+	vc := new(ValidatorsCount)
+	vc[0] = util.Fixed8FromInt64(10)
+	vc[MaxValidatorsVoted-1] = util.Fixed8FromInt64(20)
+
+	buf := io.NewBufBinWriter()
+	vc.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	decoded := new(ValidatorsCount)
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	decoded.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, vc, decoded)
+}
+
+func TestValidatorsCountGetWeightedAverage(t *testing.T) {
+	vc := new(ValidatorsCount)
+	assert.Equal(t, 0, vc.GetWeightedAverage())
+
+	vc[6] = util.Fixed8FromInt64(100)
+	assert.Equal(t, 6, vc.GetWeightedAverage())
+
+	// Votes outside of the middle half don't affect the result.
+	vc[0] = util.Fixed8FromInt64(10)
+	vc[20] = util.Fixed8FromInt64(10)
+	assert.Equal(t, 6, vc.GetWeightedAverage())
+
+	vc = new(ValidatorsCount)
+	vc[4] = util.Fixed8FromInt64(50)
+	vc[8] = util.Fixed8FromInt64(50)
+	assert.Equal(t, 6, vc.GetWeightedAverage())
+}
+
+func TestProcessStateTX(t *testing.T) {
+	var (
+		store      = storage.NewMemoryStore()
+		accounts   = make(Accounts)
+		validators = make(Validators)
+		vc         = new(ValidatorsCount)
+		balance    = util.Fixed8FromInt64(1000)
+	)
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	pub := priv.PublicKey()
+
+	account, err := accounts.getAndUpdate(store, randomUint160())
+	require.NoError(t, err)
+	account.Balances[governingTokenTX().Hash()] = balance
+
+	require.NoError(t, processEnrollmentTX(store, &transaction.EnrollmentTX{PublicKey: pub}, validators))
+	validator, err := validators.getAndUpdate(store, pub)
+	require.NoError(t, err)
+	assert.True(t, validator.Registered)
+
+	buf := io.NewBufBinWriter()
+	buf.WriteVarUint(1)
+	pub.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+	vote := &transaction.StateTX{Descriptors: []*transaction.StateDescriptor{{
+		Type:  transaction.Account,
+		Key:   account.ScriptHash.Bytes(),
+		Value: buf.Bytes(),
+		Field: "Votes",
+	}}}
+	require.NoError(t, processStateTX(store, vote, accounts, validators, vc))
+	assert.Equal(t, balance, validator.Votes)
+	assert.Equal(t, balance, vc[0])
+	assert.Equal(t, 1, len(account.Votes))
+
+	// Voting again doesn't double the votes.
+	require.NoError(t, processStateTX(store, vote, accounts, validators, vc))
+	assert.Equal(t, balance, validator.Votes)
+	assert.Equal(t, balance, vc[0])
+
+	// Repeated keys are counted once.
+	buf = io.NewBufBinWriter()
+	buf.WriteVarUint(2)
+	pub.EncodeBinary(buf.BinWriter)
+	pub.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+	duplicate := &transaction.StateTX{Descriptors: []*transaction.StateDescriptor{{
+		Type:  transaction.Account,
+		Key:   account.ScriptHash.Bytes(),
+		Value: buf.Bytes(),
+		Field: "Votes",
+	}}}
+	require.NoError(t, processStateTX(store, duplicate, accounts, validators, vc))
+	assert.Equal(t, []*keys.PublicKey{pub}, account.Votes)
+	assert.Equal(t, balance, validator.Votes)
+	assert.Equal(t, balance, vc[0])
+	assert.Equal(t, util.Fixed8(0), vc[1])
+
+	// Unknown fields are ignored.
+	unknown := &transaction.StateTX{Descriptors: []*transaction.StateDescriptor{{
+		Type:  transaction.Account,
+		Key:   account.ScriptHash.Bytes(),
+		Value: []byte{1, 2, 3},
+		Field: "Unknown",
+	}, {
+		Type:  transaction.Validator,
+		Key:   pub.Bytes(),
+		Value: []byte{0},
+		Field: "Unknown",
+	}}}
+	require.NoError(t, processStateTX(store, unknown, accounts, validators, vc))
+	assert.Equal(t, []*keys.PublicKey{pub}, account.Votes)
+	assert.True(t, validator.Registered)
+	assert.Equal(t, balance, validator.Votes)
+
+	unregister := &transaction.StateTX{Descriptors: []*transaction.StateDescriptor{{
+		Type:  transaction.Validator,
+		Key:   pub.Bytes(),
+		Value: []byte{0},
+		Field: "Registered",
+	}}}
+	require.NoError(t, processStateTX(store, unregister, accounts, validators, vc))
+	assert.False(t, validator.Registered)
+	assert.Equal(t, balance, validator.Votes)
+
+	require.NoError(t, validators.commit(store))
+	stored, err := getValidatorStateFromStore(store, pub)
+	require.NoError(t, err)
+	assert.Equal(t, validator, stored)
+
+	// Unregistered validators without votes are removed.
+	validator.Votes = 0
+	require.NoError(t, validators.commit(store))
+	_, err = getValidatorStateFromStore(store, pub)
+	assert.Equal(t, storage.ErrKeyNotFound, err)
+}
@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+)
+
+// bloomSeedMultiplier is used to derive hash function seeds from the tweak.
+const bloomSeedMultiplier = 0xfba4c795
+
+// BloomFilter is a probabilistic set used by light clients to request only
+// the data they're interested in, it's compatible with the C# BloomFilter.
+type BloomFilter struct {
+	seeds []uint32
+	bits  []byte
+	m     uint32
+	tweak uint32
+}
+
+// NewBloomFilter creates a new BloomFilter with m bits and k hash functions
+// using the given tweak. Initial bits are taken from elements (in
+// little-endian bit order) if it's not nil.
+func NewBloomFilter(m, k int, tweak uint32, elements []byte) *BloomFilter {
+	f := &BloomFilter{
+		seeds: make([]uint32, k),
+		bits:  make([]byte, (m+7)/8),
+		m:     uint32(m),
+		tweak: tweak,
+	}
+	for i := range f.seeds {
+		f.seeds[i] = uint32(i)*bloomSeedMultiplier + tweak
+	}
+	copy(f.bits, elements)
+	// Bits beyond m are not used.
+	if rem := m % 8; rem != 0 && len(f.bits) > 0 {
+		f.bits[len(f.bits)-1] &= byte(1<<uint(rem)) - 1
+	}
+	return f
+}
+
+// K returns the number of hash functions used.
+func (f *BloomFilter) K() int {
+	return len(f.seeds)
+}
+
+// M returns the size of the filter in bits.
+func (f *BloomFilter) M() int {
+	return int(f.m)
+}
+
+// Tweak returns the tweak of the filter.
+func (f *BloomFilter) Tweak() uint32 {
+	return f.tweak
+}
+
+// Bits returns a copy of the filter bits.
+func (f *BloomFilter) Bits() []byte {
+	res := make([]byte, len(f.bits))
+	copy(res, f.bits)
+	return res
+}
+
+// Add adds the element to the filter.
+func (f *BloomFilter) Add(element []byte) {
+	if f.m == 0 {
+		return
+	}
+	for _, seed := range f.seeds {
+		i := hash.Murmur32(element, seed) % f.m
+		f.bits[i/8] |= 1 << (i % 8)
+	}
+}
+
+// Check checks whether the element may be in the filter.
+func (f *BloomFilter) Check(element []byte) bool {
+	if f.m == 0 {
+		return false
+	}
+	for _, seed := range f.seeds {
+		i := hash.Murmur32(element, seed) % f.m
+		if f.bits[i/8]&(1<<(i%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilterAddCheck(t *testing.T) {
+	f := NewBloomFilter(7, 10, 123456, nil)
+	elements := []byte{0, 1, 2, 3, 4}
+	assert.False(t, f.Check(elements))
+	f.Add(elements)
+	assert.True(t, f.Check(elements))
+	assert.False(t, f.Check([]byte{5, 6, 7, 8, 9}))
+
+	assert.Equal(t, 7, f.M())
+	assert.Equal(t, 10, f.K())
+	assert.Equal(t, uint32(123456), f.Tweak())
+}
+
+func TestBloomFilterBits(t *testing.T) {
+	// Bits beyond m are dropped.
+	f := NewBloomFilter(7, 10, 123456, []byte{0x80, 1, 2, 3, 4})
+	assert.Equal(t, []byte{0}, f.Bits())
+
+	f = NewBloomFilter(16, 3, 0, nil)
+	f.Add([]byte("element"))
+	restored := NewBloomFilter(16, 3, 0, f.Bits())
+	assert.True(t, restored.Check([]byte("element")))
+
+	// Another tweak means other hash functions.
+	other := NewBloomFilter(16, 3, 1, f.Bits())
+	other.Add([]byte("element"))
+	assert.NotEqual(t, f.Bits(), other.Bits())
+}
+
+func TestBloomFilterEmpty(t *testing.T) {
+	f := NewBloomFilter(0, 3, 0, nil)
+	f.Add([]byte{1})
+	assert.False(t, f.Check([]byte{1}))
+}
@@ -47,3 +47,25 @@ func TestHash160(t *testing.T) {
 	actual := hex.EncodeToString(data.Bytes())
 	assert.Equal(t, expected, actual)
 }
+
+func TestMurmur32(t *testing.T) {
+	testCases := []struct {
+		data     string
+		seed     uint32
+		expected uint32
+	}{
+		{"", 0, 0},
+		{"", 1, 0x514e28b7},
+		{"", 0xffffffff, 0x81f16f39},
+		{"\x00\x00\x00\x00", 0, 0x2362f9de},
+		{"\x21\x43\x65\x87", 0x5082edee, 0x2362f9de},
+		{"\x21\x43\x65", 0, 0x7e4a8634},
+		{"\x21\x43", 0, 0xa0f7b07a},
+		{"\x21", 0, 0x72661cf4},
+		{"Hello, world!", 1234, 0xfaf6cdb3},
+		{"The quick brown fox jumps over the lazy dog", 0x9747b28c, 0x2fa826cd},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, Murmur32([]byte(tc.data), tc.seed), "data %q, seed %d", tc.data, tc.seed)
+	}
+}
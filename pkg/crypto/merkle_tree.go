@@ -31,9 +31,13 @@ func NewMerkleTree(hashes []util.Uint256) (*MerkleTree, error) {
 		return nil, err
 	}
 
+	depth := 1
+	for n := root; n.leftChild != nil; n = n.leftChild {
+		depth++
+	}
 	return &MerkleTree{
 		root:  root,
-		depth: 1,
+		depth: depth,
 	}, nil
 }
 
@@ -42,6 +46,49 @@ func (t *MerkleTree) Root() util.Uint256 {
 	return t.root.hash
 }
 
+// Trim removes the subtrees not containing any of the leaves flagged, only
+// their root hashes are kept, so that the tree can be sent as a partial
+// merkle tree proving the inclusion of flagged leaves.
+func (t *MerkleTree) Trim(flags []bool) {
+	full := make([]bool, 1<<uint(t.depth-1))
+	copy(full, flags)
+	trim(t.root, 0, t.depth, full)
+}
+
+func trim(node *MerkleTreeNode, index, depth int, flags []bool) {
+	if depth == 1 || node.leftChild == nil {
+		return
+	}
+	if depth == 2 {
+		if !flags[index*2] && !flags[index*2+1] {
+			node.leftChild = nil
+			node.rightChild = nil
+		}
+		return
+	}
+	trim(node.leftChild, index*2, depth-1, flags)
+	trim(node.rightChild, index*2+1, depth-1, flags)
+	if node.leftChild.leftChild == nil && node.rightChild.rightChild == nil {
+		node.leftChild = nil
+		node.rightChild = nil
+	}
+}
+
+// ToHashArray returns the hashes of the tree leaves (or roots of the trimmed
+// subtrees) in depth-first order.
+func (t *MerkleTree) ToHashArray() []util.Uint256 {
+	var hashes []util.Uint256
+	return depthFirstSearch(t.root, hashes)
+}
+
+func depthFirstSearch(node *MerkleTreeNode, hashes []util.Uint256) []util.Uint256 {
+	if node.leftChild == nil {
+		return append(hashes, node.hash)
+	}
+	hashes = depthFirstSearch(node.leftChild, hashes)
+	return depthFirstSearch(node.rightChild, hashes)
+}
+
 func buildMerkleTree(leaves []*MerkleTreeNode) (*MerkleTreeNode, error) {
 	if len(leaves) == 0 {
 		return nil, errors.New("length of the leaves cannot be zero")
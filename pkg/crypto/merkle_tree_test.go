@@ -27,3 +27,28 @@ func TestComputeMerkleTree(t *testing.T) {
 	}
 	assert.Equal(t, "803ff4abe3ea6533bcc0be574efa02f83ae8fdc651c879056b0d9be336c01bf4", merkle.Root().ReverseString())
 }
+
+func TestMerkleTreeTrim(t *testing.T) {
+	hashes := []util.Uint256{{1}, {2}, {3}, {4}, {5}}
+	tree, err := NewMerkleTree(hashes)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, tree.depth)
+	assert.Equal(t, hashes, tree.ToHashArray()[:5])
+
+	l12 := tree.root.leftChild.leftChild.hash
+	l34 := tree.root.leftChild.rightChild
+	right := tree.root.rightChild.hash
+	tree.Trim([]bool{false, false, true})
+	assert.Equal(t, []util.Uint256{l12, l34.leftChild.hash, l34.rightChild.hash, right}, tree.ToHashArray())
+
+	tree, err = NewMerkleTree(hashes)
+	assert.Nil(t, err)
+	root := tree.Root()
+	tree.Trim(nil)
+	assert.Equal(t, []util.Uint256{root}, tree.ToHashArray())
+
+	tree, err = NewMerkleTree(hashes[:1])
+	assert.Nil(t, err)
+	tree.Trim([]bool{true})
+	assert.Equal(t, hashes[:1], tree.ToHashArray())
+}
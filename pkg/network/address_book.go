@@ -0,0 +1,137 @@
+package network
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// maxAddressBookSize is the maximum number of addresses kept in the address
+// book, the oldest ones are dropped when it's exceeded.
+const maxAddressBookSize = 1000
+
+// addressBook is a set of known peer addresses along with the time they were
+// last seen at. It can be stored on disk so that the node doesn't have to
+// start from the seeds after restart.
+type addressBook struct {
+	path  string
+	lock  sync.RWMutex
+	addrs map[string]uint32
+}
+
+// newAddressBook creates an empty addressBook stored at the given path, it's
+// not persisted if the path is empty.
+func newAddressBook(path string) *addressBook {
+	return &addressBook{
+		path:  path,
+		addrs: make(map[string]uint32),
+	}
+}
+
+// load reads the address book from disk, a missing file is not an error.
+func (b *addressBook) load() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	addrs := make(map[string]uint32)
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for addr, ts := range addrs {
+		b.addLocked(addr, ts)
+	}
+	return nil
+}
+
+// save writes the address book to disk.
+func (b *addressBook) save() error {
+	if b.path == "" {
+		return nil
+	}
+	b.lock.RLock()
+	data, err := json.Marshal(b.addrs)
+	b.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first to not corrupt the book if
+	// something goes wrong.
+	tmp := b.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// add adds the address seen at the given time (UNIX timestamp) to the book or
+// updates its timestamp if it's newer than the known one.
+func (b *addressBook) add(addr string, ts uint32) {
+	b.lock.Lock()
+	b.addLocked(addr, ts)
+	b.lock.Unlock()
+}
+
+func (b *addressBook) addLocked(addr string, ts uint32) {
+	if known, ok := b.addrs[addr]; ok {
+		if ts > known {
+			b.addrs[addr] = ts
+		}
+		return
+	}
+	if len(b.addrs) >= maxAddressBookSize {
+		var (
+			oldest   string
+			oldestTS uint32
+		)
+		for a, t := range b.addrs {
+			if oldest == "" || t < oldestTS {
+				oldest, oldestTS = a, t
+			}
+		}
+		if ts < oldestTS {
+			return
+		}
+		delete(b.addrs, oldest)
+	}
+	b.addrs[addr] = ts
+}
+
+// remove removes the given addresses from the book.
+func (b *addressBook) remove(addrs ...string) {
+	b.lock.Lock()
+	for _, addr := range addrs {
+		delete(b.addrs, addr)
+	}
+	b.lock.Unlock()
+}
+
+// addresses returns all known addresses, the most recently seen first.
+func (b *addressBook) addresses() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	addrs := make([]string, 0, len(b.addrs))
+	for addr := range b.addrs {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if b.addrs[addrs[i]] != b.addrs[addrs[j]] {
+			return b.addrs[addrs[i]] > b.addrs[addrs[j]]
+		}
+		return addrs[i] < addrs[j]
+	})
+	return addrs
+}
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressBook(t *testing.T) {
+	b := newAddressBook("")
+	b.add("1.1.1.1:10333", 100)
+	b.add("2.2.2.2:10333", 200)
+	b.add("3.3.3.3:10333", 150)
+	assert.Equal(t, []string{"2.2.2.2:10333", "3.3.3.3:10333", "1.1.1.1:10333"}, b.addresses())
+
+	// Timestamps are only updated with newer ones.
+	b.add("1.1.1.1:10333", 300)
+	b.add("2.2.2.2:10333", 50)
+	assert.Equal(t, []string{"1.1.1.1:10333", "2.2.2.2:10333", "3.3.3.3:10333"}, b.addresses())
+
+	b.remove("2.2.2.2:10333", "4.4.4.4:10333")
+	assert.Equal(t, []string{"1.1.1.1:10333", "3.3.3.3:10333"}, b.addresses())
+
+	// Persistence is disabled without the path.
+	require.NoError(t, b.save())
+	require.NoError(t, b.load())
+}
+
+func TestAddressBookLimit(t *testing.T) {
+	b := newAddressBook("")
+	for i := 0; i < maxAddressBookSize; i++ {
+		b.add(fmt.Sprintf("10.0.%d.%d:10333", i/256, i%256), uint32(i+1))
+	}
+	// Older than any known address.
+	b.add("1.1.1.1:10333", 0)
+	// The oldest one should be replaced.
+	b.add("2.2.2.2:10333", maxAddressBookSize+1)
+
+	addrs := b.addresses()
+	require.Equal(t, maxAddressBookSize, len(addrs))
+	assert.Equal(t, "2.2.2.2:10333", addrs[0])
+	assert.NotContains(t, addrs, "1.1.1.1:10333")
+	assert.NotContains(t, addrs, "10.0.0.0:10333")
+}
+
+func TestAddressBookPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrbook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chains", "peers.json")
+
+	// Missing file is OK.
+	b := newAddressBook(path)
+	require.NoError(t, b.load())
+	assert.Equal(t, 0, len(b.addresses()))
+
+	b.add("1.1.1.1:10333", 100)
+	b.add("2.2.2.2:10333", 200)
+	require.NoError(t, b.save())
+
+	loaded := newAddressBook(path)
+	require.NoError(t, loaded.load())
+	assert.Equal(t, b.addresses(), loaded.addresses())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("garbage"), 0644))
+	require.Error(t, newAddressBook(path).load())
+}
@@ -10,13 +10,16 @@ type blockQueue struct {
 	queue       *queue.PriorityQueue
 	checkBlocks chan struct{}
 	chain       core.Blockchainer
+	// onBlock is called for every block successfully added to the chain.
+	onBlock func(*core.Block)
 }
 
-func newBlockQueue(capacity int, bc core.Blockchainer) *blockQueue {
+func newBlockQueue(capacity int, bc core.Blockchainer, onBlock func(*core.Block)) *blockQueue {
 	return &blockQueue{
 		queue:       queue.NewPriorityQueue(capacity, false),
 		checkBlocks: make(chan struct{}, 1),
 		chain:       bc,
+		onBlock:     onBlock,
 	}
 }
 
@@ -43,6 +46,8 @@ func (bq *blockQueue) run() {
 							"blockHeight": bq.chain.BlockHeight(),
 							"nextIndex":   minblock.Index,
 						}).Warn("blockQueue: failed adding block into the blockchain")
+					} else if bq.onBlock != nil {
+						bq.onBlock(minblock)
 					}
 				}
 			} else {
@@ -11,7 +11,7 @@ import (
 func TestBlockQueue(t *testing.T) {
 	chain := &testChain{}
 	// notice, it's not yet running
-	bq := newBlockQueue(0, chain)
+	bq := newBlockQueue(0, chain, nil)
 	blocks := make([]*core.Block, 11)
 	for i := 1; i < 11; i++ {
 		blocks[i] = &core.Block{BlockBase: core.BlockBase{Index: uint32(i)}}
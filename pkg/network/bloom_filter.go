@@ -0,0 +1,97 @@
+package network
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/network/payload"
+)
+
+// handleFilterLoadCmd sets the bloom filter of the peer.
+func (s *Server) handleFilterLoadCmd(p Peer, fl *payload.FilterLoad) error {
+	filter := crypto.NewBloomFilter(len(fl.Filter)*8, int(fl.K), fl.Tweak, fl.Filter)
+	s.lock.Lock()
+	s.filters[p] = filter
+	s.lock.Unlock()
+	return nil
+}
+
+// handleFilterAddCmd adds the element to the bloom filter of the peer if it
+// has one.
+func (s *Server) handleFilterAddCmd(p Peer, fa *payload.FilterAdd) error {
+	s.lock.Lock()
+	if filter := s.filters[p]; filter != nil {
+		filter.Add(fa.Data)
+	}
+	s.lock.Unlock()
+	return nil
+}
+
+// handleFilterClearCmd removes the bloom filter of the peer.
+func (s *Server) handleFilterClearCmd(p Peer) error {
+	s.lock.Lock()
+	delete(s.filters, p)
+	s.lock.Unlock()
+	return nil
+}
+
+// newBlockMessage creates a message with the given block for the peer, it's
+// a merkleblock with the transactions matching the filter for the peers that
+// have set one.
+func (s *Server) newBlockMessage(p Peer, b *core.Block) (*Message, error) {
+	s.lock.RLock()
+	filter := s.filters[p]
+	if filter == nil {
+		s.lock.RUnlock()
+		return NewMessage(s.Net, CMDBlock, b), nil
+	}
+	flags := make([]bool, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		flags[i] = filterMatchesTx(filter, tx)
+	}
+	s.lock.RUnlock()
+
+	mb, err := payload.NewMerkleBlock(b, flags)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessage(s.Net, CMDMerkleBlock, mb), nil
+}
+
+// peerWantsTx checks whether the transaction passes the bloom filter of the
+// peer (if it has one). It must be called with the server lock held.
+func (s *Server) peerWantsTx(p Peer, tx *transaction.Transaction) bool {
+	filter := s.filters[p]
+	return filter == nil || filterMatchesTx(filter, tx)
+}
+
+// filterMatchesTx checks whether any of the transaction hash, output script
+// hashes, inputs, witness script hashes or the registered asset admin
+// matches the filter.
+func filterMatchesTx(filter *crypto.BloomFilter, tx *transaction.Transaction) bool {
+	if filter.Check(tx.Hash().Bytes()) {
+		return true
+	}
+	for _, out := range tx.Outputs {
+		if filter.Check(out.ScriptHash.Bytes()) {
+			return true
+		}
+	}
+	for _, in := range tx.Inputs {
+		buf := io.NewBufBinWriter()
+		in.EncodeBinary(buf.BinWriter)
+		if filter.Check(buf.Bytes()) {
+			return true
+		}
+	}
+	for _, w := range tx.Scripts {
+		if filter.Check(w.ScriptHash().Bytes()) {
+			return true
+		}
+	}
+	if reg, ok := tx.Data.(*transaction.RegisterTX); ok && filter.Check(reg.Admin.Bytes()) {
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"errors"
 	"math/rand"
 	"net"
 	"sync/atomic"
@@ -11,6 +12,8 @@ import (
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/network/payload"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/infinitete/neo-go-inf/pkg/vm"
@@ -18,6 +21,9 @@ import (
 
 type testChain struct {
 	blockheight uint32
+	blocks      map[util.Uint256]*core.Block
+	headers     []util.Uint256
+	pool        core.MemPool
 }
 
 func (chain testChain) GetConfig() config.ProtocolConfiguration {
@@ -52,16 +58,31 @@ func (chain *testChain) AddBlock(block *core.Block) error {
 func (chain *testChain) BlockHeight() uint32 {
 	return atomic.LoadUint32(&chain.blockheight)
 }
+func (chain testChain) CalculateBonus([]*transaction.Input) (util.Fixed8, error) {
+	panic("TODO")
+}
+func (chain testChain) CalculateClaimable(util.Fixed8, uint32, uint32) (util.Fixed8, util.Fixed8) {
+	panic("TODO")
+}
 func (chain testChain) HeaderHeight() uint32 {
-	return 0
+	if len(chain.headers) == 0 {
+		return 0
+	}
+	return uint32(len(chain.headers) - 1)
 }
 func (chain testChain) GetBlock(hash util.Uint256) (*core.Block, error) {
-	panic("TODO")
+	if b, ok := chain.blocks[hash]; ok {
+		return b, nil
+	}
+	return nil, errors.New("block not found")
 }
 func (chain testChain) GetContractState(hash util.Uint160) *core.ContractState {
 	panic("TODO")
 }
-func (chain testChain) GetHeaderHash(int) util.Uint256 {
+func (chain testChain) GetHeaderHash(i int) util.Uint256 {
+	if i < len(chain.headers) {
+		return chain.headers[i]
+	}
 	return util.Uint256{}
 }
 func (chain testChain) GetHeader(hash util.Uint256) (*core.Header, error) {
@@ -74,15 +95,24 @@ func (chain testChain) GetAssetState(util.Uint256) *core.AssetState {
 func (chain testChain) GetAccountState(util.Uint160) *core.AccountState {
 	panic("TODO")
 }
+func (chain testChain) GetAppExecResult(hash util.Uint256) (*core.AppExecResult, error) {
+	panic("TODO")
+}
 func (chain testChain) GetScriptHashesForVerifying(*transaction.Transaction) ([]util.Uint160, error) {
 	panic("TODO")
 }
 func (chain testChain) GetStorageItem(scripthash util.Uint160, key []byte) *core.StorageItem {
 	panic("TODO")
 }
+func (chain testChain) GetSystemFeeAmount(h util.Uint256) uint32 {
+	panic("TODO")
+}
 func (chain testChain) GetTestVM() (*vm.VM, storage.Store) {
 	panic("TODO")
 }
+func (chain testChain) SeekStorageItems(hash util.Uint160, prefix, start []byte, f func(k []byte, si *core.StorageItem) bool) error {
+	panic("TODO")
+}
 func (chain testChain) GetStorageItems(hash util.Uint160) (map[string]*core.StorageItem, error) {
 	panic("TODO")
 }
@@ -102,22 +132,82 @@ func (chain testChain) GetTransaction(util.Uint256) (*transaction.Transaction, u
 	panic("TODO")
 }
 
+func (chain testChain) GetValidators(...*transaction.Transaction) ([]*keys.PublicKey, error) {
+	panic("TODO")
+}
+
+func (chain testChain) GetEnrollments() ([]*core.ValidatorState, error) {
+	panic("TODO")
+}
+
+func (chain testChain) GetClaimable(util.Uint160) ([]*core.SpentCoin, error) {
+	panic("TODO")
+}
+
+func (chain testChain) GetUnclaimed(util.Uint160) (util.Fixed8, util.Fixed8, error) {
+	panic("TODO")
+}
+
 func (chain testChain) GetUnspentCoinState(util.Uint256) *core.UnspentCoinState {
 	panic("TODO")
 }
 
-func (chain testChain) GetMemPool() core.MemPool {
+func (chain testChain) GetUnspents(util.Uint160) ([]*core.UnspentOutput, error) {
 	panic("TODO")
 }
 
+func (chain testChain) GetMemPool() core.MemPool {
+	return chain.pool
+}
+
 func (chain testChain) IsLowPriority(*transaction.Transaction) bool {
 	panic("TODO")
 }
 
+func (chain testChain) GetNEP5Balances(util.Uint160) ([]*core.NEP5Balance, error) {
+	panic("TODO")
+}
+
+func (chain testChain) GetNEP5Transfers(util.Uint160, uint32, uint32) ([]*core.NEP5Transfer, error) {
+	panic("TODO")
+}
+
+func (chain testChain) VerifyBlock(*core.Block) error {
+	panic("TODO")
+}
+
 func (chain testChain) VerifyTx(*transaction.Transaction, *core.Block) error {
 	panic("TODO")
 }
 
+func (chain testChain) PoolTx(*transaction.Transaction) error {
+	panic("TODO")
+}
+
+func (chain testChain) SubscribeForBlocks(ch chan<- *core.Block) {
+	panic("TODO")
+}
+
+func (chain testChain) SubscribeForExecutions(ch chan<- *core.AppExecResult) {
+	panic("TODO")
+}
+
+func (chain testChain) SubscribeForTransactions(ch chan<- *transaction.Transaction) {
+	panic("TODO")
+}
+
+func (chain testChain) UnsubscribeFromBlocks(ch chan<- *core.Block) {
+	panic("TODO")
+}
+
+func (chain testChain) UnsubscribeFromExecutions(ch chan<- *core.AppExecResult) {
+	panic("TODO")
+}
+
+func (chain testChain) UnsubscribeFromTransactions(ch chan<- *transaction.Transaction) {
+	panic("TODO")
+}
+
 type testDiscovery struct{}
 
 func (d testDiscovery) BackFill(addrs ...string)       {}
@@ -144,6 +234,7 @@ var defaultMessageHandler = func(t *testing.T, msg *Message) {}
 type localPeer struct {
 	netaddr        net.TCPAddr
 	version        *payload.Version
+	lastBlockIndex uint32
 	handshaked     bool
 	t              *testing.T
 	messageHandler func(t *testing.T, msg *Message)
@@ -178,6 +269,7 @@ func (p *localPeer) Version() *payload.Version {
 }
 func (p *localPeer) HandleVersion(v *payload.Version) error {
 	p.version = v
+	p.lastBlockIndex = v.StartHeight
 	return nil
 }
 func (p *localPeer) SendVersion(m *Message) error {
@@ -195,10 +287,19 @@ func (p *localPeer) Handshaked() bool {
 	return p.handshaked
 }
 
+func (p *localPeer) LastBlockIndex() uint32 {
+	return p.lastBlockIndex
+}
+
+func (p *localPeer) UpdateLastBlockIndex(index uint32) {
+	p.lastBlockIndex = index
+}
+
 func newTestServer() *Server {
+	chain := &testChain{}
 	return &Server{
 		ServerConfig: ServerConfig{},
-		chain:        &testChain{},
+		chain:        chain,
 		transport:    localTransport{},
 		discovery:    testDiscovery{},
 		id:           rand.Uint32(),
@@ -206,6 +307,10 @@ func newTestServer() *Server {
 		register:     make(chan Peer),
 		unregister:   make(chan peerDrop),
 		peers:        make(map[Peer]bool),
+		ownAddrs:     make(map[string]bool),
+		filters:      make(map[Peer]*crypto.BloomFilter),
+		addrBook:     newAddressBook(""),
+		syncMgr:      newSyncManager(chain, blockRequestTimeout),
 	}
 
 }
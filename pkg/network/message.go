@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/infinitete/neo-go-inf/config"
+	"github.com/infinitete/neo-go-inf/pkg/consensus"
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
 	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
@@ -185,8 +186,7 @@ func (m *Message) decodePayload(br *io.BinReader) error {
 	case CMDBlock:
 		p = &core.Block{}
 	case CMDConsensus:
-		// Stubbed out for now, see #431.
-		return nil
+		p = &consensus.Payload{}
 	case CMDGetBlocks:
 		fallthrough
 	case CMDGetHeaders:
@@ -197,6 +197,12 @@ func (m *Message) decodePayload(br *io.BinReader) error {
 		p = &transaction.Transaction{}
 	case CMDMerkleBlock:
 		p = &payload.MerkleBlock{}
+	case CMDFilterLoad:
+		p = &payload.FilterLoad{}
+	case CMDFilterAdd:
+		p = &payload.FilterAdd{}
+	case CMDPing, CMDPong:
+		p = &payload.Ping{}
 	default:
 		return fmt.Errorf("can't decode command %s", cmdByteArrayToString(m.Command))
 	}
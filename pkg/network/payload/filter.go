@@ -0,0 +1,76 @@
+package payload
+
+import (
+	"errors"
+
+	"github.com/infinitete/neo-go-inf/pkg/io"
+)
+
+const (
+	// MaxFilterSize is the maximum size of the filterload bloom filter in
+	// bytes.
+	MaxFilterSize = 36000
+	// MaxFilterHashFuncs is the maximum number of hash functions of the
+	// filterload bloom filter.
+	MaxFilterHashFuncs = 50
+	// MaxFilterAddDataSize is the maximum size of the filteradd element.
+	MaxFilterAddDataSize = 520
+)
+
+type (
+	// FilterLoad payload sets the bloom filter of the peer.
+	FilterLoad struct {
+		Filter []byte
+		K      uint8
+		Tweak  uint32
+	}
+
+	// FilterAdd payload adds an element to the bloom filter of the peer.
+	FilterAdd struct {
+		Data []byte
+	}
+)
+
+// DecodeBinary implements Serializable interface.
+func (p *FilterLoad) DecodeBinary(br *io.BinReader) {
+	p.Filter = readLimitedBytes(br, MaxFilterSize)
+	br.ReadLE(&p.K)
+	if br.Err == nil && p.K > MaxFilterHashFuncs {
+		br.Err = errors.New("too many filter hash functions")
+		return
+	}
+	br.ReadLE(&p.Tweak)
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *FilterLoad) EncodeBinary(bw *io.BinWriter) {
+	bw.WriteBytes(p.Filter)
+	bw.WriteLE(p.K)
+	bw.WriteLE(p.Tweak)
+}
+
+// DecodeBinary implements Serializable interface.
+func (p *FilterAdd) DecodeBinary(br *io.BinReader) {
+	p.Data = readLimitedBytes(br, MaxFilterAddDataSize)
+}
+
+// EncodeBinary implements Serializable interface.
+func (p *FilterAdd) EncodeBinary(bw *io.BinWriter) {
+	bw.WriteBytes(p.Data)
+}
+
+// readLimitedBytes reads a variable-length byte slice checking that it's not
+// longer than max.
+func readLimitedBytes(br *io.BinReader, max int) []byte {
+	n := br.ReadVarUint()
+	if br.Err != nil {
+		return nil
+	}
+	if n > uint64(max) {
+		br.Err = errors.New("byte array is too long")
+		return nil
+	}
+	b := make([]byte, n)
+	br.ReadLE(b)
+	return b
+}
@@ -0,0 +1,57 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterLoadEncodeDecode(t *testing.T) {
+	expected := &FilterLoad{
+		Filter: []byte{1, 2, 3},
+		K:      10,
+		Tweak:  123456,
+	}
+	buf := io.NewBufBinWriter()
+	expected.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	actual := &FilterLoad{}
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, expected, actual)
+
+	for _, bad := range []*FilterLoad{
+		{Filter: make([]byte, MaxFilterSize+1), K: 1},
+		{Filter: []byte{1}, K: MaxFilterHashFuncs + 1},
+	} {
+		buf := io.NewBufBinWriter()
+		bad.EncodeBinary(buf.BinWriter)
+		require.NoError(t, buf.Err)
+		r := io.NewBinReaderFromBuf(buf.Bytes())
+		(&FilterLoad{}).DecodeBinary(r)
+		assert.Error(t, r.Err)
+	}
+}
+
+func TestFilterAddEncodeDecode(t *testing.T) {
+	expected := &FilterAdd{Data: []byte{1, 2, 3}}
+	buf := io.NewBufBinWriter()
+	expected.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	actual := &FilterAdd{}
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, expected, actual)
+
+	buf = io.NewBufBinWriter()
+	(&FilterAdd{Data: make([]byte, MaxFilterAddDataSize+1)}).EncodeBinary(buf.BinWriter)
+	r = io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	assert.Error(t, r.Err)
+}
@@ -37,6 +37,10 @@ const (
 	ConsensusType InventoryType = 0xe0 // 224
 )
 
+// MaxHashesCount is the maximum number of hashes sent in one inventory
+// message.
+const MaxHashesCount = 500
+
 // Inventory payload.
 type Inventory struct {
 	// Type if the object hash.
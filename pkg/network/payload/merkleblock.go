@@ -2,6 +2,7 @@ package payload
 
 import (
 	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 )
@@ -14,6 +15,34 @@ type MerkleBlock struct {
 	Flags   []byte
 }
 
+// NewMerkleBlock creates a MerkleBlock for the given block with the
+// transactions flagged (by their index in the block) included into the
+// partial merkle tree.
+func NewMerkleBlock(b *core.Block, flags []bool) (*MerkleBlock, error) {
+	hashes := make([]util.Uint256, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		hashes[i] = tx.Hash()
+	}
+	tree, err := crypto.NewMerkleTree(hashes)
+	if err != nil {
+		return nil, err
+	}
+	tree.Trim(flags)
+
+	bits := make([]byte, (len(flags)+7)/8)
+	for i, f := range flags {
+		if f {
+			bits[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return &MerkleBlock{
+		BlockBase: &b.BlockBase,
+		TxCount:   len(b.Transactions),
+		Hashes:    tree.ToHashArray(),
+		Flags:     bits,
+	}, nil
+}
+
 // DecodeBinary implements Serializable interface.
 func (m *MerkleBlock) DecodeBinary(br *io.BinReader) {
 	m.BlockBase = &core.BlockBase{}
@@ -30,7 +59,6 @@ func (m *MerkleBlock) DecodeBinary(br *io.BinReader) {
 
 // EncodeBinary implements Serializable interface.
 func (m *MerkleBlock) EncodeBinary(bw *io.BinWriter) {
-	m.BlockBase = &core.BlockBase{}
 	m.BlockBase.EncodeBinary(bw)
 
 	bw.WriteVarUint(uint64(m.TxCount))
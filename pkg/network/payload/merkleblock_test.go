@@ -0,0 +1,62 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleBlockEncodeDecode(t *testing.T) {
+	b := &core.Block{
+		BlockBase: core.BlockBase{
+			Index: 1,
+			Script: &transaction.Witness{
+				InvocationScript:   []byte{0x0},
+				VerificationScript: []byte{0x1},
+			},
+		},
+	}
+	hashes := make([]util.Uint256, 0, 3)
+	for i := uint32(0); i < 3; i++ {
+		tx := &transaction.Transaction{
+			Type: transaction.MinerType,
+			Data: &transaction.MinerTX{Nonce: i},
+		}
+		b.Transactions = append(b.Transactions, tx)
+		hashes = append(hashes, tx.Hash())
+	}
+	tree, err := crypto.NewMerkleTree(hashes)
+	require.NoError(t, err)
+	b.MerkleRoot = tree.Root()
+
+	expected, err := NewMerkleBlock(b, []bool{false, true, false})
+	require.NoError(t, err)
+	assert.Equal(t, 3, expected.TxCount)
+	assert.Equal(t, []byte{0x02}, expected.Flags)
+	require.Equal(t, 3, len(expected.Hashes))
+	assert.Equal(t, b.Transactions[0].Hash(), expected.Hashes[0])
+	assert.Equal(t, b.Transactions[1].Hash(), expected.Hashes[1])
+
+	buf := io.NewBufBinWriter()
+	expected.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+
+	actual := &MerkleBlock{}
+	r := io.NewBinReaderFromBuf(buf.Bytes())
+	actual.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, b.Hash(), actual.Hash())
+	assert.Equal(t, b.MerkleRoot, actual.MerkleRoot)
+	assert.Equal(t, expected.TxCount, actual.TxCount)
+	assert.Equal(t, expected.Hashes, actual.Hashes)
+	assert.Equal(t, expected.Flags, actual.Flags)
+
+	_, err = NewMerkleBlock(&core.Block{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package payload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodePing(t *testing.T) {
+	ping := NewPing(123, 456)
+	assert.InDelta(t, time.Now().UTC().Unix(), int64(ping.Timestamp), 1)
+
+	buf := io.NewBufBinWriter()
+	ping.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+	b := buf.Bytes()
+	assert.Equal(t, 12, len(b))
+
+	r := io.NewBinReaderFromBuf(b)
+	pingDecode := &Ping{}
+	pingDecode.DecodeBinary(r)
+	require.NoError(t, r.Err)
+	assert.Equal(t, ping, pingDecode)
+}
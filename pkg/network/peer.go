@@ -26,4 +26,9 @@ type Peer interface {
 	SendVersionAck(*Message) error
 	HandleVersion(*payload.Version) error
 	HandleVersionAck() error
+	// LastBlockIndex returns the last known height of the peer's chain,
+	// it's initialized from the version message and updated by pings and
+	// pongs.
+	LastBlockIndex() uint32
+	UpdateLastBlockIndex(uint32)
 }
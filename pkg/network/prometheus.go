@@ -38,6 +38,38 @@ var (
 			Namespace: "neogo",
 		},
 	)
+
+	syncTargetHeight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Help:      "Highest block height reported by peers",
+			Name:      "sync_target_height",
+			Namespace: "neogo",
+		},
+	)
+
+	syncBlocksInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Help:      "Number of requested blocks not yet received",
+			Name:      "sync_blocks_in_flight",
+			Namespace: "neogo",
+		},
+	)
+
+	syncBlockTimeouts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of block requests timed out",
+			Name:      "sync_block_timeouts_total",
+			Namespace: "neogo",
+		},
+	)
+
+	syncBadBlocks = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of bad blocks received from peers",
+			Name:      "sync_bad_blocks_total",
+			Namespace: "neogo",
+		},
+	)
 )
 
 func init() {
@@ -46,6 +78,10 @@ func init() {
 		servAndNodeVersion,
 		poolCount,
 		blockQueueLength,
+		syncTargetHeight,
+		syncBlocksInFlight,
+		syncBlockTimeouts,
+		syncBadBlocks,
 	)
 }
 
@@ -53,6 +89,14 @@ func updateBlockQueueLenMetric(bqLen int) {
 	blockQueueLength.Set(float64(bqLen))
 }
 
+func updateSyncTargetHeightMetric(height uint32) {
+	syncTargetHeight.Set(float64(height))
+}
+
+func updateSyncBlocksInFlightMetric(n int) {
+	syncBlocksInFlight.Set(float64(n))
+}
+
 func updatePoolCountMetric(pCount int) {
 	poolCount.Set(float64(pCount))
 }
@@ -9,8 +9,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/infinitete/neo-go-inf/pkg/consensus"
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
 	"github.com/infinitete/neo-go-inf/pkg/network/payload"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 	log "github.com/sirupsen/logrus"
@@ -21,9 +23,18 @@ const (
 	defaultMinPeers         = 5
 	defaultAttemptConnPeers = 20
 	defaultMaxPeers         = 100
+	defaultPingInterval     = 30 * time.Second
+	defaultAddrMaxAge       = 5 * 24 * time.Hour
 	maxBlockBatch           = 200
 	maxAddrsToSend          = 200
 	minPoolCount            = 30
+	// addrBookSaveInterval is the interval of saving the address book to
+	// disk.
+	addrBookSaveInterval = time.Minute
+	// maxBlockRelayLag is the maximum distance from the highest known
+	// peer height for the block to be announced, older blocks (like the
+	// ones received during the initial sync) are not announced.
+	maxBlockRelayLag = 20
 )
 
 var (
@@ -50,9 +61,19 @@ type (
 		discovery Discoverer
 		chain     core.Blockchainer
 		bQueue    *blockQueue
+		syncMgr   *syncManager
+		consensus consensus.Service
 
 		lock  sync.RWMutex
 		peers map[Peer]bool
+		// ownAddrs are the addresses we've connected to ourselves with.
+		ownAddrs map[string]bool
+		// localIPs are the addresses of the local network interfaces.
+		localIPs []net.IP
+		// filters are the bloom filters set by peers.
+		filters map[Peer]*crypto.BloomFilter
+
+		addrBook *addressBook
 
 		addrReq    chan *Message
 		register   chan Peer
@@ -67,17 +88,43 @@ type (
 )
 
 // NewServer returns a new Server, initialized with the given configuration.
-func NewServer(config ServerConfig, chain core.Blockchainer) *Server {
+// If the wallet is configured the Server also takes part in consensus.
+func NewServer(config ServerConfig, chain core.Blockchainer) (*Server, error) {
 	s := &Server{
 		ServerConfig: config,
 		chain:        chain,
-		bQueue:       newBlockQueue(maxBlockBatch, chain),
 		id:           rand.Uint32(),
 		quit:         make(chan struct{}),
 		addrReq:      make(chan *Message, config.MinPeers),
 		register:     make(chan Peer),
 		unregister:   make(chan peerDrop),
 		peers:        make(map[Peer]bool),
+		ownAddrs:     make(map[string]bool),
+		filters:      make(map[Peer]*crypto.BloomFilter),
+		addrBook:     newAddressBook(config.AddressBookPath),
+		localIPs:     interfaceIPs(),
+	}
+	s.bQueue = newBlockQueue(maxBlockBatch, chain, s.relayBlock)
+	s.syncMgr = newSyncManager(chain, blockRequestTimeout)
+	if err := s.addrBook.load(); err != nil {
+		log.WithFields(log.Fields{
+			"path": config.AddressBookPath,
+		}).Warnf("can't load address book: %s", err)
+	}
+
+	if config.Wallet != nil {
+		srv, err := consensus.NewService(consensus.Config{
+			Broadcast:    s.relayConsensusPayload,
+			RelayBlock:   s.handleConsensusBlock,
+			RequestTx:    s.requestTx,
+			Chain:        chain,
+			TimePerBlock: config.TimePerBlock,
+			Wallet:       config.Wallet,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't initialize consensus: %s", err)
+		}
+		s.consensus = srv
 	}
 
 	if s.MinPeers <= 0 {
@@ -104,13 +151,29 @@ func NewServer(config ServerConfig, chain core.Blockchainer) *Server {
 		s.AttemptConnPeers = defaultAttemptConnPeers
 	}
 
+	if s.PingInterval <= 0 {
+		log.WithFields(log.Fields{
+			"PingInterval configured": s.PingInterval,
+			"PingInterval actual":     defaultPingInterval,
+		}).Info("bad PingInterval configured, using the default value")
+		s.PingInterval = defaultPingInterval
+	}
+
+	if s.AddrMaxAge <= 0 {
+		log.WithFields(log.Fields{
+			"AddrMaxAge configured": s.AddrMaxAge,
+			"AddrMaxAge actual":     defaultAddrMaxAge,
+		}).Info("bad AddrMaxAge configured, using the default value")
+		s.AddrMaxAge = defaultAddrMaxAge
+	}
+
 	s.transport = NewTCPTransport(s, fmt.Sprintf("%s:%d", config.Address, config.Port))
 	s.discovery = NewDefaultDiscovery(
 		s.DialTimeout,
 		s.transport,
 	)
 
-	return s
+	return s, nil
 }
 
 // ID returns the servers ID.
@@ -126,8 +189,12 @@ func (s *Server) Start(errChan chan error) {
 	}).Info("node started")
 
 	s.discovery.BackFill(s.Seeds...)
+	s.discovery.BackFill(s.addrBook.addresses()...)
 
 	go s.bQueue.run()
+	if s.consensus != nil {
+		s.consensus.Start()
+	}
 	go s.transport.Accept()
 	setServerAndNodeVersions(s.UserAgent, strconv.FormatUint(uint64(s.id), 10))
 	s.run()
@@ -139,6 +206,9 @@ func (s *Server) Shutdown() {
 		"peers": s.PeerCount(),
 	}).Info("shutting down server")
 	s.bQueue.discard()
+	if s.consensus != nil {
+		s.consensus.Shutdown()
+	}
 	close(s.quit)
 }
 
@@ -154,6 +224,8 @@ func (s *Server) BadPeers() []string {
 }
 
 func (s *Server) run() {
+	saveTicker := time.NewTicker(addrBookSaveInterval)
+	defer saveTicker.Stop()
 	for {
 		if s.PeerCount() < s.MinPeers {
 			s.discovery.RequestRemote(s.AttemptConnPeers)
@@ -173,7 +245,10 @@ func (s *Server) run() {
 			for p := range s.peers {
 				p.Disconnect(errServerShutdown)
 			}
+			s.saveAddressBook()
 			return
+		case <-saveTicker.C:
+			s.saveAddressBook()
 		case p := <-s.register:
 			// When a new peer is connected we send out our version immediately.
 			if err := s.sendVersion(p); err != nil {
@@ -203,7 +278,9 @@ func (s *Server) run() {
 			s.lock.Lock()
 			if s.peers[drop.peer] {
 				delete(s.peers, drop.peer)
+				delete(s.filters, drop.peer)
 				s.lock.Unlock()
+				s.syncMgr.removePeer(drop.peer)
 				log.WithFields(log.Fields{
 					"addr":      drop.peer.RemoteAddr(),
 					"reason":    drop.reason,
@@ -211,7 +288,14 @@ func (s *Server) run() {
 				}).Warn("peer disconnected")
 				addr := drop.peer.PeerAddr().String()
 				s.discovery.UnregisterConnectedAddr(addr)
-				s.discovery.BackFill(addr)
+				if drop.reason == errIdenticalID {
+					s.lock.Lock()
+					s.ownAddrs[addr] = true
+					s.lock.Unlock()
+					s.addrBook.remove(addr)
+				} else {
+					s.discovery.BackFill(addr)
+				}
 				updatePeersConnectedMetric(s.PeerCount())
 			} else {
 				// else the peer is already gone, which can happen
@@ -247,6 +331,7 @@ func (s *Server) startProtocol(p Peer) {
 	}).Info("started protocol")
 
 	s.discovery.RegisterGoodAddr(p.PeerAddr().String())
+	s.addrBook.add(p.PeerAddr().String(), uint32(time.Now().Unix()))
 	err := s.requestHeaders(p)
 	if err != nil {
 		p.Disconnect(err)
@@ -254,15 +339,19 @@ func (s *Server) startProtocol(p Peer) {
 	}
 
 	timer := time.NewTimer(s.ProtoTickInterval)
+	pingTicker := time.NewTicker(s.PingInterval)
 	for {
 		select {
 		case err = <-p.Done():
 			// time to stop
 		case m := <-s.addrReq:
 			err = p.WriteMsg(m)
+		case <-pingTicker.C:
+			// Keep the peer's height up to date, it replies with a pong.
+			err = s.sendPing(p)
 		case <-timer.C:
 			// Try to sync in headers and block with the peer if his block height is higher then ours.
-			if p.Version().StartHeight > s.chain.BlockHeight() {
+			if p.LastBlockIndex() > s.chain.BlockHeight() {
 				err = s.requestBlocks(p)
 			}
 			if err == nil {
@@ -272,6 +361,7 @@ func (s *Server) startProtocol(p Peer) {
 		if err != nil {
 			s.unregister <- peerDrop{p, err}
 			timer.Stop()
+			pingTicker.Stop()
 			p.Disconnect(err)
 			return
 		}
@@ -325,22 +415,61 @@ func (s *Server) handleHeadersCmd(p Peer, headers *payload.Headers) {
 	// The peer will respond with a maximum of 2000 headers in one batch.
 	// We will ask one more batch here if needed. Eventually we will get synced
 	// due to the startProtocol routine that will ask headers every protoTick.
-	if s.chain.HeaderHeight() < p.Version().StartHeight {
+	if s.chain.HeaderHeight() < p.LastBlockIndex() {
 		s.requestHeaders(p)
 	}
 }
 
 // handleBlockCmd processes the received block received from its peer.
+// Peers sending bad blocks are disconnected, more blocks are requested
+// from the peer once it has delivered all the requested ones. The request
+// is put into the peer's send queue, so the reader doesn't wait for the
+// connection to be writable.
 func (s *Server) handleBlockCmd(p Peer, block *core.Block) error {
-	return s.bQueue.putBlock(block)
+	idle, err := s.syncMgr.blockReceived(p, block)
+	if err != nil {
+		s.discovery.RegisterBadAddr(p.PeerAddr().String())
+		return err
+	}
+	if err = s.bQueue.putBlock(block); err != nil {
+		return err
+	}
+	if idle && p.LastBlockIndex() > s.chain.BlockHeight() {
+		return s.requestBlocks(p)
+	}
+	return nil
 }
 
 // handleInvCmd processes the received inventory.
 func (s *Server) handleInvCmd(p Peer, inv *payload.Inventory) error {
-	payload := payload.NewInventory(inv.Type, inv.Hashes)
+	var reqHashes []util.Uint256
+	for _, hash := range inv.Hashes {
+		if !s.hasInventory(inv.Type, hash) {
+			reqHashes = append(reqHashes, hash)
+		}
+	}
+	if len(reqHashes) == 0 {
+		return nil
+	}
+	payload := payload.NewInventory(inv.Type, reqHashes)
 	return p.WriteMsg(NewMessage(s.Net, CMDGetData, payload))
 }
 
+// hasInventory returns true if the item of the given type and hash doesn't
+// need to be requested from the peer.
+func (s *Server) hasInventory(t payload.InventoryType, hash util.Uint256) bool {
+	switch t {
+	case payload.TXType:
+		return s.chain.HasTransaction(hash)
+	case payload.BlockType:
+		return s.chain.HasBlock(hash)
+	case payload.ConsensusType:
+		// Consensus payloads are only useful for the consensus service.
+		return s.consensus == nil || s.consensus.GetPayload(hash) != nil
+	}
+	return false
+}
+
 // handleInvCmd processes the received inventory.
 func (s *Server) handleGetDataCmd(p Peer, inv *payload.Inventory) error {
 	switch inv.Type {
@@ -359,23 +488,178 @@ func (s *Server) handleGetDataCmd(p Peer, inv *payload.Inventory) error {
 		for _, hash := range inv.Hashes {
 			b, err := s.chain.GetBlock(hash)
 			if err == nil {
-				err = p.WriteMsg(NewMessage(s.Net, CMDBlock, b))
+				msg, err := s.newBlockMessage(p, b)
 				if err != nil {
 					return err
 				}
+				if err = p.WriteMsg(msg); err != nil {
+					return err
+				}
 			}
 		}
 	case payload.ConsensusType:
-		// TODO (#431)
+		if s.consensus == nil {
+			break
+		}
+		for _, hash := range inv.Hashes {
+			cp := s.consensus.GetPayload(hash)
+			if cp != nil {
+				err := p.WriteMsg(NewMessage(s.Net, CMDConsensus, cp))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// handleConsensusCmd processes the received consensus payload.
+func (s *Server) handleConsensusCmd(cp *consensus.Payload) error {
+	if s.consensus != nil {
+		s.consensus.OnPayload(cp)
 	}
 	return nil
 }
 
-// handleAddrCmd will process received addresses.
+// handleTxCmd processes the received transaction.
+func (s *Server) handleTxCmd(tx *transaction.Transaction) error {
+	// It's OK for it to fail for various reasons like the transaction
+	// already existing in the pool, so the error isn't returned.
+	if s.RelayTxn(tx) == RelaySucceed && s.consensus != nil {
+		s.consensus.OnTransaction(tx)
+	}
+	return nil
+}
+
+// handleAddrCmd will process received addresses, valid ones are added to the
+// discovery pool and to the address book.
 func (s *Server) handleAddrCmd(p Peer, addrs *payload.AddressList) error {
+	bad := make(map[string]bool)
+	for _, addr := range s.discovery.BadPeers() {
+		bad[addr] = true
+	}
+	minTS := uint32(time.Now().Add(-s.AddrMaxAge).Unix())
+	maxTS := uint32(time.Now().Add(s.AddrMaxAge).Unix())
+
+	newAddrs := make([]string, 0, len(addrs.Addrs))
 	for _, a := range addrs.Addrs {
-		s.discovery.BackFill(a.IPPortString())
+		addr := a.IPPortString()
+		if a.Timestamp < minTS || a.Timestamp > maxTS || bad[addr] ||
+			!s.isValidPeerAddr(a) || s.isOwnAddr(addr) {
+			continue
+		}
+		s.addrBook.add(addr, a.Timestamp)
+		newAddrs = append(newAddrs, addr)
+	}
+	s.discovery.BackFill(newAddrs...)
+	return nil
+}
+
+// isValidPeerAddr checks whether the given address can be connected to,
+// loopback addresses are never valid for remote peers whatever the port.
+func (s *Server) isValidPeerAddr(a *payload.AddressAndTime) bool {
+	ip := net.IP(a.IP[:])
+	return a.Port != 0 && !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsMulticast()
+}
+
+// isOwnAddr checks whether the given address is the address of this node.
+func (s *Server) isOwnAddr(addr string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.ownAddrs[addr] {
+		return true
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port != strconv.Itoa(int(s.Port)) {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if s.Address != "" && ip.Equal(net.ParseIP(s.Address)) {
+		return true
+	}
+	for _, localIP := range s.localIPs {
+		if localIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceIPs returns the addresses of the local network interfaces.
+func interfaceIPs() []net.IP {
+	ifAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Warnf("can't get interface addresses: %s", err)
+		return nil
+	}
+	ips := make([]net.IP, 0, len(ifAddrs))
+	for _, ifAddr := range ifAddrs {
+		if ipNet, ok := ifAddr.(*net.IPNet); ok {
+			ips = append(ips, ipNet.IP)
+		}
 	}
+	return ips
+}
+
+// saveAddressBook removes bad addresses from the address book and saves it
+// to disk.
+func (s *Server) saveAddressBook() {
+	s.addrBook.remove(s.discovery.BadPeers()...)
+	if err := s.addrBook.save(); err != nil {
+		log.WithFields(log.Fields{
+			"path": s.AddressBookPath,
+		}).Warnf("can't save address book: %s", err)
+	}
+}
+
+// handleMempoolCmd sends the hashes of the verified transactions in the
+// memory pool to the peer in chunks of at most payload.MaxHashesCount.
+func (s *Server) handleMempoolCmd(p Peer) error {
+	pool := s.chain.GetMemPool()
+	txs := pool.GetVerifiedTransactions()
+	hashes := make([]util.Uint256, 0, len(txs))
+	s.lock.RLock()
+	for _, tx := range txs {
+		if s.peerWantsTx(p, tx) {
+			hashes = append(hashes, tx.Hash())
+		}
+	}
+	s.lock.RUnlock()
+
+	for start := 0; start < len(hashes); start += payload.MaxHashesCount {
+		end := start + payload.MaxHashesCount
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		inv := payload.NewInventory(payload.TXType, hashes[start:end])
+		if err := p.WriteMsg(NewMessage(s.Net, CMDInv, inv)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPing sends a ping with our current height to the peer.
+func (s *Server) sendPing(p Peer) error {
+	ping := payload.NewPing(s.chain.BlockHeight(), s.id)
+	return p.WriteMsg(NewMessage(s.Net, CMDPing, ping))
+}
+
+// handlePingCmd updates the height of the peer and replies with a pong
+// carrying our height.
+func (s *Server) handlePingCmd(p Peer, ping *payload.Ping) error {
+	p.UpdateLastBlockIndex(ping.LastBlockIndex)
+	pong := payload.NewPing(s.chain.BlockHeight(), s.id)
+	return p.WriteMsg(NewMessage(s.Net, CMDPong, pong))
+}
+
+// handlePongCmd updates the height of the peer.
+func (s *Server) handlePongCmd(p Peer, pong *payload.Ping) error {
+	p.UpdateLastBlockIndex(pong.LastBlockIndex)
 	return nil
 }
 
@@ -404,23 +688,18 @@ func (s *Server) requestHeaders(p Peer) error {
 }
 
 // requestBlocks sends a getdata message to the peer
-// to sync up in blocks. A maximum of maxBlockBatch will
-// send at once.
+// to sync up in blocks. The blocks are picked by the sync
+// manager, a maximum of maxBlockBatch will be in flight
+// for every peer.
 func (s *Server) requestBlocks(p Peer) error {
-	var (
-		hashes       []util.Uint256
-		hashStart    = s.chain.BlockHeight() + 1
-		headerHeight = s.chain.HeaderHeight()
-	)
-	for hashStart <= headerHeight && len(hashes) < maxBlockBatch {
-		hash := s.chain.GetHeaderHash(int(hashStart))
-		hashes = append(hashes, hash)
-		hashStart++
+	hashes, err := s.syncMgr.nextBlocks(p)
+	if err != nil {
+		return err
 	}
 	if len(hashes) > 0 {
 		payload := payload.NewInventory(payload.BlockType, hashes)
 		return p.WriteMsg(NewMessage(s.Net, CMDGetData, payload))
-	} else if s.chain.HeaderHeight() < p.Version().StartHeight {
+	} else if s.chain.HeaderHeight() < p.LastBlockIndex() {
 		return s.requestHeaders(p)
 	}
 	return nil
@@ -459,6 +738,29 @@ func (s *Server) handleMessage(peer Peer, msg *Message) error {
 		case CMDBlock:
 			block := msg.Payload.(*core.Block)
 			return s.handleBlockCmd(peer, block)
+		case CMDConsensus:
+			cp := msg.Payload.(*consensus.Payload)
+			return s.handleConsensusCmd(cp)
+		case CMDTX:
+			tx := msg.Payload.(*transaction.Transaction)
+			return s.handleTxCmd(tx)
+		case CMDFilterLoad:
+			fl := msg.Payload.(*payload.FilterLoad)
+			return s.handleFilterLoadCmd(peer, fl)
+		case CMDFilterAdd:
+			fa := msg.Payload.(*payload.FilterAdd)
+			return s.handleFilterAddCmd(peer, fa)
+		case CMDFilterClear:
+			return s.handleFilterClearCmd(peer)
+		case CMDMempool:
+			// it has no payload
+			return s.handleMempoolCmd(peer)
+		case CMDPing:
+			ping := msg.Payload.(*payload.Ping)
+			return s.handlePingCmd(peer, ping)
+		case CMDPong:
+			pong := msg.Payload.(*payload.Ping)
+			return s.handlePongCmd(peer, pong)
 		case CMDVersion, CMDVerack:
 			return fmt.Errorf("received '%s' after the handshake", msg.CommandType())
 		}
@@ -495,18 +797,134 @@ func (s *Server) RelayTxn(t *transaction.Transaction) RelayReason {
 	// TODO: Implement Plugin.CheckPolicy?
 	//if (!Plugin.CheckPolicy(transaction))
 	// return RelayResultReason.PolicyFail;
-	if ok := s.chain.GetMemPool().TryAdd(t.Hash(), core.NewPoolItem(t, s.chain)); !ok {
+	if err := s.chain.PoolTx(t); err != nil {
 		return RelayOutOfMemory
 	}
 
-	for p := range s.Peers() {
-		payload := payload.NewInventory(payload.TXType, []util.Uint256{t.Hash()})
+	// Peers with bloom filters only want matching transactions, the
+	// messages are sent without holding the lock.
+	peers := s.handshakedPeers()
+	wanted := peers[:0]
+	s.lock.RLock()
+	for _, p := range peers {
+		if s.peerWantsTx(p, t) {
+			wanted = append(wanted, p)
+		}
+	}
+	s.lock.RUnlock()
+
+	payload := payload.NewInventory(payload.TXType, []util.Uint256{t.Hash()})
+	for _, p := range wanted {
 		s.RelayDirectly(p, payload)
 	}
 
 	return RelaySucceed
 }
 
+// RelayBlock fully verifies the given block (including its transactions),
+// adds it to the chain and announces it to the connected peers.
+func (s *Server) RelayBlock(b *core.Block) RelayReason {
+	height := s.chain.BlockHeight()
+	if b.Index <= height || s.chain.HasBlock(b.Hash()) {
+		return RelayAlreadyExists
+	}
+	if b.Index != height+1 {
+		return RelayUnableToVerify
+	}
+	if err := b.Verify(); err != nil {
+		return RelayInvalid
+	}
+	for _, tx := range b.Transactions {
+		if err := s.chain.VerifyTx(tx, b); err != nil {
+			return RelayInvalid
+		}
+	}
+	if err := s.chain.VerifyBlock(b); err != nil {
+		return RelayInvalid
+	}
+	if err := s.chain.AddBlock(b); err != nil {
+		if b.Index <= s.chain.BlockHeight() {
+			return RelayAlreadyExists
+		}
+		return RelayInvalid
+	}
+	s.relayBlock(b)
+	return RelaySucceed
+}
+
+// relayInventory announces the inventory of the given type to all the
+// connected peers.
+func (s *Server) relayInventory(t payload.InventoryType, hashes ...util.Uint256) {
+	inv := payload.NewInventory(t, hashes)
+	for _, p := range s.handshakedPeers() {
+		s.RelayDirectly(p, inv)
+	}
+}
+
+// handshakedPeers returns the list of the peers that have completed the
+// handshake. It's a copy, so messages can be sent to them without holding
+// the server lock.
+func (s *Server) handshakedPeers() []Peer {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	peers := make([]Peer, 0, len(s.peers))
+	for p := range s.peers {
+		if p.Handshaked() {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+// bestPeerHeight returns the highest block height known by the connected
+// peers.
+func (s *Server) bestPeerHeight() uint32 {
+	var best uint32
+	for _, p := range s.handshakedPeers() {
+		if h := p.LastBlockIndex(); h > best {
+			best = h
+		}
+	}
+	return best
+}
+
+// relayBlock announces the block added to the chain to the connected peers
+// (unless it's too far behind them) and notifies the consensus service
+// about it.
+func (s *Server) relayBlock(b *core.Block) {
+	if b.Index+maxBlockRelayLag >= s.bestPeerHeight() {
+		s.relayInventory(payload.BlockType, b.Hash())
+	}
+	if s.consensus != nil {
+		s.consensus.OnNewBlock(b)
+	}
+}
+
+// relayConsensusPayload announces the consensus payload to the connected
+// peers.
+func (s *Server) relayConsensusPayload(cp *consensus.Payload) {
+	s.relayInventory(payload.ConsensusType, cp.Hash())
+}
+
+// handleConsensusBlock adds the block produced by the consensus to the
+// chain, it's announced to other nodes once added.
+func (s *Server) handleConsensusBlock(b *core.Block) {
+	if err := s.bQueue.putBlock(b); err != nil {
+		log.WithFields(log.Fields{
+			"height": b.Index,
+		}).Warnf("failed to add block produced by consensus: %s", err)
+	}
+}
+
+// requestTx requests the transactions with the given hashes from the
+// connected peers.
+func (s *Server) requestTx(hashes ...util.Uint256) {
+	msg := NewMessage(s.Net, CMDGetData, payload.NewInventory(payload.TXType, hashes))
+	for _, p := range s.handshakedPeers() {
+		_ = p.WriteMsg(msg)
+	}
+}
+
 // RelayDirectly relays directly the inventory to the remote peers.
 // Reference: the method OnRelayDirectly in C#: https://github.com/neo-project/neo/blob/master/neo/Network/P2P/LocalNode.cs#L166
 func (s *Server) RelayDirectly(p Peer, inv *payload.Inventory) {
@@ -45,6 +45,15 @@ type (
 		// Seeds are a list of initial nodes used to establish connectivity.
 		Seeds []string
 
+		// AddressBookPath is the file the known peer addresses are stored
+		// in, they're not persisted if it's empty.
+		AddressBookPath string
+
+		// AddrMaxAge is the maximum age of the addresses received from
+		// peers, older ones are ignored. When this is 0, the default of
+		// 5 days will be used.
+		AddrMaxAge time.Duration
+
 		// Maximum duration a single dial may take.
 		DialTimeout time.Duration
 
@@ -52,8 +61,20 @@ type (
 		// When this is 0, the default interval of 5 seconds will be used.
 		ProtoTickInterval time.Duration
 
+		// The interval of pinging the peers to keep their heights up to
+		// date. When this is 0, the default interval of 30 seconds will
+		// be used.
+		PingInterval time.Duration
+
 		// Level of the internal logger.
 		LogLevel log.Level
+
+		// Wallet is the wallet used to take part in consensus, the
+		// consensus service isn't started when it's nil.
+		Wallet *config.WalletConfig
+
+		// TimePerBlock is the expected interval between two blocks.
+		TimePerBlock time.Duration
 	}
 )
 
@@ -70,10 +91,15 @@ func NewServerConfig(cfg config.Config) ServerConfig {
 		Net:               protoConfig.Magic,
 		Relay:             appConfig.Relay,
 		Seeds:             protoConfig.SeedList,
+		AddressBookPath:   appConfig.AddressBookPath,
+		AddrMaxAge:        appConfig.AddrMaxAge * time.Second,
 		DialTimeout:       appConfig.DialTimeout * time.Second,
 		ProtoTickInterval: appConfig.ProtoTickInterval * time.Second,
+		PingInterval:      appConfig.PingInterval * time.Second,
 		MaxPeers:          appConfig.MaxPeers,
 		AttemptConnPeers:  appConfig.AttemptConnPeers,
 		MinPeers:          appConfig.MinPeers,
+		Wallet:            appConfig.UnlockWallet,
+		TimePerBlock:      time.Duration(protoConfig.SecondsPerBlock) * time.Second,
 	}
 }
@@ -3,8 +3,13 @@ package network
 import (
 	"net"
 	"testing"
+	"time"
 
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
 	"github.com/infinitete/neo-go-inf/pkg/network/payload"
+	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -100,3 +105,196 @@ func TestRequestHeaders(t *testing.T) {
 	}
 	s.requestHeaders(p)
 }
+
+func TestHandleAddrCmd(t *testing.T) {
+	s := newTestServer()
+	s.Port = 10333
+	s.AddrMaxAge = defaultAddrMaxAge
+	s.ownAddrs["5.5.5.5:10333"] = true
+	s.localIPs = []net.IP{net.ParseIP("6.6.6.6")}
+
+	now := time.Now()
+	addr := func(s string, ts time.Time) *payload.AddressAndTime {
+		a, err := net.ResolveTCPAddr("tcp", s)
+		require.NoError(t, err)
+		return payload.NewAddressAndTime(a, ts)
+	}
+	list := &payload.AddressList{Addrs: []*payload.AddressAndTime{
+		addr("1.1.1.1:10333", now),
+		addr("2.2.2.2:20333", now.Add(-48*time.Hour)),
+		// Too old.
+		addr("3.3.3.3:10333", now.Add(-time.Hour-defaultAddrMaxAge)),
+		// Invalid.
+		addr("4.4.4.4:0", now),
+		addr("0.0.0.0:10333", now),
+		addr("0.0.0.0:20333", now),
+		addr("[::]:20333", now),
+		addr("127.0.0.1:10333", now),
+		addr("127.0.0.1:20333", now),
+		addr("[::1]:20333", now),
+		// Our own.
+		addr("5.5.5.5:10333", now),
+		addr("6.6.6.6:10333", now),
+	}}
+	require.NoError(t, s.handleAddrCmd(newLocalPeer(t), list))
+	assert.Equal(t, []string{"1.1.1.1:10333", "2.2.2.2:20333"}, s.addrBook.addresses())
+}
+
+func TestHandleFilterCmds(t *testing.T) {
+	s := newTestServer()
+	p := newLocalPeer(t)
+
+	tx := &transaction.Transaction{
+		Type: transaction.MinerType,
+		Data: &transaction.MinerTX{Nonce: 1},
+	}
+	other := &transaction.Transaction{
+		Type: transaction.MinerType,
+		Data: &transaction.MinerTX{Nonce: 2},
+	}
+	tree, err := crypto.NewMerkleTree([]util.Uint256{tx.Hash(), other.Hash()})
+	require.NoError(t, err)
+	b := &core.Block{
+		BlockBase: core.BlockBase{
+			Index:      1,
+			MerkleRoot: tree.Root(),
+			Script:     &transaction.Witness{},
+		},
+		Transactions: []*transaction.Transaction{tx, other},
+	}
+	s.chain = &testChain{blocks: map[util.Uint256]*core.Block{b.Hash(): b}}
+	inv := payload.NewInventory(payload.BlockType, []util.Uint256{b.Hash()})
+
+	var msgs []*Message
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		msgs = append(msgs, msg)
+	}
+
+	require.NoError(t, s.handleGetDataCmd(p, inv))
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDBlock, msgs[0].CommandType())
+
+	require.NoError(t, s.handleFilterLoadCmd(p, &payload.FilterLoad{Filter: make([]byte, 8), K: 3}))
+	assert.False(t, s.peerWantsTx(p, tx))
+	require.NoError(t, s.handleFilterAddCmd(p, &payload.FilterAdd{Data: tx.Hash().Bytes()}))
+	assert.True(t, s.peerWantsTx(p, tx))
+
+	msgs = nil
+	require.NoError(t, s.handleGetDataCmd(p, inv))
+	require.Equal(t, 1, len(msgs))
+	require.Equal(t, CMDMerkleBlock, msgs[0].CommandType())
+	mb := msgs[0].Payload.(*payload.MerkleBlock)
+	assert.Equal(t, 2, mb.TxCount)
+	assert.Equal(t, []byte{0x01}, mb.Flags)
+
+	require.NoError(t, s.handleFilterClearCmd(p))
+	assert.True(t, s.peerWantsTx(p, other))
+	msgs = nil
+	require.NoError(t, s.handleGetDataCmd(p, inv))
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDBlock, msgs[0].CommandType())
+}
+
+func TestHandlePingPong(t *testing.T) {
+	s := newTestServer()
+	s.chain = &testChain{blockheight: 10}
+	p := newLocalPeer(t)
+	require.NoError(t, p.HandleVersion(&payload.Version{StartHeight: 5}))
+	assert.Equal(t, uint32(5), p.LastBlockIndex())
+
+	var msgs []*Message
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		msgs = append(msgs, msg)
+	}
+
+	require.NoError(t, s.sendPing(p))
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDPing, msgs[0].CommandType())
+	assert.Equal(t, uint32(10), msgs[0].Payload.(*payload.Ping).LastBlockIndex)
+
+	msgs = nil
+	require.NoError(t, s.handlePingCmd(p, payload.NewPing(20, 1)))
+	assert.Equal(t, uint32(20), p.LastBlockIndex())
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDPong, msgs[0].CommandType())
+	pong := msgs[0].Payload.(*payload.Ping)
+	assert.Equal(t, uint32(10), pong.LastBlockIndex)
+	assert.Equal(t, s.id, pong.Nonce)
+
+	require.NoError(t, s.handlePongCmd(p, payload.NewPing(25, 1)))
+	assert.Equal(t, uint32(25), p.LastBlockIndex())
+}
+
+// testFeer is a Feer making all transactions free.
+type testFeer struct{}
+
+func (testFeer) NetworkFee(*transaction.Transaction) util.Fixed8 { return 0 }
+func (testFeer) IsLowPriority(*transaction.Transaction) bool     { return false }
+func (testFeer) FeePerByte(*transaction.Transaction) util.Fixed8 { return 0 }
+func (testFeer) SystemFee(*transaction.Transaction) util.Fixed8  { return 0 }
+
+func TestHandleMempoolCmd(t *testing.T) {
+	const txCount = payload.MaxHashesCount + 10
+
+	pool := core.NewMemPool(txCount)
+	for i := 0; i < txCount; i++ {
+		tx := &transaction.Transaction{
+			Type: transaction.ContractType,
+			Data: &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{{
+				Usage: transaction.Remark,
+				Data:  []byte{byte(i), byte(i >> 8)},
+			}},
+		}
+		require.True(t, pool.TryAdd(tx.Hash(), core.NewPoolItem(tx, testFeer{})))
+	}
+	s := newTestServer()
+	s.chain = &testChain{pool: pool}
+	p := newLocalPeer(t)
+
+	var invs []*payload.Inventory
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		require.Equal(t, CMDInv, msg.CommandType())
+		invs = append(invs, msg.Payload.(*payload.Inventory))
+	}
+	require.NoError(t, s.handleMempoolCmd(p))
+	require.Equal(t, 2, len(invs))
+	assert.Equal(t, payload.MaxHashesCount, len(invs[0].Hashes))
+	assert.Equal(t, 10, len(invs[1].Hashes))
+	for _, inv := range invs {
+		assert.Equal(t, payload.TXType, inv.Type)
+		for _, h := range inv.Hashes {
+			assert.True(t, pool.ContainsKey(h))
+		}
+	}
+
+	// Filtered peers only get matching transactions.
+	invs = nil
+	require.NoError(t, s.handleFilterLoadCmd(p, &payload.FilterLoad{Filter: make([]byte, 8), K: 3}))
+	require.NoError(t, s.handleMempoolCmd(p))
+	assert.Equal(t, 0, len(invs))
+}
+
+func TestRelayBlock(t *testing.T) {
+	s := newTestServer()
+	p := newLocalPeer(t)
+	p.handshaked = true
+	require.NoError(t, p.HandleVersion(&payload.Version{StartHeight: 100, Relay: true}))
+	s.peers[p] = true
+
+	var invs []*payload.Inventory
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		require.Equal(t, CMDInv, msg.CommandType())
+		invs = append(invs, msg.Payload.(*payload.Inventory))
+	}
+
+	// Old blocks are not announced.
+	s.relayBlock(&core.Block{BlockBase: core.BlockBase{Index: 100 - maxBlockRelayLag - 1}})
+	assert.Equal(t, 0, len(invs))
+
+	b := &core.Block{BlockBase: core.BlockBase{Index: 100 - maxBlockRelayLag}}
+	s.relayBlock(b)
+	require.Equal(t, 1, len(invs))
+	assert.Equal(t, payload.BlockType, invs[0].Type)
+	assert.Equal(t, []util.Uint256{b.Hash()}, invs[0].Hashes)
+}
@@ -0,0 +1,202 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+const (
+	// syncWindowSize is the maximum number of blocks above the current
+	// chain height that can be requested from peers.
+	syncWindowSize = 2000
+	// blockRequestTimeout is the time a peer has to deliver the requested
+	// block, it's requested from some other peer after that.
+	blockRequestTimeout = 15 * time.Second
+	// maxSyncPenalty is the penalty after which the peer is no longer
+	// used for synchronization and is disconnected. Every penalty point
+	// halves the number of blocks that can be requested from the peer.
+	maxSyncPenalty = 5
+)
+
+var errSyncPenalty = errors.New("too many block requests timed out")
+
+type (
+	// syncManager spreads block requests among the peers. Each peer gets
+	// the lowest block indexes not yet requested (up to its height) and
+	// requests not served in time are handed over to other peers.
+	syncManager struct {
+		chain   core.Blockchainer
+		timeout time.Duration
+
+		lock sync.Mutex
+		// requests are the requested blocks by their index.
+		requests map[uint32]*blockRequest
+		peers    map[Peer]*syncPeer
+		// target is the highest block index reported by peers.
+		target uint32
+	}
+
+	// blockRequest is a block requested from the peer.
+	blockRequest struct {
+		peer Peer
+		// ts is the time the block was requested or received at.
+		ts time.Time
+		// received is set once the block is delivered, the request is
+		// kept until the block is added to the chain.
+		received bool
+	}
+
+	// syncPeer is the synchronization state of the peer.
+	syncPeer struct {
+		inFlight int
+		penalty  int
+	}
+)
+
+// newSyncManager creates a syncManager for the given chain with the given
+// block request timeout.
+func newSyncManager(chain core.Blockchainer, timeout time.Duration) *syncManager {
+	return &syncManager{
+		chain:    chain,
+		timeout:  timeout,
+		requests: make(map[uint32]*blockRequest),
+		peers:    make(map[Peer]*syncPeer),
+	}
+}
+
+// nextBlocks returns the hashes of the blocks that should be requested from
+// the peer and marks them as requested. It returns an error if the peer has
+// been penalized too much.
+func (sm *syncManager) nextBlocks(p Peer) ([]util.Uint256, error) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	height := sm.chain.BlockHeight()
+	now := time.Now()
+	sm.expire(height, now)
+
+	sp := sm.peers[p]
+	if sp == nil {
+		sp = &syncPeer{}
+		sm.peers[p] = sp
+	}
+	if sp.penalty >= maxSyncPenalty {
+		return nil, errSyncPenalty
+	}
+
+	peerHeight := p.LastBlockIndex()
+	if peerHeight > sm.target {
+		sm.target = peerHeight
+		updateSyncTargetHeightMetric(sm.target)
+	}
+	last := sm.chain.HeaderHeight()
+	if peerHeight < last {
+		last = peerHeight
+	}
+	if height+syncWindowSize < last {
+		last = height + syncWindowSize
+	}
+
+	var (
+		limit  = maxBlockBatch>>uint(sp.penalty) - sp.inFlight
+		hashes []util.Uint256
+	)
+	for i := height + 1; i <= last && len(hashes) < limit; i++ {
+		if _, ok := sm.requests[i]; ok {
+			continue
+		}
+		sm.requests[i] = &blockRequest{peer: p, ts: now}
+		hashes = append(hashes, sm.chain.GetHeaderHash(int(i)))
+	}
+	sp.inFlight += len(hashes)
+	sm.updateInFlightMetric()
+	return hashes, nil
+}
+
+// expire drops the requests for the blocks already added to the chain and
+// the ones that have timed out, the peers that haven't delivered the blocks
+// in time are penalized. It must be called with the lock held.
+func (sm *syncManager) expire(height uint32, now time.Time) {
+	stalled := make(map[Peer]bool)
+	for i, r := range sm.requests {
+		if i > height && now.Sub(r.ts) < sm.timeout {
+			continue
+		}
+		if !r.received {
+			if sp := sm.peers[r.peer]; sp != nil {
+				sp.inFlight--
+			}
+			if i > height {
+				stalled[r.peer] = true
+				syncBlockTimeouts.Inc()
+			}
+		}
+		delete(sm.requests, i)
+	}
+	for p := range stalled {
+		if sp := sm.peers[p]; sp != nil {
+			sp.penalty++
+		}
+	}
+}
+
+// blockReceived checks the block received from the peer against the known
+// headers and marks it as delivered. It returns whether the peer has no more
+// blocks in flight or an error if the block is bad.
+func (sm *syncManager) blockReceived(p Peer, b *core.Block) (bool, error) {
+	if b.Index <= sm.chain.HeaderHeight() && !b.Hash().Equals(sm.chain.GetHeaderHash(int(b.Index))) {
+		syncBadBlocks.Inc()
+		return false, fmt.Errorf("block %d doesn't match the header", b.Index)
+	}
+	if err := b.Verify(); err != nil {
+		syncBadBlocks.Inc()
+		return false, fmt.Errorf("bad block %d: %s", b.Index, err)
+	}
+
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	if r := sm.requests[b.Index]; r != nil && !r.received {
+		r.received = true
+		r.ts = time.Now()
+		if sp := sm.peers[r.peer]; sp != nil {
+			sp.inFlight--
+			// Peers delivering all the requested blocks are
+			// gradually forgiven.
+			if sp.inFlight == 0 && sp.penalty > 0 {
+				sp.penalty--
+			}
+		}
+		sm.updateInFlightMetric()
+	}
+	sp := sm.peers[p]
+	return sp != nil && sp.inFlight == 0, nil
+}
+
+// removePeer drops the peer along with its undelivered requests, so that
+// the blocks can be requested from other peers.
+func (sm *syncManager) removePeer(p Peer) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	for i, r := range sm.requests {
+		if r.peer == p && !r.received {
+			delete(sm.requests, i)
+		}
+	}
+	delete(sm.peers, p)
+	sm.updateInFlightMetric()
+}
+
+// updateInFlightMetric updates the number of blocks in flight metric. It
+// must be called with the lock held.
+func (sm *syncManager) updateInFlightMetric() {
+	var n int
+	for _, sp := range sm.peers {
+		n += sp.inFlight
+	}
+	updateSyncBlocksInFlightMetric(n)
+}
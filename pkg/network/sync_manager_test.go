@@ -0,0 +1,226 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/network/payload"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBlock creates a valid block with the given index.
+func newTestBlock(t *testing.T, index uint32) *core.Block {
+	tx := &transaction.Transaction{
+		Type: transaction.MinerType,
+		Data: &transaction.MinerTX{Nonce: index},
+	}
+	tree, err := crypto.NewMerkleTree([]util.Uint256{tx.Hash()})
+	require.NoError(t, err)
+	return &core.Block{
+		BlockBase: core.BlockBase{
+			Index:      index,
+			MerkleRoot: tree.Root(),
+			Script:     &transaction.Witness{},
+		},
+		Transactions: []*transaction.Transaction{tx},
+	}
+}
+
+// newSyncTestChain creates a chain with headers (and blocks) up to the given
+// height.
+func newSyncTestChain(t *testing.T, height uint32) *testChain {
+	chain := &testChain{blocks: make(map[util.Uint256]*core.Block)}
+	for i := uint32(0); i <= height; i++ {
+		b := newTestBlock(t, i)
+		chain.headers = append(chain.headers, b.Hash())
+		chain.blocks[b.Hash()] = b
+	}
+	return chain
+}
+
+// newSyncTestPeer creates a peer with the given height.
+func newSyncTestPeer(t *testing.T, height uint32) *localPeer {
+	p := newLocalPeer(t)
+	p.lastBlockIndex = height
+	return p
+}
+
+func TestSyncManager(t *testing.T) {
+	chain := newSyncTestChain(t, 450)
+	sm := newSyncManager(chain, blockRequestTimeout)
+	p1 := newSyncTestPeer(t, 450)
+	p2 := newSyncTestPeer(t, 100)
+	p3 := newSyncTestPeer(t, 1000)
+
+	t.Run("spread", func(t *testing.T) {
+		hashes, err := sm.nextBlocks(p1)
+		require.NoError(t, err)
+		require.Equal(t, maxBlockBatch, len(hashes))
+		assert.Equal(t, chain.headers[1], hashes[0])
+		assert.Equal(t, chain.headers[maxBlockBatch], hashes[maxBlockBatch-1])
+
+		// Everything p2 has is already requested.
+		hashes, err = sm.nextBlocks(p2)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(hashes))
+
+		// p3 gets the next window limited by the headers.
+		hashes, err = sm.nextBlocks(p3)
+		require.NoError(t, err)
+		require.Equal(t, maxBlockBatch, len(hashes))
+		assert.Equal(t, chain.headers[maxBlockBatch+1], hashes[0])
+		hashes, err = sm.nextBlocks(p3)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(hashes))
+		assert.Equal(t, uint32(1000), sm.target)
+	})
+
+	t.Run("received", func(t *testing.T) {
+		idle, err := sm.blockReceived(p1, chain.blocks[chain.headers[1]])
+		require.NoError(t, err)
+		assert.False(t, idle)
+		assert.Equal(t, maxBlockBatch-1, sm.peers[p1].inFlight)
+		assert.True(t, sm.requests[1].received)
+
+		// Blocks above the header height can't be checked.
+		_, err = sm.blockReceived(p1, newTestBlock(t, 1000))
+		require.NoError(t, err)
+
+		// Doesn't match the header.
+		bad := newTestBlock(t, 2)
+		bad.Timestamp = 1
+		_, err = sm.blockReceived(p1, bad)
+		require.Error(t, err)
+
+		// Invalid.
+		bad = newTestBlock(t, 1001)
+		bad.Transactions = nil
+		_, err = sm.blockReceived(p1, bad)
+		require.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		sm.timeout = 0
+		hashes, err := sm.nextBlocks(p2)
+		require.NoError(t, err)
+		// The received block not added to the chain in time is
+		// requested again too.
+		require.Equal(t, 100, len(hashes))
+		assert.Equal(t, chain.headers[1], hashes[0])
+		assert.Equal(t, 1, sm.peers[p1].penalty)
+		assert.Equal(t, 0, sm.peers[p1].inFlight)
+		assert.Equal(t, 1, sm.peers[p3].penalty)
+		assert.Equal(t, 0, sm.peers[p3].inFlight)
+		sm.timeout = blockRequestTimeout
+
+		// The penalized peer gets fewer blocks.
+		hashes, err = sm.nextBlocks(p1)
+		require.NoError(t, err)
+		require.Equal(t, maxBlockBatch/2, len(hashes))
+		assert.Equal(t, chain.headers[101], hashes[0])
+
+		sm.peers[p1].penalty = maxSyncPenalty
+		_, err = sm.nextBlocks(p1)
+		require.Equal(t, errSyncPenalty, err)
+	})
+
+	t.Run("remove peer", func(t *testing.T) {
+		sm.removePeer(p1)
+		assert.Nil(t, sm.peers[p1])
+		hashes, err := sm.nextBlocks(p3)
+		require.NoError(t, err)
+		require.Equal(t, maxBlockBatch/2, len(hashes))
+		assert.Equal(t, chain.headers[101], hashes[0])
+	})
+
+	t.Run("added blocks", func(t *testing.T) {
+		chain.blockheight = 150
+		sm.expire(chain.BlockHeight(), sm.requests[151].ts)
+		for i := range sm.requests {
+			assert.True(t, i > 150)
+		}
+		assert.Equal(t, 50, sm.peers[p3].inFlight)
+		assert.Equal(t, 0, sm.peers[p2].inFlight)
+	})
+}
+
+func TestHandleBlockCmd(t *testing.T) {
+	s := newTestServer()
+	chain := newSyncTestChain(t, 300)
+	s.chain = chain
+	s.bQueue = newBlockQueue(maxBlockBatch, chain, nil)
+	s.syncMgr = newSyncManager(chain, blockRequestTimeout)
+	p := newSyncTestPeer(t, 300)
+
+	var msgs []*Message
+	p.messageHandler = func(t *testing.T, msg *Message) {
+		msgs = append(msgs, msg)
+	}
+	require.NoError(t, s.requestBlocks(p))
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDGetData, msgs[0].CommandType())
+	assert.Equal(t, chain.headers[1:maxBlockBatch+1], msgs[0].Payload.(*payload.Inventory).Hashes)
+
+	// More blocks are requested once all are delivered.
+	msgs = nil
+	for i := 1; i < maxBlockBatch; i++ {
+		require.NoError(t, s.handleBlockCmd(p, chain.blocks[chain.headers[i]]))
+	}
+	assert.Equal(t, 0, len(msgs))
+	require.NoError(t, s.handleBlockCmd(p, chain.blocks[chain.headers[maxBlockBatch]]))
+	require.Equal(t, 1, len(msgs))
+	assert.Equal(t, CMDGetData, msgs[0].CommandType())
+	assert.Equal(t, chain.headers[maxBlockBatch+1:], msgs[0].Payload.(*payload.Inventory).Hashes)
+
+	bad := newTestBlock(t, 5)
+	bad.Timestamp = 1
+	require.Error(t, s.handleBlockCmd(p, bad))
+}
+
+func TestHandleBlockCmdQueued(t *testing.T) {
+	s := newTestServer()
+	chain := newSyncTestChain(t, 300)
+	s.chain = chain
+	s.bQueue = newBlockQueue(maxBlockBatch, chain, nil)
+	s.syncMgr = newSyncManager(chain, blockRequestTimeout)
+
+	server, client := net.Pipe()
+	p := NewTCPPeer(server)
+	defer p.Disconnect(nil)
+	p.handShake = verAckReceived | verAckSent | versionReceived | versionSent
+	p.lastBlockIndex = 300
+	hashes, err := s.syncMgr.nextBlocks(p)
+	require.NoError(t, err)
+	require.Equal(t, maxBlockBatch, len(hashes))
+
+	// Nothing reads the other side of the pipe yet, so writing to the
+	// connection directly would block the handler.
+	done := make(chan error, 1)
+	go func() {
+		for i := 1; i <= maxBlockBatch; i++ {
+			if err := s.handleBlockCmd(p, chain.blocks[chain.headers[i]]); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleBlockCmd is blocked by the connection")
+	}
+
+	msg := &Message{}
+	require.NoError(t, msg.Decode(io.NewBinReaderFromIO(client)))
+	assert.Equal(t, CMDGetData, msg.CommandType())
+	assert.Equal(t, chain.headers[maxBlockBatch+1:], msg.Payload.(*payload.Inventory).Hashes)
+}
@@ -19,8 +19,15 @@ const (
 	verAckReceived
 )
 
+const (
+	// sendQueueSize is the number of messages that can be queued for
+	// sending to the peer, writers block when the queue is full.
+	sendQueueSize = 64
+)
+
 var (
 	errStateMismatch = errors.New("tried to send protocol message before handshake completed")
+	errPeerClosed    = errors.New("peer disconnected")
 )
 
 // TCPPeer represents a connected remote node in the
@@ -34,17 +41,48 @@ type TCPPeer struct {
 
 	lock      sync.RWMutex
 	handShake handShakeStage
+	// The last known block index of the peer.
+	lastBlockIndex uint32
+
+	// sendQ is the queue of encoded messages written to the connection
+	// by the writer goroutine, so that messages sent concurrently don't
+	// interleave.
+	sendQ chan []byte
+	// closed is closed when the peer is disconnected.
+	closed    chan struct{}
+	closeOnce sync.Once
 
 	done chan error
 
 	wg sync.WaitGroup
 }
 
-// NewTCPPeer returns a TCPPeer structure based on the given connection.
+// NewTCPPeer returns a TCPPeer structure based on the given connection and
+// starts its writer goroutine.
 func NewTCPPeer(conn net.Conn) *TCPPeer {
-	return &TCPPeer{
-		conn: conn,
-		done: make(chan error, 1),
+	p := &TCPPeer{
+		conn:   conn,
+		sendQ:  make(chan []byte, sendQueueSize),
+		closed: make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	go p.writeLoop()
+	return p
+}
+
+// writeLoop writes the queued messages to the connection until the peer is
+// disconnected, write errors disconnect the peer.
+func (p *TCPPeer) writeLoop() {
+	for {
+		select {
+		case <-p.closed:
+			return
+		case data := <-p.sendQ:
+			if _, err := p.conn.Write(data); err != nil {
+				p.Disconnect(err)
+				return
+			}
+		}
 	}
 }
 
@@ -58,13 +96,23 @@ func (p *TCPPeer) WriteMsg(msg *Message) error {
 	return p.writeMsg(msg)
 }
 
+// writeMsg encodes the message and puts it into the send queue, it blocks
+// while the queue is full.
 func (p *TCPPeer) writeMsg(msg *Message) error {
 	select {
-	case err := <-p.done:
-		return err
+	case <-p.closed:
+		return errPeerClosed
 	default:
-		w := io.NewBinWriterFromIO(p.conn)
-		return msg.Encode(w)
+	}
+	w := io.NewBufBinWriter()
+	if err := msg.Encode(w.BinWriter); err != nil {
+		return err
+	}
+	select {
+	case <-p.closed:
+		return errPeerClosed
+	case p.sendQ <- w.Bytes():
+		return nil
 	}
 }
 
@@ -97,6 +145,7 @@ func (p *TCPPeer) HandleVersion(version *payload.Version) error {
 		return errors.New("invalid handshake: already received Version")
 	}
 	p.version = version
+	p.lastBlockIndex = version.StartHeight
 	p.handShake |= versionReceived
 	return nil
 }
@@ -170,8 +219,10 @@ func (p *TCPPeer) Done() chan error {
 	return p.done
 }
 
-// Disconnect will fill the peer's done channel with the given error.
+// Disconnect will fill the peer's done channel with the given error and
+// stop the writer goroutine.
 func (p *TCPPeer) Disconnect(err error) {
+	p.closeOnce.Do(func() { close(p.closed) })
 	p.conn.Close()
 	select {
 	case p.done <- err:
@@ -185,3 +236,17 @@ func (p *TCPPeer) Disconnect(err error) {
 func (p *TCPPeer) Version() *payload.Version {
 	return p.version
 }
+
+// LastBlockIndex implements the Peer interface.
+func (p *TCPPeer) LastBlockIndex() uint32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.lastBlockIndex
+}
+
+// UpdateLastBlockIndex implements the Peer interface.
+func (p *TCPPeer) UpdateLastBlockIndex(index uint32) {
+	p.lock.Lock()
+	p.lastBlockIndex = index
+	p.lock.Unlock()
+}
@@ -2,9 +2,14 @@ package network
 
 import (
 	"net"
+	"sync"
 	"testing"
 
+	"github.com/infinitete/neo-go-inf/config"
+	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/network/payload"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -83,3 +88,35 @@ func TestPeerHandshake(t *testing.T) {
 	require.NoError(t, tcpS.WriteMsg(&Message{}))
 	require.NoError(t, tcpC.WriteMsg(&Message{}))
 }
+
+func TestPeerConcurrentWrites(t *testing.T) {
+	server, client := net.Pipe()
+	p := NewTCPPeer(server)
+	p.handShake = verAckReceived | verAckSent | versionReceived | versionSent
+
+	const writers, msgsPerWriter = 4, 50
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < msgsPerWriter; j++ {
+				inv := payload.NewInventory(payload.BlockType, make([]util.Uint256, 100))
+				assert.NoError(t, p.WriteMsg(NewMessage(config.ModeUnitTestNet, CMDInv, inv)))
+			}
+		}()
+	}
+
+	// Messages written concurrently are received intact.
+	r := io.NewBinReaderFromIO(client)
+	for i := 0; i < writers*msgsPerWriter; i++ {
+		msg := &Message{}
+		require.NoError(t, msg.Decode(r))
+		require.Equal(t, CMDInv, msg.CommandType())
+		require.Equal(t, 100, len(msg.Payload.(*payload.Inventory).Hashes))
+	}
+	wg.Wait()
+
+	p.Disconnect(nil)
+	require.Equal(t, errPeerClosed, p.WriteMsg(NewMessage(config.ModeUnitTestNet, CMDPing, nil)))
+}
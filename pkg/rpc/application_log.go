@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// ApplicationLog represents the results of the script executions for a
+// transaction.
+type ApplicationLog struct {
+	TxHash     util.Uint256 `json:"txid"`
+	Executions []Execution  `json:"executions"`
+}
+
+// Execution represents the result of a single script execution.
+type Execution struct {
+	Trigger     string              `json:"trigger"`
+	ScriptHash  util.Uint160        `json:"contract"`
+	VMState     string              `json:"vmstate"`
+	GasConsumed util.Fixed8         `json:"gas_consumed"`
+	Stack       []StackParam        `json:"stack"`
+	Events      []NotificationState `json:"notifications"`
+}
+
+// NotificationState is a notification emitted during the script execution.
+type NotificationState struct {
+	Contract util.Uint160 `json:"contract"`
+	Item     StackParam   `json:"state"`
+}
+
+// triggerName returns the name of the given script trigger.
+func triggerName(t byte) string {
+	switch t {
+	case 0x00:
+		return "Verification"
+	case 0x01:
+		return "VerificationR"
+	case 0x10:
+		return "Application"
+	case 0x11:
+		return "ApplicationR"
+	default:
+		return "Unknown"
+	}
+}
+
+// NewApplicationLog creates a new ApplicationLog from the given
+// AppExecResult, scriptHash is the hash of the executed script.
+func NewApplicationLog(appExecRes *core.AppExecResult, scriptHash util.Uint160) ApplicationLog {
+	events := make([]NotificationState, 0, len(appExecRes.Events))
+	for _, e := range appExecRes.Events {
+		events = append(events, NotificationState{
+			Contract: e.ScriptHash,
+			Item:     stackParamFromStackItem(e.Item),
+		})
+	}
+
+	stack := make([]StackParam, 0, len(appExecRes.Stack))
+	for _, item := range appExecRes.Stack {
+		stack = append(stack, stackParamFromStackItem(item))
+	}
+
+	return ApplicationLog{
+		TxHash: appExecRes.TxHash,
+		Executions: []Execution{{
+			Trigger:     triggerName(appExecRes.Trigger),
+			ScriptHash:  scriptHash,
+			VMState:     appExecRes.VMState,
+			GasConsumed: appExecRes.GasConsumed,
+			Stack:       stack,
+			Events:      events,
+		}},
+	}
+}
@@ -3,8 +3,12 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -12,12 +16,14 @@ import (
 	"time"
 
 	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/pkg/errors"
 )
 
 var (
 	defaultDialTimeout    = 4 * time.Second
 	defaultRequestTimeout = 4 * time.Second
+	defaultRetryBackoff   = 100 * time.Millisecond
 	defaultClientVersion  = "2.0"
 )
 
@@ -26,26 +32,66 @@ var (
 type Client struct {
 	// The underlying http client. It's never a good practice to use
 	// the http.DefaultClient, therefore we will role our own.
-	cliMu      *sync.Mutex
-	cli        *http.Client
-	endpoint   *url.URL
-	ctx        context.Context
-	version    string
-	wifMu      *sync.Mutex
-	wif        *keys.WIF
-	balancerMu *sync.Mutex
-	balancer   BalanceGetter
+	cliMu          *sync.Mutex
+	cli            *http.Client
+	endpointsMu    *sync.RWMutex
+	endpoints      []*endpoint
+	quorum         int
+	quorumMethods  map[string]bool
+	ctx            context.Context
+	version        string
+	requestTimeout time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	wifMu          *sync.Mutex
+	wif            *keys.WIF
+	balancerMu     *sync.Mutex
+	balancer       BalanceGetter
 }
 
 // ClientOptions defines options for the RPC client.
 // All Values are optional. If any duration is not specified
-// a default of 3 seconds will be used.
+// a default of 4 seconds will be used.
 type ClientOptions struct {
-	Cert        string
-	Key         string
-	CACert      string
+	// Cert and Key are the paths to the PEM-encoded client certificate
+	// and its key used for TLS connections, both should be set to use
+	// the client certificate.
+	Cert string
+	Key  string
+	// CACert is the path to the PEM-encoded root certificates used to
+	// verify the server, system roots are used if it's not set.
+	CACert string
+	// InsecureSkipVerify disables server certificate verification, it
+	// should only be used for testing.
+	InsecureSkipVerify bool
+	// Proxy is the URL of the proxy to use, by default the proxy is
+	// taken from the environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY).
+	Proxy       string
 	DialTimeout time.Duration
-	Client      *http.Client
+	// RequestTimeout limits the time of every request attempt.
+	RequestTimeout time.Duration
+	// MaxRetries is the number of times the request is retried after a
+	// network error, no retries are made by default. Notice that a
+	// request may have reached the server even if it has failed.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, it's doubled
+	// for every subsequent one. The default is 100 milliseconds.
+	RetryBackoff time.Duration
+	// Client is the HTTP client to use, TLS, proxy and dial options are
+	// ignored if it's set.
+	Client *http.Client
+	// HealthCheckInterval is the interval of endpoint health checks made
+	// by the pool client, the default is 10 seconds.
+	HealthCheckInterval time.Duration
+	// Quorum is the number of the pool client endpoints that must return
+	// the same response to QuorumMethods calls, it's not required by
+	// default.
+	Quorum int
+	// QuorumMethods are the methods requiring a quorum, getblock,
+	// getblockhash, getblockheader and getrawtransaction by default.
+	// Height-dependent fields of verbose results (confirmations and
+	// nextblockhash) are ignored when responses are compared.
+	QuorumMethods []string
 	// Version is the version of the client that will be send
 	// along with the request body. If no version is specified
 	// the default version (currently 2.0) will be used.
@@ -54,42 +100,100 @@ type ClientOptions struct {
 
 // NewClient returns a new Client ready to use.
 func NewClient(ctx context.Context, endpoint string, opts ClientOptions) (*Client, error) {
-	url, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, err
+	return newClient(ctx, []string{endpoint}, opts)
+}
+
+func newClient(ctx context.Context, endpoints []string, opts ClientOptions) (*Client, error) {
+	eps := make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return nil, err
+		}
+		eps = append(eps, &endpoint{url: u, healthy: true})
 	}
 
 	if opts.Version == "" {
 		opts.Version = defaultClientVersion
 	}
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
 
 	if opts.Client == nil {
-		opts.Client = &http.Client{
-			Transport: &http.Transport{
-				DialContext: (&net.Dialer{
-					Timeout: opts.DialTimeout,
-				}).DialContext,
-			},
+		transport, err := newTransport(opts)
+		if err != nil {
+			return nil, err
 		}
+		opts.Client = &http.Client{Transport: transport}
 	}
 
-	// TODO(@antdm): Enable SSL.
-	if opts.Cert != "" && opts.Key != "" {
+	c := &Client{
+		ctx:            ctx,
+		cli:            opts.Client,
+		cliMu:          new(sync.Mutex),
+		balancerMu:     new(sync.Mutex),
+		wifMu:          new(sync.Mutex),
+		endpointsMu:    new(sync.RWMutex),
+		endpoints:      eps,
+		version:        opts.Version,
+		requestTimeout: opts.RequestTimeout,
+		maxRetries:     opts.MaxRetries,
+		retryBackoff:   opts.RetryBackoff,
+	}
+	// Unspents are taken from the node itself unless some other
+	// balancer is set.
+	c.balancer = c
+	return c, nil
+}
 
+// newTransport creates an http.Transport with the TLS, proxy and dial
+// options given.
+func newTransport(opts ClientOptions) (*http.Transport, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.Cert != "" || opts.Key != "" {
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if opts.CACert != "" {
+		pem, err := ioutil.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CA certificate")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CACert)
+		}
+		tlsConfig.RootCAs = pool
 	}
 
-	if opts.Client.Timeout == 0 {
-		opts.Client.Timeout = defaultRequestTimeout
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid proxy URL")
+		}
+		proxy = http.ProxyURL(proxyURL)
 	}
 
-	return &Client{
-		ctx:        ctx,
-		cli:        opts.Client,
-		cliMu:      new(sync.Mutex),
-		balancerMu: new(sync.Mutex),
-		wifMu:      new(sync.Mutex),
-		endpoint:   url,
-		version:    opts.Version,
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout: opts.DialTimeout,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: opts.DialTimeout,
 	}, nil
 }
 
@@ -167,27 +271,138 @@ func (c *Client) performRequest(method string, p params, v interface{}) error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", c.endpoint.String(), buf)
+	var (
+		data []byte
+		err  error
+	)
+	if c.quorum > 1 && c.quorumMethods[method] {
+		data, err = c.requestQuorum(buf.Bytes())
+	} else {
+		data, err = c.requestFailover(buf.Bytes())
+	}
 	if err != nil {
 		return err
 	}
-	resp, err := c.Client().Do(req)
+	return json.Unmarshal(data, v)
+}
+
+// requestWithRetries sends the request to the given endpoint retrying it
+// after network errors.
+func (c *Client) requestWithRetries(ep *endpoint, body []byte) ([]byte, error) {
+	backoff := c.retryBackoff
+	for i := 0; ; i++ {
+		data, err := c.doRequest(ep, body)
+		if _, ok := err.(networkError); !ok || i >= c.maxRetries {
+			return data, err
+		}
+		select {
+		case <-c.ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// networkError is an error that occurred while sending the request or
+// receiving the response, such requests can be retried.
+type networkError struct {
+	error
+}
+
+// serverError is returned for 5xx responses, the pool client fails over to
+// other endpoints on it. data is the response body if it's valid JSON.
+type serverError struct {
+	code int
+	data []byte
+}
+
+func (e serverError) Error() string {
+	return fmt.Sprintf("remote responded with a server error: %d", e.code)
+}
+
+// doRequest makes a single attempt to send the request with the given body
+// to the given endpoint and returns the response body.
+func (c *Client) doRequest(ep *endpoint, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", ep.url.String(), bytes.NewReader(body))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	resp, err := c.Client().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, networkError{err}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("remote responded with a non 200 response: %d", resp.StatusCode)
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, networkError{err}
+	}
+	// JSON-RPC errors are returned with non-200 codes, so the status only
+	// matters if the body is not a valid response or it's a server error.
+	if resp.StatusCode >= http.StatusInternalServerError {
+		se := serverError{code: resp.StatusCode}
+		if json.Valid(data) {
+			se.data = data
+		}
+		return nil, se
+	}
+	if !json.Valid(data) {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("remote responded with a non 200 response: %d", resp.StatusCode)
+		}
+		return nil, errors.New("remote responded with invalid JSON")
+	}
+	return data, nil
+}
+
+// performTypedRequest performs the request and decodes its result into v, an
+// error returned by the server is returned as *Error.
+func (c *Client) performTypedRequest(method string, p params, v interface{}) error {
+	resp := new(rawResponse)
+	if err := c.performRequest(method, p, resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
 	}
+	return json.Unmarshal(resp.Result, v)
+}
 
-	return json.NewDecoder(resp.Body).Decode(v)
+// performBinaryRequest performs the request returning a hex-encoded result
+// and decodes it into v.
+func (c *Client) performBinaryRequest(method string, p params, v io.Serializable) error {
+	var res string
+	if err := c.performTypedRequest(method, p, &res); err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(res)
+	if err != nil {
+		return err
+	}
+	r := io.NewBinReaderFromBuf(data)
+	v.DecodeBinary(r)
+	return r.Err
 }
 
-// Ping attempts to create a connection to the endpoint.
-// and returns an error if there is one.
+// Ping attempts to create a connection to the endpoint (any of the
+// endpoints for the pool client) and returns an error if there is one.
 func (c *Client) Ping() error {
-	conn, err := net.DialTimeout("tcp", c.endpoint.Host, defaultDialTimeout)
+	var err error
+	for _, ep := range c.candidates() {
+		if err = ping(ep.url); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// ping attempts to create a connection to the given URL.
+func ping(u *url.URL) error {
+	conn, err := net.DialTimeout("tcp", u.Host, defaultDialTimeout)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockCountHandler replies to any request with the getblockcount result.
+func blockCountHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":42}`))
+}
+
+func TestClientTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(blockCountHandler))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "rpc-client")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(caFile, caPEM, 0600))
+
+	t.Run("unknown CA", func(t *testing.T) {
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{})
+		require.NoError(t, err)
+		_, err = c.GetBlockCount()
+		require.Error(t, err)
+	})
+
+	t.Run("custom CA", func(t *testing.T) {
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{CACert: caFile})
+		require.NoError(t, err)
+		count, err := c.GetBlockCount()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(42), count)
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		_, err = c.GetBlockCount()
+		require.NoError(t, err)
+	})
+
+	t.Run("bad files", func(t *testing.T) {
+		_, err := NewClient(context.Background(), srv.URL, ClientOptions{CACert: filepath.Join(dir, "missing.pem")})
+		require.Error(t, err)
+		garbage := filepath.Join(dir, "garbage.pem")
+		require.NoError(t, ioutil.WriteFile(garbage, []byte("not a certificate"), 0600))
+		_, err = NewClient(context.Background(), srv.URL, ClientOptions{CACert: garbage})
+		require.Error(t, err)
+		_, err = NewClient(context.Background(), srv.URL, ClientOptions{Cert: caFile})
+		require.Error(t, err)
+	})
+}
+
+func TestClientProxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		blockCountHandler(w, r)
+	}))
+	defer proxy.Close()
+
+	c, err := NewClient(context.Background(), "http://node.example:10332", ClientOptions{Proxy: proxy.URL})
+	require.NoError(t, err)
+	count, err := c.GetBlockCount()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(42), count)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+
+	_, err = NewClient(context.Background(), "http://node.example:10332", ClientOptions{Proxy: "://bad"})
+	require.Error(t, err)
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			// Drop the connection without replying.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		blockCountHandler(w, r)
+	}))
+	defer srv.Close()
+
+	t.Run("no retries", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{})
+		require.NoError(t, err)
+		_, err = c.GetBlockCount()
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		})
+		require.NoError(t, err)
+		count, err := c.GetBlockCount()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(42), count)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("server errors are not retried", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		})
+		require.NoError(t, err)
+		_, err = c.GetBlockCount()
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	c, err := NewClient(context.Background(), srv.URL, ClientOptions{RequestTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = c.GetBlockCount()
+	require.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
@@ -7,9 +7,20 @@ Client
 After creating a client instance with or without a ClientConfig
 you can interact with the NEO blockchain by its exposed methods.
 
-Some of the methods also allow to pass a verbose bool. This will
-return a more pretty printed response from the server instead of
-a raw hex string.
+Blocks, headers and transactions are decoded into their core types
+(core.Block, core.Header and transaction.Transaction), the Verbose
+variants of these methods return the wrappers types with additional
+data provided by the server instead. Errors returned by the server
+are returned as *Error.
+
+HTTPS endpoints are supported, ClientOptions allow to specify custom root
+certificates, a client certificate, a proxy, request timeout and the number
+of retries made after network errors.
+
+NewPoolClient creates a client working with several nodes, it sends requests
+to the healthy node with the highest block count and fails over to other
+nodes on errors. It can also require a quorum of nodes to return the same
+response for some methods (like getblock).
 
 An example:
   endpoint := "http://seed5.bridgeprotocol.io:10332"
@@ -33,29 +44,36 @@ An example:
 
 TODO:
 	Merge structs so can be used by both server and client.
-	Add missing methods to client.
-	Allow client to connect using client cert.
 	More in-depth examples.
 
 Supported methods
 
+	findstates
 	getblock
+	getblockheader
 	getaccountstate
+	getapplicationlog
+	getblocksysfee
+	getclaimable
+	getcontractstate
+	getnep5balances
+	getnep5transfers
+	getstorage
+	getunclaimed
+	getunspents
+	getvalidators
 	invokescript
 	invokefunction
 	sendrawtransaction
+	submitblock
 	invoke
 	getrawtransaction
+	getrawmempool
+	gettxout
 
 Unsupported methods
 
 	validateaddress
-	getblocksysfee
-	getcontractstate
-	getrawmempool
-	getstorage
-	submitblock
-	gettxout
 	getassetstate
 	getpeers
 	getversion
@@ -70,7 +88,6 @@ The server is written to support as much of the JSON-RPC 2.0 Spec as possible.
 The server is run as part of the node currently.
 
 TODO:
-	Implement HTTPS server.
 	Add remaining methods (Documented below).
 	Add Swagger spec and test using dredd in circleCI.
 
@@ -62,6 +62,24 @@ func NewInternalServerError(data string, cause error) *Error {
 	return newError(-32603, http.StatusInternalServerError, "Internal error", data, cause)
 }
 
+// NewUnauthorizedError creates a new error with code
+// -32001, it's returned when the request lacks valid credentials.
+func NewUnauthorizedError(data string, cause error) *Error {
+	return newError(-32001, http.StatusUnauthorized, "Unauthorized", data, cause)
+}
+
+// NewAccessDeniedError creates a new error with code
+// -32002, it's returned for disabled methods.
+func NewAccessDeniedError(data string, cause error) *Error {
+	return newError(-32002, http.StatusForbidden, "Access denied", data, cause)
+}
+
+// NewTooManyRequestsError creates a new error with code
+// -32003, it's returned when the client exceeds the rate limit.
+func NewTooManyRequestsError(data string, cause error) *Error {
+	return newError(-32003, http.StatusTooManyRequests, "Too many requests", data, cause)
+}
+
 // Error implements the error interface.
 func (e Error) Error() string {
 	return fmt.Sprintf("%s (%d) - %s - %s", e.Message, e.Code, e.Data, e.Cause)
@@ -54,9 +54,7 @@ func filterSpecificAsset(asset string, balance []*Unspent, assetBalance *Unspent
 func (s NeoScanServer) CalculateInputs(address string, assetIDUint util.Uint256, cost util.Fixed8) ([]transaction.Input, util.Fixed8, error) {
 	var (
 		err          error
-		num, i       uint16
 		required     = cost
-		selected     = util.Fixed8(0)
 		us           []*Unspent
 		assetUnspent Unspent
 		assetID      = GlobalAssets[assetIDUint.ReverseString()]
@@ -65,9 +63,20 @@ func (s NeoScanServer) CalculateInputs(address string, assetIDUint util.Uint256,
 		return nil, util.Fixed8(0), errs.Wrapf(err, "Cannot get balance for address %v", address)
 	}
 	filterSpecificAsset(assetID, us, &assetUnspent)
-	sort.Sort(assetUnspent.Unspent)
+	return unspentsToInputs(assetUnspent.Unspent, required)
+}
+
+// unspentsToInputs selects the smallest unspent outputs from the given ones
+// sufficient to pay the required amount and returns the inputs referencing
+// them along with their total value.
+func unspentsToInputs(utxos Unspents, required util.Fixed8) ([]transaction.Input, util.Fixed8, error) {
+	var (
+		num, i   uint16
+		selected = util.Fixed8(0)
+	)
+	sort.Sort(utxos)
 
-	for _, us := range assetUnspent.Unspent {
+	for _, us := range utxos {
 		if selected >= required {
 			break
 		}
@@ -81,8 +90,8 @@ func (s NeoScanServer) CalculateInputs(address string, assetIDUint util.Uint256,
 	inputs := make([]transaction.Input, 0, num)
 	for i = 0; i < num; i++ {
 		inputs = append(inputs, transaction.Input{
-			PrevHash:  assetUnspent.Unspent[i].TxID,
-			PrevIndex: assetUnspent.Unspent[i].N,
+			PrevHash:  utxos[i].TxID,
+			PrevIndex: utxos[i].N,
 		})
 	}
 
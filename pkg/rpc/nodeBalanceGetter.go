@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	errs "github.com/pkg/errors"
+)
+
+// CalculateInputs implements BalanceGetter interface using the getunspents
+// call of the node the client is connected to. It's the default balancer of
+// the Client.
+func (c *Client) CalculateInputs(address string, assetID util.Uint256, cost util.Fixed8) ([]transaction.Input, util.Fixed8, error) {
+	resp, err := c.GetUnspents(address)
+	if err != nil {
+		return nil, util.Fixed8(0), errs.Wrapf(err, "Cannot get balance for address %v", address)
+	}
+	if resp.Error != nil {
+		return nil, util.Fixed8(0), fmt.Errorf("cannot get balance for address %v: %s", address, resp.Error.Message)
+	}
+
+	var utxos Unspents
+	if resp.Result != nil {
+		for _, balance := range resp.Result.Balance {
+			if balance.AssetHash != assetID {
+				continue
+			}
+			for _, us := range balance.Unspents {
+				utxos = append(utxos, UTXO{
+					Value: us.Value,
+					TxID:  us.Tx,
+					N:     uint16(us.N),
+				})
+			}
+		}
+	}
+	return unspentsToInputs(utxos, cost)
+}
@@ -0,0 +1,250 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultQuorumMethods       = []string{"getblock", "getblockhash", "getblockheader", "getrawtransaction"}
+
+	// heightDependentFields are the fields of verbose results that differ
+	// between nodes with different heights, they're ignored when
+	// responses are compared.
+	heightDependentFields = []string{"confirmations", "nextblockhash"}
+)
+
+// endpoint is a single node used by the Client along with its state.
+type endpoint struct {
+	url     *url.URL
+	healthy bool
+	height  uint32
+}
+
+// NewPoolClient returns a new Client working with several nodes. Requests are
+// sent to the healthy node with the highest block count failing over to other
+// nodes on errors and 5xx responses. Nodes are checked in the background with
+// getblockcount calls until the context is done.
+func NewPoolClient(ctx context.Context, endpoints []string, opts ClientOptions) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints given")
+	}
+	if opts.Quorum > len(endpoints) {
+		return nil, fmt.Errorf("quorum of %d can't be reached with %d endpoints", opts.Quorum, len(endpoints))
+	}
+	if opts.HealthCheckInterval == 0 {
+		opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opts.QuorumMethods == nil {
+		opts.QuorumMethods = defaultQuorumMethods
+	}
+
+	c, err := newClient(ctx, endpoints, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.quorum = opts.Quorum
+	c.quorumMethods = make(map[string]bool, len(opts.QuorumMethods))
+	for _, m := range opts.QuorumMethods {
+		c.quorumMethods[m] = true
+	}
+
+	c.checkEndpoints()
+	go c.healthCheckLoop(opts.HealthCheckInterval)
+	return c, nil
+}
+
+// healthCheckLoop checks the endpoints periodically until the context is done.
+func (c *Client) healthCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.checkEndpoints()
+		}
+	}
+}
+
+// checkEndpoints checks all endpoints concurrently and updates their state.
+func (c *Client) checkEndpoints() {
+	var wg sync.WaitGroup
+	for _, ep := range c.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			height, err := c.checkEndpoint(ep)
+			c.endpointsMu.Lock()
+			ep.healthy = err == nil
+			if err == nil {
+				ep.height = height
+			}
+			c.endpointsMu.Unlock()
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// checkEndpoint requests the block count from the endpoint, any error makes
+// it unhealthy.
+func (c *Client) checkEndpoint(ep *endpoint) (uint32, error) {
+	body, err := json.Marshal(request{
+		JSONRPC: c.version,
+		Method:  "getblockcount",
+		Params:  []interface{}{},
+		ID:      1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	data, err := c.doRequest(ep, body)
+	if err != nil {
+		return 0, err
+	}
+	resp := new(rawResponse)
+	if err := json.Unmarshal(data, resp); err != nil {
+		return 0, err
+	}
+	if resp.Error != nil {
+		return 0, resp.Error
+	}
+	var count uint32
+	err = json.Unmarshal(resp.Result, &count)
+	return count, err
+}
+
+// candidates returns the endpoints in the order they should be tried:
+// healthy ones with the highest block count first, unhealthy ones last.
+func (c *Client) candidates() []*endpoint {
+	c.endpointsMu.RLock()
+	defer c.endpointsMu.RUnlock()
+
+	eps := make([]*endpoint, len(c.endpoints))
+	copy(eps, c.endpoints)
+	sort.SliceStable(eps, func(i, j int) bool {
+		if eps[i].healthy != eps[j].healthy {
+			return eps[i].healthy
+		}
+		return eps[i].height > eps[j].height
+	})
+	return eps
+}
+
+// markUnhealthy marks the endpoint as unhealthy until the next successful
+// health check.
+func (c *Client) markUnhealthy(ep *endpoint) {
+	c.endpointsMu.Lock()
+	ep.healthy = false
+	c.endpointsMu.Unlock()
+}
+
+// requestFailover sends the request to the endpoints one by one until one of
+// them responds. If all of them fail and some returned a JSON-RPC error with
+// a 5xx code, the last such response is returned.
+func (c *Client) requestFailover(body []byte) ([]byte, error) {
+	var (
+		err      error
+		lastResp []byte
+	)
+	for _, ep := range c.candidates() {
+		var data []byte
+		if data, err = c.requestWithRetries(ep, body); err == nil {
+			return data, nil
+		}
+		if se, ok := err.(serverError); ok && se.data != nil {
+			lastResp = se.data
+		}
+		c.markUnhealthy(ep)
+		if c.ctx.Err() != nil {
+			break
+		}
+	}
+	if lastResp != nil {
+		return lastResp, nil
+	}
+	return nil, err
+}
+
+// requestQuorum sends the request to the endpoints until the quorum of them
+// returns the same response.
+func (c *Client) requestQuorum(body []byte) ([]byte, error) {
+	var (
+		eps       = c.candidates()
+		responses [][]byte
+		keys      [][]byte
+		counts    []int
+		lastErr   error
+	)
+	for i, ep := range eps {
+		data, err := c.requestWithRetries(ep, body)
+		if err != nil {
+			c.markUnhealthy(ep)
+			lastErr = err
+		} else {
+			key := quorumKey(data)
+			found := false
+			for j := range responses {
+				if bytes.Equal(keys[j], key) {
+					counts[j]++
+					found = true
+					if counts[j] >= c.quorum {
+						return data, nil
+					}
+				}
+			}
+			if !found {
+				responses = append(responses, data)
+				keys = append(keys, key)
+				counts = append(counts, 1)
+			}
+		}
+		// Check whether the quorum can still be reached.
+		best := 0
+		for _, n := range counts {
+			if n > best {
+				best = n
+			}
+		}
+		if best+len(eps)-i-1 < c.quorum {
+			break
+		}
+	}
+	if lastErr != nil {
+		return nil, errors.Wrap(lastErr, "quorum not reached")
+	}
+	return nil, errors.New("quorum not reached: endpoints returned different responses")
+}
+
+// quorumKey returns the data used to compare responses of different nodes,
+// that's the response with height-dependent fields of the result removed.
+func quorumKey(data []byte) []byte {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return data
+	}
+	var res map[string]json.RawMessage
+	if err := json.Unmarshal(resp["result"], &res); err == nil && res != nil {
+		for _, f := range heightDependentFields {
+			delete(res, f)
+		}
+		if resp["result"], err = json.Marshal(res); err != nil {
+			return data
+		}
+	}
+	key, err := json.Marshal(resp)
+	if err != nil {
+		return data
+	}
+	return key
+}
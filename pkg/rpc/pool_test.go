@@ -0,0 +1,189 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNode creates a server replying to getblockcount with the given count,
+// to getblockhash with the given hash and to getblockheader with a verbose
+// header having this hash and count-dependent confirmations.
+func newTestNode(t *testing.T, count uint32, hash util.Uint256) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(request)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		var result interface{}
+		switch req.Method {
+		case "getblockcount":
+			result = count
+		case "getblockhash":
+			result = hash
+		case "getblockheader":
+			result = map[string]interface{}{"hash": hash, "confirmations": count}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`)
+			return
+		}
+		resp, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+		require.NoError(t, err)
+		_, _ = w.Write(resp)
+	}))
+}
+
+func TestNewPoolClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewPoolClient(ctx, nil, ClientOptions{})
+	require.Error(t, err)
+	_, err = NewPoolClient(ctx, []string{"http://127.0.0.1:1"}, ClientOptions{Quorum: 2})
+	require.Error(t, err)
+}
+
+func TestPoolClientFailover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := []*httptest.Server{
+		newTestNode(t, 10, util.Uint256{1}),
+		newTestNode(t, 20, util.Uint256{2}),
+		newTestNode(t, 15, util.Uint256{3}),
+	}
+	down := newTestNode(t, 30, util.Uint256{4})
+	down.Close()
+	endpoints := []string{down.URL}
+	for _, n := range nodes {
+		defer n.Close()
+		endpoints = append(endpoints, n.URL)
+	}
+
+	c, err := NewPoolClient(ctx, endpoints, ClientOptions{})
+	require.NoError(t, err)
+	require.NoError(t, c.Ping())
+
+	eps := c.candidates()
+	assert.Equal(t, down.URL, eps[len(eps)-1].url.String())
+	assert.False(t, eps[len(eps)-1].healthy)
+
+	// The most up-to-date node is used.
+	hash, err := c.GetBlockHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, util.Uint256{2}, hash)
+
+	// The next one is used when it fails.
+	nodes[1].Close()
+	hash, err = c.GetBlockHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, util.Uint256{3}, hash)
+	for _, ep := range c.candidates() {
+		if ep.url.String() == nodes[1].URL {
+			assert.False(t, ep.healthy)
+		}
+	}
+
+	// JSON-RPC errors are not failed over.
+	_, err = c.GetVersion()
+	require.Error(t, err)
+	_, ok := err.(*Error)
+	require.True(t, ok)
+
+	nodes[0].Close()
+	nodes[2].Close()
+	_, err = c.GetBlockHash(1)
+	require.Error(t, err)
+}
+
+func TestPoolClientQuorum(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes := []*httptest.Server{
+		newTestNode(t, 20, util.Uint256{1}),
+		newTestNode(t, 10, util.Uint256{2}),
+		newTestNode(t, 10, util.Uint256{2}),
+	}
+	endpoints := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		defer n.Close()
+		endpoints = append(endpoints, n.URL)
+	}
+
+	c, err := NewPoolClient(ctx, endpoints, ClientOptions{Quorum: 2})
+	require.NoError(t, err)
+	hash, err := c.GetBlockHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, util.Uint256{2}, hash)
+
+	// Confirmations are ignored when responses are compared.
+	higher := newTestNode(t, 15, util.Uint256{2})
+	defer higher.Close()
+	hc, err := NewPoolClient(ctx, []string{nodes[1].URL, higher.URL}, ClientOptions{Quorum: 2})
+	require.NoError(t, err)
+	header, err := hc.GetBlockHeaderVerbose(util.Uint256{})
+	require.NoError(t, err)
+	assert.Equal(t, util.Uint256{2}, header.Hash)
+
+	// Methods not requiring a quorum are served by the best node.
+	count, err := c.GetBlockCount()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), count)
+
+	c, err = NewPoolClient(ctx, endpoints, ClientOptions{Quorum: 3})
+	require.NoError(t, err)
+	_, err = c.GetBlockHash(1)
+	require.Error(t, err)
+}
+
+func TestPoolClientServerError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failing bool
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"Internal error"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	node := newTestNode(t, 10, util.Uint256{1})
+	defer node.Close()
+
+	// The node accepting connections but failing the block count request
+	// is unhealthy.
+	c, err := NewPoolClient(ctx, []string{broken.URL, node.URL}, ClientOptions{})
+	require.NoError(t, err)
+	eps := c.candidates()
+	assert.Equal(t, node.URL, eps[0].url.String())
+	assert.Equal(t, broken.URL, eps[1].url.String())
+	assert.False(t, eps[1].healthy)
+
+	// 5xx responses are failed over.
+	c, err = NewPoolClient(ctx, []string{broken.URL, node.URL}, ClientOptions{})
+	require.NoError(t, err)
+	c.endpoints[0].healthy = true
+	c.endpoints[0].height = 20
+	hash, err := c.GetBlockHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, util.Uint256{1}, hash)
+
+	// The JSON-RPC error is returned if there is nothing to fail over to.
+	failing = true
+	c, err = NewClient(ctx, broken.URL, ClientOptions{})
+	require.NoError(t, err)
+	_, err = c.GetBlockHash(1)
+	require.Error(t, err)
+	_, ok := err.(*Error)
+	require.True(t, ok)
+}
@@ -36,6 +36,30 @@ var (
 		},
 	)
 
+	getblockheaderCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getblockheader rpc endpoint",
+			Name:      "getblockheader_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getblocksysfeeCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getblocksysfee rpc endpoint",
+			Name:      "getblocksysfee_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getcontractstateCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getcontractstate rpc endpoint",
+			Name:      "getcontractstate_called",
+			Namespace: "neogo",
+		},
+	)
+
 	getconnectioncountCalled = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Help:      "Number of calls to getconnectioncount rpc endpoint",
@@ -92,6 +116,102 @@ var (
 		},
 	)
 
+	getapplicationlogCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getapplicationlog rpc endpoint",
+			Name:      "getapplicationlog_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getclaimableCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getclaimable rpc endpoint",
+			Name:      "getclaimable_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getstorageCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getstorage rpc endpoint",
+			Name:      "getstorage_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getunclaimedCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getunclaimed rpc endpoint",
+			Name:      "getunclaimed_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getunspentsCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getunspents rpc endpoint",
+			Name:      "getunspents_called",
+			Namespace: "neogo",
+		},
+	)
+
+	findstatesCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to findstates rpc endpoint",
+			Name:      "findstates_called",
+			Namespace: "neogo",
+		},
+	)
+
+	gettxoutCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to gettxout rpc endpoint",
+			Name:      "gettxout_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getrawmempoolCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getrawmempool rpc endpoint",
+			Name:      "getrawmempool_called",
+			Namespace: "neogo",
+		},
+	)
+
+	submitblockCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to submitblock rpc endpoint",
+			Name:      "submitblock_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getnep5balancesCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getnep5balances rpc endpoint",
+			Name:      "getnep5balances_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getnep5transfersCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getnep5transfers rpc endpoint",
+			Name:      "getnep5transfers_called",
+			Namespace: "neogo",
+		},
+	)
+
+	getvalidatorsCalled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Help:      "Number of calls to getvalidators rpc endpoint",
+			Name:      "getvalidators_called",
+			Namespace: "neogo",
+		},
+	)
+
 	sendrawtransactionCalled = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Help:      "Number of calls to sendrawtransaction rpc endpoint",
@@ -107,13 +227,28 @@ func init() {
 		getbestblockCalled,
 		getblockcountCalled,
 		getblockHashCalled,
+		getblockheaderCalled,
+		getblocksysfeeCalled,
 		getconnectioncountCalled,
+		getcontractstateCalled,
 		getversionCalled,
 		getpeersCalled,
 		validateaddressCalled,
 		getassetstateCalled,
 		getaccountstateCalled,
 		getrawtransactionCalled,
+		getapplicationlogCalled,
+		getclaimableCalled,
+		getstorageCalled,
+		getunclaimedCalled,
+		getunspentsCalled,
+		findstatesCalled,
+		gettxoutCalled,
+		getrawmempoolCalled,
+		getnep5balancesCalled,
+		getnep5transfersCalled,
+		getvalidatorsCalled,
+		submitblockCalled,
 		sendrawtransactionCalled,
 	)
 }
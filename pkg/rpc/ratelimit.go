@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiterCleanupInterval is the interval of removing idle clients from
+// the rateLimiter.
+const rateLimiterCleanupInterval = time.Minute
+
+type (
+	// rateLimiter is a per-client token bucket rate limiter, every client
+	// can make up to rate requests per second with bursts of the same size.
+	rateLimiter struct {
+		lock        sync.Mutex
+		rate        float64
+		buckets     map[string]*tokenBucket
+		lastCleanup time.Time
+	}
+
+	// tokenBucket is the state of a single client.
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// newRateLimiter creates a rateLimiter allowing the given number of requests
+// per second for every client.
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{
+		rate:        float64(rate),
+		buckets:     make(map[string]*tokenBucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+// allow checks whether the client can make another request now.
+func (l *rateLimiter) allow(client string) bool {
+	return l.allowAt(client, time.Now())
+}
+
+// allowAt checks whether the client can make another request at the given
+// moment and takes a token from its bucket if so.
+func (l *rateLimiter) allowAt(client string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastCleanup) > rateLimiterCleanupInterval {
+		for k, b := range l.buckets {
+			// Idle buckets are full anyway.
+			if now.Sub(b.last).Seconds()*l.rate >= l.rate {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.rate, last: now}
+		l.buckets[client] = b
+	} else if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * l.rate
+		if b.tokens > l.rate {
+			b.tokens = l.rate
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
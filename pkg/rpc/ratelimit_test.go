@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2)
+	now := time.Now()
+
+	assert.True(t, l.allowAt("a", now))
+	assert.True(t, l.allowAt("a", now))
+	assert.False(t, l.allowAt("a", now))
+	// Other clients have their own limits.
+	assert.True(t, l.allowAt("b", now))
+
+	// One token is restored in half a second.
+	assert.False(t, l.allowAt("a", now.Add(100*time.Millisecond)))
+	assert.True(t, l.allowAt("a", now.Add(600*time.Millisecond)))
+	assert.False(t, l.allowAt("a", now.Add(600*time.Millisecond)))
+
+	// Idle clients are removed and get full buckets.
+	later := now.Add(2 * rateLimiterCleanupInterval)
+	assert.True(t, l.allowAt("b", later))
+	assert.Equal(t, 1, len(l.buckets))
+	assert.True(t, l.allowAt("a", later))
+	assert.True(t, l.allowAt("a", later))
+	assert.False(t, l.allowAt("a", later))
+}
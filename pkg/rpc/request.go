@@ -3,6 +3,7 @@ package rpc
 import (
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/pkg/errors"
@@ -30,10 +31,27 @@ type (
 		JSONRPC string          `json:"jsonrpc"`
 		Result  interface{}     `json:"result,omitempty"`
 		Error   *Error          `json:"error,omitempty"`
-		ID      json.RawMessage `json:"id,omitempty"`
+		ID      json.RawMessage `json:"id"`
 	}
 )
 
+// MarshalJSON implements json.Marshaler interface. Successful responses
+// always have a result (null for nil results), error responses never do.
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Error   *Error          `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{r.JSONRPC, r.Error, r.ID})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  interface{}     `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}{r.JSONRPC, r.Result, r.ID})
+}
+
 // NewRequest creates a new Request struct.
 func NewRequest(corsWorkaround bool) *Request {
 	return &Request{
@@ -43,7 +61,7 @@ func NewRequest(corsWorkaround bool) *Request {
 }
 
 // DecodeData decodes the given reader into the the request
-// struct.
+// struct, the request itself is validated when it's handled.
 func (r *Request) DecodeData(data io.ReadCloser) error {
 	defer data.Close()
 
@@ -52,17 +70,26 @@ func (r *Request) DecodeData(data io.ReadCloser) error {
 		return errors.Errorf("error parsing JSON payload: %s", err)
 	}
 
-	if r.JSONRPC != jsonRPCVersion {
-		return errors.Errorf("invalid version, expected 2.0 got: '%s'", r.JSONRPC)
-	}
-
 	return nil
 }
 
+// readRequestBody reads the request body up to the given limit, tooLarge is
+// set (with no error) if the body exceeds it.
+func readRequestBody(body io.Reader, limit int64) (data []byte, tooLarge bool, err error) {
+	data, err = ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		return nil, true, nil
+	}
+	return data, false, err
+}
+
 // Params takes a slice of any type and attempts to bind
 // the params to it.
 func (r *Request) Params() (*Params, error) {
 	params := Params{}
+	if len(r.RawParams) == 0 {
+		return &params, nil
+	}
 
 	err := json.Unmarshal(r.RawParams, &params)
 	if err != nil {
@@ -72,19 +99,24 @@ func (r *Request) Params() (*Params, error) {
 	return &params, nil
 }
 
+// isNotification checks whether the request is a notification (has no ID),
+// notifications are processed, but not answered.
+func (r *Request) isNotification() bool {
+	return len(r.RawID) == 0
+}
+
 // WriteErrorResponse writes an error response to the ResponseWriter.
 func (r Request) WriteErrorResponse(w http.ResponseWriter, err error) {
+	r.writeServerResponse(w, r.errorResponse(err))
+}
+
+// errorResponse creates a Response with the given error and logs it.
+func (r Request) errorResponse(err error) Response {
 	jsonErr, ok := err.(*Error)
 	if !ok {
 		jsonErr = NewInternalServerError("Internal server error", err)
 	}
 
-	response := Response{
-		JSONRPC: r.JSONRPC,
-		Error:   jsonErr,
-		ID:      r.RawID,
-	}
-
 	logFields := log.Fields{
 		"err":    jsonErr.Cause,
 		"method": r.Method,
@@ -93,10 +125,13 @@ func (r Request) WriteErrorResponse(w http.ResponseWriter, err error) {
 	if err == nil {
 		logFields["params"] = *params
 	}
-
 	log.WithFields(logFields).Error("Error encountered with rpc request")
-	w.WriteHeader(jsonErr.HTTPCode)
-	r.writeServerResponse(w, response)
+
+	return Response{
+		JSONRPC: jsonRPCVersion,
+		Error:   jsonErr,
+		ID:      r.RawID,
+	}
 }
 
 // WriteResponse encodes the response and writes it to the ResponseWriter.
@@ -110,12 +145,18 @@ func (r Request) WriteResponse(w http.ResponseWriter, result interface{}) {
 	r.writeServerResponse(w, response)
 }
 
-func (r Request) writeServerResponse(w http.ResponseWriter, response Response) {
+// writeServerResponse writes the given response (or an array of responses
+// for batch requests) to the ResponseWriter, error response to a single
+// request sets the HTTP status code according to the error.
+func (r Request) writeServerResponse(w http.ResponseWriter, response interface{}) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	if r.enableCORSWorkaround {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Access-Control-Allow-Headers, Authorization, X-Requested-With")
 	}
+	if resp, ok := response.(Response); ok && resp.Error != nil {
+		w.WriteHeader(resp.Error.HTTPCode)
+	}
 
 	encoder := json.NewEncoder(w)
 	err := encoder.Encode(response)
@@ -0,0 +1,31 @@
+package result
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// ClaimableInfo is a result of the getclaimable RPC call.
+type ClaimableInfo struct {
+	Spents    []Claimable `json:"claimable"`
+	Address   string      `json:"address"`
+	Unclaimed util.Fixed8 `json:"unclaimed"`
+}
+
+// Claimable represents spent outputs which can be claimed.
+type Claimable struct {
+	Tx          util.Uint256 `json:"txid"`
+	N           int          `json:"n"`
+	Value       util.Fixed8  `json:"value"`
+	StartHeight uint32       `json:"start_height"`
+	EndHeight   uint32       `json:"end_height"`
+	Generated   util.Fixed8  `json:"generated"`
+	SysFee      util.Fixed8  `json:"sys_fee"`
+	Unclaimed   util.Fixed8  `json:"unclaimed"`
+}
+
+// Unclaimed is a result of the getunclaimed RPC call.
+type Unclaimed struct {
+	Available   util.Fixed8 `json:"available"`
+	Unavailable util.Fixed8 `json:"unavailable"`
+	Unclaimed   util.Fixed8 `json:"unclaimed"`
+}
@@ -0,0 +1,13 @@
+package result
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// RawMempool represents the result of the getrawmempool RPC call with
+// unverified transactions requested.
+type RawMempool struct {
+	Height     uint32         `json:"height"`
+	Verified   []util.Uint256 `json:"verified"`
+	Unverified []util.Uint256 `json:"unverified"`
+}
@@ -0,0 +1,38 @@
+package result
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+type (
+	// NEP5Balances is a result for the getnep5balances RPC call.
+	NEP5Balances struct {
+		Balances []NEP5Balance `json:"balance"`
+		Address  string        `json:"address"`
+	}
+
+	// NEP5Balance represents balance for the single token contract.
+	NEP5Balance struct {
+		Asset       string `json:"asset_hash"`
+		Amount      string `json:"amount"`
+		LastUpdated uint32 `json:"last_updated_block"`
+	}
+
+	// NEP5Transfers is a result for the getnep5transfers RPC.
+	NEP5Transfers struct {
+		Sent     []NEP5Transfer `json:"sent"`
+		Received []NEP5Transfer `json:"received"`
+		Address  string         `json:"address"`
+	}
+
+	// NEP5Transfer represents single NEP5 transfer event.
+	NEP5Transfer struct {
+		Timestamp   uint32       `json:"timestamp"`
+		Asset       string       `json:"asset_hash"`
+		Address     string       `json:"transfer_address"`
+		Amount      string       `json:"amount"`
+		Index       uint32       `json:"block_index"`
+		NotifyIndex uint16       `json:"transfer_notify_index"`
+		TxHash      util.Uint256 `json:"tx_hash"`
+	}
+)
@@ -0,0 +1,14 @@
+package result
+
+// FindStates is a result of the findstates RPC call.
+type FindStates struct {
+	Results   []KeyValue `json:"results"`
+	Truncated bool       `json:"truncated"`
+}
+
+// KeyValue represents a single contract storage item, both the key and the
+// value are hex-encoded.
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,27 @@
+package result
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// Unspents is a result of the getunspents RPC call.
+type Unspents struct {
+	Balance []UnspentBalance `json:"balance"`
+	Address string           `json:"address"`
+}
+
+// UnspentBalance represents unspent outputs of a single asset.
+type UnspentBalance struct {
+	Unspents    []Unspent    `json:"unspent"`
+	AssetHash   util.Uint256 `json:"asset_hash"`
+	Asset       string       `json:"asset"`
+	AssetSymbol string       `json:"asset_symbol"`
+	Amount      util.Fixed8  `json:"amount"`
+}
+
+// Unspent represents a single unspent output.
+type Unspent struct {
+	Tx    util.Uint256 `json:"txid"`
+	N     int          `json:"n"`
+	Value util.Fixed8  `json:"value"`
+}
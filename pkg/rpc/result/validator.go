@@ -0,0 +1,13 @@
+package result
+
+import (
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// Validator used for the representation of
+// core.ValidatorState on the RPC Server.
+type Validator struct {
+	PublicKey string      `json:"publickey"`
+	Votes     util.Fixed8 `json:"votes"`
+	Active    bool        `json:"active"`
+}
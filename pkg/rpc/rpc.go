@@ -2,30 +2,207 @@ package rpc
 
 import (
 	"encoding/hex"
+	"encoding/json"
 
+	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
 	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/result"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/wrappers"
 	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/pkg/errors"
 )
 
-// getBlock returns a block by its hash or index/height. If verbose is true
-// the response will contain a pretty Block object instead of the raw hex string.
-// missing output wrapper at the moment, thus commented out
-// func (c *Client) getBlock(indexOrHash interface{}, verbose bool) (*response, error) {
-// 	var (
-// 		params = newParams(indexOrHash)
-// 		resp   = &response{}
-// 	)
-// 	if verbose {
-// 		params = newParams(indexOrHash, 1)
-// 	}
-// 	if err := c.performRequest("getblock", params, resp); err != nil {
-// 		return nil, err
-// 	}
-// 	return resp, nil
-// }
+// GetBestBlockHash returns the hash of the tallest block in the main chain.
+func (c *Client) GetBestBlockHash() (util.Uint256, error) {
+	var hash util.Uint256
+	if err := c.performTypedRequest("getbestblockhash", newParams(), &hash); err != nil {
+		return util.Uint256{}, err
+	}
+	return hash, nil
+}
+
+// GetBlockCount returns the number of blocks in the main chain.
+func (c *Client) GetBlockCount() (uint32, error) {
+	var count uint32
+	if err := c.performTypedRequest("getblockcount", newParams(), &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetBlockHash returns the hash of the block with the given index.
+func (c *Client) GetBlockHash(index uint32) (util.Uint256, error) {
+	var hash util.Uint256
+	if err := c.performTypedRequest("getblockhash", newParams(index), &hash); err != nil {
+		return util.Uint256{}, err
+	}
+	return hash, nil
+}
+
+// GetBlockByIndex returns the block with the given index.
+func (c *Client) GetBlockByIndex(index uint32) (*core.Block, error) {
+	return c.getBlock(newParams(index))
+}
+
+// GetBlockByHash returns the block with the given hash.
+func (c *Client) GetBlockByHash(hash util.Uint256) (*core.Block, error) {
+	return c.getBlock(newParams(hash.ReverseString()))
+}
+
+func (c *Client) getBlock(params params) (*core.Block, error) {
+	block := new(core.Block)
+	if err := c.performBinaryRequest("getblock", params, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockByIndexVerbose returns the block with the given index along with
+// the additional data (like confirmations and transaction fees) provided by
+// the server.
+func (c *Client) GetBlockByIndexVerbose(index uint32) (*wrappers.Block, error) {
+	return c.getBlockVerbose(newParams(index, 1))
+}
+
+// GetBlockByHashVerbose returns the block with the given hash along with
+// the additional data (like confirmations and transaction fees) provided by
+// the server.
+func (c *Client) GetBlockByHashVerbose(hash util.Uint256) (*wrappers.Block, error) {
+	return c.getBlockVerbose(newParams(hash.ReverseString(), 1))
+}
+
+func (c *Client) getBlockVerbose(params params) (*wrappers.Block, error) {
+	block := new(wrappers.Block)
+	if err := c.performTypedRequest("getblock", params, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockHeader returns the header of the block with the given hash.
+func (c *Client) GetBlockHeader(hash util.Uint256) (*core.Header, error) {
+	header := new(core.Header)
+	if err := c.performBinaryRequest("getblockheader", newParams(hash.ReverseString()), header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// GetBlockHeaderVerbose returns the header of the block with the given hash
+// along with the additional data provided by the server.
+func (c *Client) GetBlockHeaderVerbose(hash util.Uint256) (*wrappers.Header, error) {
+	header := new(wrappers.Header)
+	if err := c.performTypedRequest("getblockheader", newParams(hash.ReverseString(), 1), header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// GetConnectionCount returns the number of peers the node is connected to.
+func (c *Client) GetConnectionCount() (int, error) {
+	var count int
+	if err := c.performTypedRequest("getconnectioncount", newParams(), &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetVersion returns the version information of the node.
+func (c *Client) GetVersion() (*result.Version, error) {
+	version := new(result.Version)
+	if err := c.performTypedRequest("getversion", newParams(), version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// GetPeers returns the lists of connected, unconnected and bad peers of the
+// node.
+func (c *Client) GetPeers() (*result.Peers, error) {
+	peers := new(result.Peers)
+	if err := c.performTypedRequest("getpeers", newParams(), peers); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// ValidateAddress checks whether the given string is a valid NEO address.
+func (c *Client) ValidateAddress(address string) (bool, error) {
+	resp := new(wrappers.ValidateAddressResponse)
+	if err := c.performTypedRequest("validateaddress", newParams(address), resp); err != nil {
+		return false, err
+	}
+	return resp.IsValid, nil
+}
+
+// GetAssetState returns the state of the asset with the given id.
+func (c *Client) GetAssetState(id util.Uint256) (*wrappers.AssetState, error) {
+	var raw json.RawMessage
+	if err := c.performTypedRequest("getassetstate", newParams(id.ReverseString()), &raw); err != nil {
+		return nil, err
+	}
+	// Unknown assets are reported with a string result.
+	var msg string
+	if json.Unmarshal(raw, &msg) == nil {
+		return nil, errors.New(msg)
+	}
+	asset := new(wrappers.AssetState)
+	if err := json.Unmarshal(raw, asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// GetRawMempool returns the hashes of the verified transactions in the
+// memory pool of the node.
+func (c *Client) GetRawMempool() ([]util.Uint256, error) {
+	var hashes []util.Uint256
+	if err := c.performTypedRequest("getrawmempool", newParams(), &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// GetRawMempoolVerbose returns the hashes of both verified and unverified
+// transactions in the memory pool of the node along with the current height.
+func (c *Client) GetRawMempoolVerbose() (*result.RawMempool, error) {
+	mp := new(result.RawMempool)
+	if err := c.performTypedRequest("getrawmempool", newParams(1), mp); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// GetTxOut returns the unspent output of the given transaction with the
+// given index, nil is returned if it's spent or doesn't exist.
+func (c *Client) GetTxOut(hash util.Uint256, index uint16) (*transaction.Output, error) {
+	var out *transaction.Output
+	if err := c.performTypedRequest("gettxout", newParams(hash.ReverseString(), index), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetNEP5Balances returns the NEP-5 balances of the given address.
+func (c *Client) GetNEP5Balances(address string) (*result.NEP5Balances, error) {
+	balances := new(result.NEP5Balances)
+	if err := c.performTypedRequest("getnep5balances", newParams(address), balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// GetNEP5Transfers returns the NEP-5 transfers of the given address made
+// between the given start and end timestamps (inclusive).
+func (c *Client) GetNEP5Transfers(address string, start, end uint32) (*result.NEP5Transfers, error) {
+	transfers := new(result.NEP5Transfers)
+	if err := c.performTypedRequest("getnep5transfers", newParams(address, start, end), transfers); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
 
 // GetAccountState returns detailed information about a NEO account.
 func (c *Client) GetAccountState(address string) (*AccountStateResponse, error) {
@@ -39,6 +216,126 @@ func (c *Client) GetAccountState(address string) (*AccountStateResponse, error)
 	return resp, nil
 }
 
+// GetApplicationLog returns the contract log based on the specified txid.
+func (c *Client) GetApplicationLog(hash string) (*GetApplicationLogResponse, error) {
+	var (
+		params = newParams(hash)
+		resp   = &GetApplicationLogResponse{}
+	)
+	if err := c.performRequest("getapplicationlog", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetBlockSysFee returns the total amount of system fees paid by all blocks
+// up to and including the block with the given index.
+func (c *Client) GetBlockSysFee(index uint32) (*GetBlockSysFeeResponse, error) {
+	var (
+		params = newParams(index)
+		resp   = &GetBlockSysFeeResponse{}
+	)
+	if err := c.performRequest("getblocksysfee", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetContractState returns the state of the contract with the given script
+// hash (in BE representation).
+func (c *Client) GetContractState(hash string) (*GetContractStateResponse, error) {
+	var (
+		params = newParams(hash)
+		resp   = &GetContractStateResponse{}
+	)
+	if err := c.performRequest("getcontractstate", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStorage returns the hex-encoded value stored by the contract with the
+// given script hash with the given hex-encoded key.
+func (c *Client) GetStorage(hash, key string) (*GetStorageResponse, error) {
+	var (
+		params = newParams(hash, key)
+		resp   = &GetStorageResponse{}
+	)
+	if err := c.performRequest("getstorage", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FindStates returns the storage items of the contract with the given script
+// hash with keys starting with the given hex-encoded prefix. Items following
+// the start key (the last key of the previous page, may be empty) are
+// returned, count limits the number of items if it's positive.
+func (c *Client) FindStates(hash, prefix, start string, count int) (*FindStatesResponse, error) {
+	var (
+		params = newParams(hash, prefix, start)
+		resp   = &FindStatesResponse{}
+	)
+	if count > 0 {
+		params = newParams(hash, prefix, start, count)
+	}
+	if err := c.performRequest("findstates", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetClaimable returns spent and not yet claimed NEO outputs of the given
+// address along with the amount of GAS that can be claimed for them.
+func (c *Client) GetClaimable(address string) (*GetClaimableResponse, error) {
+	var (
+		params = newParams(address)
+		resp   = &GetClaimableResponse{}
+	)
+	if err := c.performRequest("getclaimable", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUnclaimed returns the amount of GAS available and not yet available for
+// claim by the given address.
+func (c *Client) GetUnclaimed(address string) (*GetUnclaimedResponse, error) {
+	var (
+		params = newParams(address)
+		resp   = &GetUnclaimedResponse{}
+	)
+	if err := c.performRequest("getunclaimed", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUnspents returns unspent outputs of the given address grouped by asset.
+func (c *Client) GetUnspents(address string) (*GetUnspentsResponse, error) {
+	var (
+		params = newParams(address)
+		resp   = &GetUnspentsResponse{}
+	)
+	if err := c.performRequest("getunspents", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetValidators returns the current NEO consensus nodes information and
+// voting status.
+func (c *Client) GetValidators() (*GetValidatorsResponse, error) {
+	var (
+		params = newParams()
+		resp   = &GetValidatorsResponse{}
+	)
+	if err := c.performRequest("getvalidators", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // InvokeScript returns the result of the given script after running it true the VM.
 // NOTE: This is a test invoke and will not affect the blockchain.
 func (c *Client) InvokeScript(script string) (*InvokeScriptResponse, error) {
@@ -57,7 +354,7 @@ func (c *Client) InvokeScript(script string) (*InvokeScriptResponse, error) {
 // NOTE: this is test invoke and will not affect the blockchain.
 func (c *Client) InvokeFunction(script, operation string, params []smartcontract.Parameter) (*InvokeScriptResponse, error) {
 	var (
-		p    = newParams(script, operation, params)
+		p    = newParams(script, operation, stackParamsFromParameters(params))
 		resp = &InvokeScriptResponse{}
 	)
 	if err := c.performRequest("invokefunction", p, resp); err != nil {
@@ -70,7 +367,7 @@ func (c *Client) InvokeFunction(script, operation string, params []smartcontract
 // with the given parameters.
 func (c *Client) Invoke(script string, params []smartcontract.Parameter) (*InvokeScriptResponse, error) {
 	var (
-		p    = newParams(script, params)
+		p    = newParams(script, stackParamsFromParameters(params))
 		resp = &InvokeScriptResponse{}
 	)
 	if err := c.performRequest("invoke", p, resp); err != nil {
@@ -79,18 +376,42 @@ func (c *Client) Invoke(script string, params []smartcontract.Parameter) (*Invok
 	return resp, nil
 }
 
-// getRawTransaction queries a transaction by hash.
-// missing output wrapper at the moment, thus commented out
-// func (c *Client) getRawTransaction(hash string, verbose bool) (*response, error) {
-// 	var (
-// 		params = newParams(hash, verbose)
-// 		resp   = &response{}
-// 	)
-// 	if err := c.performRequest("getrawtransaction", params, resp); err != nil {
-// 		return nil, err
-// 	}
-// 	return resp, nil
-// }
+// GetRawTransaction returns the transaction with the given hash.
+func (c *Client) GetRawTransaction(hash util.Uint256) (*transaction.Transaction, error) {
+	tx := new(transaction.Transaction)
+	if err := c.performBinaryRequest("getrawtransaction", newParams(hash.ReverseString()), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetRawTransactionVerbose returns the transaction with the given hash along
+// with the block metadata and fees provided by the server.
+func (c *Client) GetRawTransactionVerbose(hash util.Uint256) (*wrappers.TransactionOutputRaw, error) {
+	tx := new(wrappers.TransactionOutputRaw)
+	if err := c.performTypedRequest("getrawtransaction", newParams(hash.ReverseString(), 1), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SubmitBlock broadcasts the given block over the NEO network.
+func (c *Client) SubmitBlock(b *core.Block) error {
+	buf := io.NewBufBinWriter()
+	b.EncodeBinary(buf.BinWriter)
+	if buf.Err != nil {
+		return buf.Err
+	}
+	var res bool
+	return c.performTypedRequest("submitblock", newParams(hex.EncodeToString(buf.Bytes())), &res)
+}
+
+// SendRawTransaction broadcasts the given signed transaction over the NEO
+// network.
+func (c *Client) SendRawTransaction(tx *transaction.Transaction) error {
+	var res bool
+	return c.performTypedRequest("sendrawtransaction", newParams(hex.EncodeToString(tx.Bytes())), &res)
+}
 
 // sendRawTransaction broadcasts a transaction over the NEO network.
 // The given hex string needs to be signed with a keypair.
@@ -141,8 +462,31 @@ func (c *Client) SendToAddress(asset util.Uint256, address string, amount util.F
 	response.Error = resp.Error
 	response.ID = resp.ID
 	response.JSONRPC = resp.JSONRPC
-	response.Result = &TxResponse{
-		TxID: rawTx.Hash().ReverseString(),
-	}
+	response.Result = newTxResponse(rawTx)
 	return response, nil
 }
+
+// newTxResponse fills TxResponse with the data of the given transaction.
+func newTxResponse(tx *transaction.Transaction) *TxResponse {
+	res := &TxResponse{
+		TxID:    tx.Hash().ReverseString(),
+		Size:    io.GetVarSize(tx),
+		Type:    tx.Type.String(),
+		Version: int(tx.Version),
+	}
+	for _, a := range tx.Attributes {
+		res.Attributes = append(res.Attributes, *a)
+	}
+	for _, in := range tx.Inputs {
+		res.Vins = append(res.Vins, *in)
+	}
+	for i, out := range tx.Outputs {
+		vout := *out
+		vout.Position = i
+		res.Vouts = append(res.Vouts, vout)
+	}
+	for _, w := range tx.Scripts {
+		res.Scripts = append(res.Scripts, *w)
+	}
+	return res
+}
@@ -1,21 +1,33 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/infinitete/neo-go-inf/config"
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
 	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/network"
 	"github.com/infinitete/neo-go-inf/pkg/rpc/result"
 	"github.com/infinitete/neo-go-inf/pkg/rpc/wrappers"
 	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -27,9 +39,48 @@ type (
 		chain      core.Blockchainer
 		config     config.RPCConfig
 		coreServer *network.Server
+		upgrader   websocket.Upgrader
+		shutdown   chan struct{}
+
+		// WebSocket clients, subsLock protects the set and subscriptions
+		// of the clients.
+		subsLock    *sync.RWMutex
+		subscribers map[*subscriber]bool
+
+		// Chain event feeds, subsCounterLock protects the numbers of
+		// subscriptions for them.
+		subsCounterLock  *sync.Mutex
+		blockSubs        int
+		txSubs           int
+		executionSubs    int
+		subEventsStopped bool
+		blockCh          chan *core.Block
+		txCh             chan *transaction.Transaction
+		executionCh      chan *core.AppExecResult
+
+		// Access control.
+		enabledMethods  map[string]bool
+		disabledMethods map[string]bool
+		limiter         *rateLimiter
 	}
 )
 
+// maxFindStatesCount is the maximum number of storage items returned by a
+// single findstates call.
+const maxFindStatesCount = 50
+
+// defaultMaxBatchConcurrency is the number of batch request elements
+// processed concurrently if it's not configured.
+const defaultMaxBatchConcurrency = 8
+
+// defaultMaxRequestBodyBytes is the maximum size of the request body if it's
+// not configured, it's enough for any valid block to be submitted.
+const defaultMaxRequestBodyBytes = 8 * 1024 * 1024
+
+// nep5TransfersDefaultPeriod is the time range (in seconds) getnep5transfers
+// returns transfers for if no start time is specified.
+const nep5TransfersDefaultPeriod = 7 * 24 * 60 * 60
+
 var (
 	invalidBlockHeightError = func(index int, height int) error {
 		return errors.Errorf("Param at index %d should be greater than or equal to 0 and less then or equal to current block height, got: %d", index, height)
@@ -42,12 +93,45 @@ func NewServer(chain core.Blockchainer, conf config.RPCConfig, coreServer *netwo
 		Addr: conf.Address + ":" + strconv.FormatUint(uint64(conf.Port), 10),
 	}
 
+	upgrader := websocket.Upgrader{}
+	if conf.EnableCORSWorkaround {
+		upgrader.CheckOrigin = func(*http.Request) bool { return true }
+	}
+
+	var limiter *rateLimiter
+	if conf.RateLimit > 0 {
+		limiter = newRateLimiter(conf.RateLimit)
+	}
+
 	return Server{
 		Server:     httpServer,
 		chain:      chain,
 		config:     conf,
 		coreServer: coreServer,
+		upgrader:   upgrader,
+		shutdown:   make(chan struct{}),
+
+		subsLock:    new(sync.RWMutex),
+		subscribers: make(map[*subscriber]bool),
+
+		subsCounterLock: new(sync.Mutex),
+		blockCh:         make(chan *core.Block),
+		txCh:            make(chan *transaction.Transaction),
+		executionCh:     make(chan *core.AppExecResult),
+
+		enabledMethods:  methodsSet(conf.EnabledMethods),
+		disabledMethods: methodsSet(conf.DisabledMethods),
+		limiter:         limiter,
+	}
+}
+
+// methodsSet converts the given list of methods into a set.
+func methodsSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m] = true
 	}
+	return set
 }
 
 // Start creates a new JSON-RPC server
@@ -58,10 +142,16 @@ func (s *Server) Start(errChan chan error) {
 		return
 	}
 	s.Handler = http.HandlerFunc(s.requestHandler)
+	go s.handleSubEvents()
 	log.WithFields(log.Fields{
 		"endpoint": s.Addr,
+		"tls":      s.config.TLSConfig.Enabled,
 	}).Info("starting rpc-server")
 
+	if s.config.TLSConfig.Enabled {
+		errChan <- s.ListenAndServeTLS(s.config.TLSConfig.CertFile, s.config.TLSConfig.KeyFile)
+		return
+	}
 	errChan <- s.ListenAndServe()
 }
 
@@ -71,10 +161,24 @@ func (s *Server) Shutdown() error {
 	log.WithFields(log.Fields{
 		"endpoint": s.Addr,
 	}).Info("shutting down rpc-server")
+	close(s.shutdown)
 	return s.Server.Shutdown(context.Background())
 }
 
 func (s *Server) requestHandler(w http.ResponseWriter, httpRequest *http.Request) {
+	if err := s.checkAccess(httpRequest); err != nil {
+		if err.HTTPCode == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Basic realm="neo-go"`)
+		}
+		NewRequest(s.config.EnableCORSWorkaround).WriteErrorResponse(w, err)
+		return
+	}
+
+	if httpRequest.URL.Path == wsPath && httpRequest.Method == "GET" {
+		s.handleWsRequest(w, httpRequest)
+		return
+	}
+
 	req := NewRequest(s.config.EnableCORSWorkaround)
 
 	if httpRequest.Method != "POST" {
@@ -87,22 +191,201 @@ func (s *Server) requestHandler(w http.ResponseWriter, httpRequest *http.Request
 		return
 	}
 
-	err := req.DecodeData(httpRequest.Body)
+	body, tooLarge, err := readRequestBody(httpRequest.Body, s.maxRequestBodyBytes())
+	httpRequest.Body.Close()
+	if tooLarge {
+		req.WriteErrorResponse(w, newError(-32600, http.StatusRequestEntityTooLarge, "Invalid Request", "JSON-RPC request body is too large", nil))
+		return
+	}
+	if err != nil {
+		req.WriteErrorResponse(w, newError(-32600, http.StatusBadRequest, "Invalid Request", "Problem reading JSON-RPC request body", err))
+		return
+	}
+	if isBatchRequest(body) {
+		s.batchHandler(w, req, body, clientAddress(httpRequest))
+		return
+	}
+
+	err = req.DecodeData(ioutil.NopCloser(bytes.NewReader(body)))
 	if err != nil {
 		req.WriteErrorResponse(w, NewParseError("Problem parsing JSON-RPC request body", err))
 		return
 	}
+	resp := s.handleRequest(req, nil)
+	if req.isNotification() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	req.writeServerResponse(w, resp)
+}
 
-	reqParams, err := req.Params()
+// checkAccess checks whether the client is allowed to make requests, it
+// applies the rate limit and checks basic auth credentials if they're
+// configured.
+func (s *Server) checkAccess(httpRequest *http.Request) *Error {
+	if s.limiter != nil {
+		if !s.limiter.allow(clientAddress(httpRequest)) {
+			return NewTooManyRequestsError("Rate limit exceeded", nil)
+		}
+	}
+	if auth := s.config.BasicAuth; auth.User != "" {
+		user, password, ok := httpRequest.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(auth.User)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(auth.Password)) != 1 {
+			return NewUnauthorizedError("Invalid credentials", nil)
+		}
+	}
+	return nil
+}
+
+// clientAddress returns the IP address of the client that made the request.
+func clientAddress(httpRequest *http.Request) string {
+	host, _, err := net.SplitHostPort(httpRequest.RemoteAddr)
 	if err != nil {
-		req.WriteErrorResponse(w, NewInvalidParamsError("Problem parsing request parameters", err))
+		return httpRequest.RemoteAddr
+	}
+	return host
+}
+
+// maxRequestBodyBytes returns the maximum allowed size of the request body.
+func (s *Server) maxRequestBodyBytes() int64 {
+	if s.config.MaxRequestBodyBytes > 0 {
+		return s.config.MaxRequestBodyBytes
+	}
+	return defaultMaxRequestBodyBytes
+}
+
+// methodAllowed checks the method against configured lists of enabled and
+// disabled methods.
+func (s *Server) methodAllowed(method string) bool {
+	if len(s.enabledMethods) != 0 && !s.enabledMethods[method] {
+		return false
+	}
+	return !s.disabledMethods[method]
+}
+
+// batchHandler processes JSON-RPC batch request concurrently (up to the
+// configured limit) and writes an array of responses to non-notification
+// requests.
+func (s *Server) batchHandler(w http.ResponseWriter, req *Request, body []byte, client string) {
+	var rawReqs []json.RawMessage
+	if err := json.Unmarshal(body, &rawReqs); err != nil {
+		req.WriteErrorResponse(w, NewParseError("Problem parsing JSON-RPC batch request", err))
 		return
 	}
+	if len(rawReqs) == 0 {
+		req.WriteErrorResponse(w, NewInvalidRequestError("Empty batch request", nil))
+		return
+	}
+
+	var (
+		responses = make([]*Response, len(rawReqs))
+		workers   = make(chan struct{}, s.maxBatchConcurrency())
+		wg        sync.WaitGroup
+	)
+	for i := range rawReqs {
+		// Every element is a request of its own, the first one is
+		// already paid for by checkAccess.
+		limited := i > 0 && s.limiter != nil && !s.limiter.allow(client)
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			r := NewRequest(s.config.EnableCORSWorkaround)
+			if err := json.Unmarshal(rawReqs[i], r); err != nil {
+				resp := r.errorResponse(NewInvalidRequestError("Invalid batch element", err))
+				resp.ID = nil
+				responses[i] = &resp
+				return
+			}
+			var resp Response
+			if limited {
+				resp = r.errorResponse(NewTooManyRequestsError("Rate limit exceeded", nil))
+			} else {
+				resp = s.handleRequest(r, nil)
+			}
+			if !r.isNotification() {
+				responses[i] = &resp
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	results := make([]Response, 0, len(responses))
+	for _, resp := range responses {
+		if resp != nil {
+			results = append(results, *resp)
+		}
+	}
+	if len(results) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	req.writeServerResponse(w, results)
+}
+
+// maxBatchConcurrency returns the number of batch request elements that can
+// be processed concurrently.
+func (s *Server) maxBatchConcurrency() int {
+	if s.config.MaxBatchConcurrency > 0 {
+		return s.config.MaxBatchConcurrency
+	}
+	return defaultMaxBatchConcurrency
+}
+
+// isBatchRequest checks whether the given request body is a JSON array.
+func isBatchRequest(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleRequest validates and executes a single request returning the
+// response to it, it's shared between HTTP and WebSocket transports.
+// Subscription methods are only available to WebSocket subscribers.
+func (s *Server) handleRequest(req *Request, sub *subscriber) Response {
+	if req.JSONRPC != jsonRPCVersion {
+		return req.errorResponse(NewInvalidRequestError(fmt.Sprintf("Invalid version, expected 2.0 got: '%s'", req.JSONRPC), nil))
+	}
+	if req.Method == "" {
+		return req.errorResponse(NewInvalidRequestError("Method is not specified", nil))
+	}
+	if !s.methodAllowed(req.Method) {
+		return req.errorResponse(NewAccessDeniedError(fmt.Sprintf("Method '%s' is disabled", req.Method), nil))
+	}
+	reqParams, err := req.Params()
+	if err != nil {
+		return req.errorResponse(NewInvalidParamsError("Problem parsing request parameters", err))
+	}
 
-	s.methodHandler(w, req, *reqParams)
+	var (
+		results    interface{}
+		resultsErr error
+	)
+	switch {
+	case sub != nil && req.Method == "subscribe":
+		results, resultsErr = s.subscribe(*reqParams, sub)
+	case sub != nil && req.Method == "unsubscribe":
+		results, resultsErr = s.unsubscribe(*reqParams, sub)
+	default:
+		results, resultsErr = s.handleMethod(req, *reqParams)
+	}
+	if resultsErr != nil {
+		return req.errorResponse(resultsErr)
+	}
+	return Response{
+		JSONRPC: req.JSONRPC,
+		Result:  results,
+		ID:      req.RawID,
+	}
 }
 
-func (s *Server) methodHandler(w http.ResponseWriter, req *Request, reqParams Params) {
+// handleMethod executes the method of the given request and returns its
+// results or an error.
+func (s *Server) handleMethod(req *Request, reqParams Params) (interface{}, error) {
 	log.WithFields(log.Fields{
 		"method": req.Method,
 		"params": fmt.Sprintf("%v", reqParams),
@@ -121,40 +404,8 @@ Methods:
 
 	case "getblock":
 		getbestblockCalled.Inc()
-		var hash util.Uint256
-
-		param, err := reqParams.Value(0)
-		if err != nil {
-			resultsErr = err
-			break Methods
-		}
-
-		switch param.Type {
-		case "string":
-			hash, err = util.Uint256DecodeReverseString(param.StringVal)
-			if err != nil {
-				resultsErr = errInvalidParams
-				break Methods
-			}
-		case "number":
-			if !s.validBlockHeight(param) {
-				resultsErr = errInvalidParams
-				break Methods
-			}
+		results, resultsErr = s.getBlock(reqParams)
 
-			hash = s.chain.GetHeaderHash(param.IntVal)
-		case "default":
-			resultsErr = errInvalidParams
-			break Methods
-		}
-
-		block, err := s.chain.GetBlock(hash)
-		if err != nil {
-			resultsErr = NewInternalServerError(fmt.Sprintf("Problem locating block with hash: %s", hash), err)
-			break
-		}
-
-		results = wrappers.NewBlock(block, s.chain)
 	case "getblockcount":
 		getblockcountCalled.Inc()
 		results = s.chain.BlockHeight() + 1
@@ -172,6 +423,14 @@ Methods:
 
 		results = s.chain.GetHeaderHash(param.IntVal)
 
+	case "getblockheader":
+		getblockheaderCalled.Inc()
+		results, resultsErr = s.getBlockHeader(reqParams)
+
+	case "getblocksysfee":
+		getblocksysfeeCalled.Inc()
+		results, resultsErr = s.getBlockSysFee(reqParams)
+
 	case "getconnectioncount":
 		getconnectioncountCalled.Inc()
 		results = s.coreServer.PeerCount()
@@ -243,13 +502,71 @@ Methods:
 		} else {
 			results = "Invalid public account address"
 		}
+	case "getapplicationlog":
+		getapplicationlogCalled.Inc()
+		results, resultsErr = s.getApplicationLog(reqParams)
+
+	case "getclaimable":
+		getclaimableCalled.Inc()
+		results, resultsErr = s.getClaimable(reqParams)
+
+	case "getcontractstate":
+		getcontractstateCalled.Inc()
+		results, resultsErr = s.getContractState(reqParams)
+
+	case "getstorage":
+		getstorageCalled.Inc()
+		results, resultsErr = s.getStorage(reqParams)
+
+	case "findstates":
+		findstatesCalled.Inc()
+		results, resultsErr = s.findStates(reqParams)
+
 	case "getrawtransaction":
 		getrawtransactionCalled.Inc()
 		results, resultsErr = s.getrawtransaction(reqParams)
 
+	case "gettxout":
+		gettxoutCalled.Inc()
+		results, resultsErr = s.getTxOut(reqParams)
+
+	case "getrawmempool":
+		getrawmempoolCalled.Inc()
+		results, resultsErr = s.getRawMempool(reqParams)
+
+	case "getnep5balances":
+		getnep5balancesCalled.Inc()
+		results, resultsErr = s.getNEP5Balances(reqParams)
+
+	case "getnep5transfers":
+		getnep5transfersCalled.Inc()
+		results, resultsErr = s.getNEP5Transfers(reqParams)
+
+	case "getunclaimed":
+		getunclaimedCalled.Inc()
+		results, resultsErr = s.getUnclaimed(reqParams)
+
+	case "getunspents":
+		getunspentsCalled.Inc()
+		results, resultsErr = s.getUnspents(reqParams)
+
+	case "getvalidators":
+		getvalidatorsCalled.Inc()
+		results, resultsErr = s.getValidators()
+
 	case "invokescript":
 		results, resultsErr = s.invokescript(reqParams)
 
+	case "invokefunction":
+		results, resultsErr = s.invokeFunction(reqParams)
+
+	case "invoke":
+		results, resultsErr = s.invoke(reqParams)
+
+	case "submitblock":
+		submitblockCalled.Inc()
+		results, resultsErr = s.submitblock(reqParams)
+
 	case "sendrawtransaction":
 		sendrawtransactionCalled.Inc()
 		results, resultsErr = s.sendrawtransaction(reqParams)
@@ -258,50 +575,565 @@ Methods:
 		resultsErr = NewMethodNotFoundError(fmt.Sprintf("Method '%s' not supported", req.Method), nil)
 	}
 
-	if resultsErr != nil {
-		req.WriteErrorResponse(w, resultsErr)
-		return
+	return results, resultsErr
+}
+
+// getApplicationLog returns the contract log based on the specified txid.
+func (s *Server) getApplicationLog(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := util.Uint256DecodeReverseString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	appExecResult, err := s.chain.GetAppExecResult(txHash)
+	if err != nil {
+		return nil, NewInvalidParamsError("unknown transaction", err)
 	}
 
-	req.WriteResponse(w, results)
+	tx, _, err := s.chain.GetTransaction(txHash)
+	if err != nil {
+		return nil, NewInvalidParamsError("Error while getting transaction", err)
+	}
+
+	var scriptHash util.Uint160
+	if t, ok := tx.Data.(*transaction.InvocationTX); ok {
+		scriptHash = hash.Hash160(t.Script)
+	}
+
+	return NewApplicationLog(appExecResult, scriptHash), nil
 }
 
-func (s *Server) getrawtransaction(reqParams Params) (interface{}, error) {
-	var resultsErr error
-	var results interface{}
+// getBlockSysFee returns the total amount of system fees paid by all blocks
+// up to and including the block with the given index.
+func (s *Server) getBlockSysFee(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "number")
+	if err != nil {
+		return nil, err
+	}
+	if !s.validBlockHeight(param) {
+		return nil, invalidBlockHeightError(0, param.IntVal)
+	}
 
-	param0, err := reqParams.ValueWithType(0, "string")
+	headerHash := s.chain.GetHeaderHash(param.IntVal)
+	return util.Fixed8FromInt64(int64(s.chain.GetSystemFeeAmount(headerHash))), nil
+}
+
+// getClaimable returns spent and not yet claimed NEO outputs of the given
+// address along with the amount of GAS that can be claimed for them.
+func (s *Server) getClaimable(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
 	if err != nil {
-		resultsErr = err
-	} else if txHash, err := util.Uint256DecodeReverseString(param0.StringVal); err != nil {
-		resultsErr = errInvalidParams
-	} else if tx, height, err := s.chain.GetTransaction(txHash); err != nil {
-		err = errors.Wrapf(err, "Invalid transaction hash: %s", txHash)
-		resultsErr = NewInvalidParamsError(err.Error(), err)
-	} else if len(reqParams) >= 2 {
-		_header := s.chain.GetHeaderHash(int(height))
-		header, err := s.chain.GetHeader(_header)
-		if err != nil {
-			resultsErr = NewInvalidParamsError(err.Error(), err)
+		return nil, err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	coins, err := s.chain.GetClaimable(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("can't get claimable coins", err)
+	}
+	res := result.ClaimableInfo{
+		Spents:  make([]result.Claimable, 0, len(coins)),
+		Address: param.StringVal,
+	}
+	for _, coin := range coins {
+		generated, sysFee := s.chain.CalculateClaimable(coin.Output.Amount, coin.StartHeight, coin.EndHeight)
+		res.Spents = append(res.Spents, result.Claimable{
+			Tx:          coin.TxHash,
+			N:           int(coin.Index),
+			Value:       coin.Output.Amount,
+			StartHeight: coin.StartHeight,
+			EndHeight:   coin.EndHeight,
+			Generated:   generated,
+			SysFee:      sysFee,
+			Unclaimed:   generated + sysFee,
+		})
+		res.Unclaimed += generated + sysFee
+	}
+	return res, nil
+}
+
+// getUnclaimed returns the amount of GAS available and not yet available for
+// claim by the given address.
+func (s *Server) getUnclaimed(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	available, unavailable, err := s.chain.GetUnclaimed(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("can't get unclaimed GAS", err)
+	}
+	return result.Unclaimed{
+		Available:   available,
+		Unavailable: unavailable,
+		Unclaimed:   available + unavailable,
+	}, nil
+}
+
+// getContractState returns contract state (including its parameter list,
+// return type and properties) by the given script hash.
+func (s *Server) getContractState(reqParams Params) (interface{}, error) {
+	scriptHash, err := scriptHashFromParams(reqParams, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := s.chain.GetContractState(scriptHash)
+	if cs == nil {
+		return nil, NewInvalidParamsError("unknown contract", nil)
+	}
+	return wrappers.NewContractState(cs), nil
+}
+
+// getStorage returns hex-encoded value stored by the given contract with the
+// given hex-encoded key, null is returned when there is no such value.
+func (s *Server) getStorage(reqParams Params) (interface{}, error) {
+	scriptHash, err := scriptHashFromParams(reqParams, 0)
+	if err != nil {
+		return nil, err
+	}
+	param, err := reqParams.ValueWithType(1, "string")
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	item := s.chain.GetStorageItem(scriptHash, key)
+	if item == nil {
+		return nil, nil
+	}
+	return hex.EncodeToString(item.Value), nil
+}
+
+// findStates returns storage items of the given contract with keys starting
+// with the given hex-encoded prefix. Items are sorted by key and returned in
+// pages of at most maxFindStatesCount items, the optional third parameter is
+// the last key of the previous page and the optional fourth one limits the
+// number of items returned.
+func (s *Server) findStates(reqParams Params) (interface{}, error) {
+	scriptHash, err := scriptHashFromParams(reqParams, 0)
+	if err != nil {
+		return nil, err
+	}
+	param, err := reqParams.ValueWithType(1, "string")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := hex.DecodeString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	var start []byte
+	if param, ok := reqParams.ValueAt(2); ok {
+		if param.Type != "string" {
+			return nil, errInvalidParams
 		}
+		if start, err = hex.DecodeString(param.StringVal); err != nil {
+			return nil, errInvalidParams
+		}
+	}
+	count := maxFindStatesCount
+	if param, ok := reqParams.ValueAt(3); ok {
+		if param.Type != "number" || param.IntVal <= 0 {
+			return nil, errInvalidParams
+		}
+		if param.IntVal < count {
+			count = param.IntVal
+		}
+	}
 
-		param1, _ := reqParams.ValueAt(1)
-		switch v := param1.RawValue.(type) {
+	res := result.FindStates{Results: []result.KeyValue{}}
+	err = s.chain.SeekStorageItems(scriptHash, prefix, start, func(k []byte, si *core.StorageItem) bool {
+		// The start key itself belongs to the previous page.
+		if bytes.Equal(k, start) {
+			return true
+		}
+		if len(res.Results) == count {
+			res.Truncated = true
+			return false
+		}
+		res.Results = append(res.Results, result.KeyValue{
+			Key:   hex.EncodeToString(k),
+			Value: hex.EncodeToString(si.Value),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, NewInternalServerError("can't get storage items", err)
+	}
+	return res, nil
+}
 
-		case int, float64, bool, string:
-			if v == 0 || v == "0" || v == 0.0 || v == false || v == "false" {
-				results = hex.EncodeToString(tx.Bytes())
-			} else {
-				results = wrappers.NewTransactionOutputRaw(tx, header, s.chain)
+// scriptHashFromParams decodes the script hash (in BE representation,
+// optionally prefixed with 0x) from the parameter at the given index.
+func scriptHashFromParams(reqParams Params, index int) (util.Uint160, error) {
+	param, err := reqParams.ValueWithType(index, "string")
+	if err != nil {
+		return util.Uint160{}, err
+	}
+	scriptHash, err := util.Uint160DecodeReverseString(strings.TrimPrefix(param.StringVal, "0x"))
+	if err != nil {
+		return util.Uint160{}, errInvalidParams
+	}
+	return scriptHash, nil
+}
+
+// getNEP5Balances returns NEP-5 token balances of the given address.
+func (s *Server) getNEP5Balances(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	balances, err := s.chain.GetNEP5Balances(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("can't get NEP-5 balances", err)
+	}
+	res := result.NEP5Balances{
+		Balances: make([]result.NEP5Balance, 0, len(balances)),
+		Address:  param.StringVal,
+	}
+	for _, b := range balances {
+		res.Balances = append(res.Balances, result.NEP5Balance{
+			Asset:       "0x" + b.Asset.ReverseString(),
+			Amount:      b.Amount.String(),
+			LastUpdated: b.LastUpdatedBlock,
+		})
+	}
+	return res, nil
+}
+
+// getNEP5Transfers returns NEP-5 transfers of the given address made in the
+// given time range, the range defaults to the last week.
+func (s *Server) getNEP5Transfers(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	end := uint32(time.Now().Unix())
+	if p, ok := reqParams.ValueAt(2); ok {
+		if p.Type != "number" || p.IntVal < 0 || int64(p.IntVal) > math.MaxUint32 {
+			return nil, errInvalidParams
+		}
+		end = uint32(p.IntVal)
+	}
+	var start uint32
+	if end > nep5TransfersDefaultPeriod {
+		start = end - nep5TransfersDefaultPeriod
+	}
+	if p, ok := reqParams.ValueAt(1); ok {
+		if p.Type != "number" || p.IntVal < 0 || int64(p.IntVal) > math.MaxUint32 {
+			return nil, errInvalidParams
+		}
+		start = uint32(p.IntVal)
+	}
+	if start > end {
+		return nil, errInvalidParams
+	}
+
+	transfers, err := s.chain.GetNEP5Transfers(scriptHash, start, end)
+	if err != nil {
+		return nil, NewInternalServerError("can't get NEP-5 transfers", err)
+	}
+	res := result.NEP5Transfers{
+		Sent:     []result.NEP5Transfer{},
+		Received: []result.NEP5Transfer{},
+		Address:  param.StringVal,
+	}
+	for _, tr := range transfers {
+		transfer := result.NEP5Transfer{
+			Timestamp:   tr.Timestamp,
+			Asset:       "0x" + tr.Asset.ReverseString(),
+			Amount:      tr.Amount.String(),
+			Index:       tr.Block,
+			NotifyIndex: tr.NotifyIndex,
+			TxHash:      tr.TxHash,
+		}
+		if tr.From.Equals(scriptHash) {
+			transfer.Address = crypto.AddressFromUint160(tr.To)
+			res.Sent = append(res.Sent, transfer)
+		}
+		if tr.To.Equals(scriptHash) {
+			transfer.Address = crypto.AddressFromUint160(tr.From)
+			res.Received = append(res.Received, transfer)
+		}
+	}
+	return res, nil
+}
+
+// getUnspents returns unspent outputs of the given address grouped by asset.
+func (s *Server) getUnspents(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	scriptHash, err := crypto.Uint160DecodeAddress(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+
+	unspents, err := s.chain.GetUnspents(scriptHash)
+	if err != nil {
+		return nil, NewInternalServerError("can't get unspents", err)
+	}
+	res := result.Unspents{
+		Balance: []result.UnspentBalance{},
+		Address: param.StringVal,
+	}
+	balances := make(map[util.Uint256]int)
+	for _, us := range unspents {
+		i, ok := balances[us.Output.AssetID]
+		if !ok {
+			var name, symbol string
+			if as := s.chain.GetAssetState(us.Output.AssetID); as != nil {
+				name = as.GetName()
+				symbol = name
+				if as.AssetType == transaction.UtilityToken {
+					symbol = "GAS"
+				}
 			}
-		default:
-			results = wrappers.NewTransactionOutputRaw(tx, header, s.chain)
+			i = len(res.Balance)
+			balances[us.Output.AssetID] = i
+			res.Balance = append(res.Balance, result.UnspentBalance{
+				Unspents:    []result.Unspent{},
+				AssetHash:   us.Output.AssetID,
+				Asset:       name,
+				AssetSymbol: symbol,
+			})
 		}
-	} else {
-		results = hex.EncodeToString(tx.Bytes())
+		res.Balance[i].Unspents = append(res.Balance[i].Unspents, result.Unspent{
+			Tx:    us.TxHash,
+			N:     int(us.Index),
+			Value: us.Output.Amount,
+		})
+		res.Balance[i].Amount += us.Output.Amount
 	}
+	return res, nil
+}
 
-	return results, resultsErr
+// getValidators returns the current NEO consensus nodes information and
+// voting status.
+func (s *Server) getValidators() (interface{}, error) {
+	validators, err := s.chain.GetValidators()
+	if err != nil {
+		return nil, NewInternalServerError("can't get validators", err)
+	}
+	enrollments, err := s.chain.GetEnrollments()
+	if err != nil {
+		return nil, NewInternalServerError("can't get enrollments", err)
+	}
+	active := make(map[string]bool, len(validators))
+	for _, pub := range validators {
+		active[string(pub.Bytes())] = true
+	}
+	res := make([]result.Validator, 0, len(enrollments))
+	for _, v := range enrollments {
+		res = append(res, result.Validator{
+			PublicKey: hex.EncodeToString(v.PublicKey.Bytes()),
+			Votes:     v.Votes,
+			Active:    active[string(v.PublicKey.Bytes())],
+		})
+	}
+	return res, nil
+}
+
+func (s *Server) getrawtransaction(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	txHash, err := util.Uint256DecodeReverseString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	tx, height, err := s.chain.GetTransaction(txHash)
+	if err != nil {
+		err = errors.Wrapf(err, "Invalid transaction hash: %s", txHash)
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+	if !verboseParam(reqParams, 1) {
+		return hex.EncodeToString(tx.Bytes()), nil
+	}
+
+	header, err := s.chain.GetHeader(s.chain.GetHeaderHash(int(height)))
+	if err != nil {
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+	return wrappers.NewTransactionOutputRaw(tx, header, s.chain), nil
+}
+
+// getTxOut returns the output of the given transaction with the given index,
+// null is returned if the output is already spent.
+func (s *Server) getTxOut(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	txHash, err := util.Uint256DecodeReverseString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	param, err = reqParams.ValueWithType(1, "number")
+	if err != nil {
+		return nil, err
+	}
+	if param.IntVal < 0 || param.IntVal > math.MaxUint16 {
+		return nil, errInvalidParams
+	}
+	index := uint16(param.IntVal)
+
+	ucs := s.chain.GetUnspentCoinState(txHash)
+	if ucs == nil || ucs.IsSpent(index) {
+		return nil, nil
+	}
+	tx, _, err := s.chain.GetTransaction(txHash)
+	if err != nil || int(index) >= len(tx.Outputs) {
+		return nil, nil
+	}
+	out := *tx.Outputs[index]
+	out.Position = int(index)
+	return &out, nil
+}
+
+// getRawMempool returns hashes of the verified transactions in the memory
+// pool, if the first parameter is true unverified transactions hashes along
+// with the current height are returned too.
+func (s *Server) getRawMempool(reqParams Params) (interface{}, error) {
+	mp := s.chain.GetMemPool()
+	verified := txHashes(mp.GetVerifiedTransactions())
+	if !verboseParam(reqParams, 0) {
+		return verified, nil
+	}
+	return result.RawMempool{
+		Height:     s.chain.BlockHeight(),
+		Verified:   verified,
+		Unverified: txHashes(mp.GetUnverifiedTransactions()),
+	}, nil
+}
+
+// txHashes returns hashes of the given transactions.
+func txHashes(txes []*transaction.Transaction) []util.Uint256 {
+	hashes := make([]util.Uint256, 0, len(txes))
+	for _, tx := range txes {
+		hashes = append(hashes, tx.Hash())
+	}
+	return hashes
+}
+
+// getBlock returns the block with the given hash or index, either
+// hex-encoded or verbose one if requested by the second parameter.
+func (s *Server) getBlock(reqParams Params) (interface{}, error) {
+	hash, err := s.blockHashFromParams(reqParams)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.chain.GetBlock(hash)
+	if err != nil {
+		return nil, NewInternalServerError(fmt.Sprintf("Problem locating block with hash: %s", hash), err)
+	}
+	if !verboseParam(reqParams, 1) {
+		buf := io.NewBufBinWriter()
+		block.EncodeBinary(buf.BinWriter)
+		if buf.Err != nil {
+			return nil, NewInternalServerError("can't encode block", buf.Err)
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	return wrappers.NewBlock(block, s.chain), nil
+}
+
+// getBlockHeader returns the header of the block with the given hash or
+// index, either hex-encoded or verbose one if requested by the second
+// parameter.
+func (s *Server) getBlockHeader(reqParams Params) (interface{}, error) {
+	hash, err := s.blockHashFromParams(reqParams)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := s.chain.GetHeader(hash)
+	if err != nil {
+		return nil, NewInvalidParamsError(fmt.Sprintf("Problem locating header with hash: %s", hash), err)
+	}
+	if !verboseParam(reqParams, 1) {
+		buf := io.NewBufBinWriter()
+		header.EncodeBinary(buf.BinWriter)
+		if buf.Err != nil {
+			return nil, NewInternalServerError("can't encode header", buf.Err)
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	return wrappers.NewHeader(header, s.chain), nil
+}
+
+// blockHashFromParams returns the block hash specified by the first
+// parameter either directly (as a BE string) or by the block index.
+func (s *Server) blockHashFromParams(reqParams Params) (util.Uint256, error) {
+	param, err := reqParams.Value(0)
+	if err != nil {
+		return util.Uint256{}, err
+	}
+
+	switch param.Type {
+	case "string":
+		hash, err := util.Uint256DecodeReverseString(param.StringVal)
+		if err != nil {
+			return util.Uint256{}, errInvalidParams
+		}
+		return hash, nil
+	case "number":
+		if !s.validBlockHeight(param) {
+			return util.Uint256{}, errInvalidParams
+		}
+		return s.chain.GetHeaderHash(param.IntVal), nil
+	default:
+		return util.Uint256{}, errInvalidParams
+	}
+}
+
+// verboseParam checks whether the parameter at the given index requests
+// verbose output, it's false when the parameter is missing or is one of 0,
+// "0", false or "false".
+func verboseParam(reqParams Params, index int) bool {
+	param, ok := reqParams.ValueAt(index)
+	if !ok {
+		return false
+	}
+	switch v := param.RawValue.(type) {
+	case float64:
+		return v != 0
+	case bool:
+		return v
+	case string:
+		return v != "0" && v != "false"
+	default:
+		return true
+	}
 }
 
 // invokescript implements the `invokescript` RPC call.
@@ -314,16 +1146,90 @@ func (s *Server) invokescript(reqParams Params) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	vm, _ := s.chain.GetTestVM()
-	vm.LoadScript(script)
-	_ = vm.Run()
-	result := &wrappers.InvokeResult{
-		State:       vm.State(),
-		GasConsumed: "0.1",
-		Script:      hexScript.StringVal,
-		Stack:       vm.Estack(),
+	return s.runScriptInVM(script), nil
+}
+
+// invokeFunction implements the `invokefunction` RPC call.
+func (s *Server) invokeFunction(reqParams Params) (interface{}, error) {
+	scriptHash, err := scriptHashFromParams(reqParams, 0)
+	if err != nil {
+		return nil, err
+	}
+	operation, err := reqParams.ValueWithType(1, "string")
+	if err != nil {
+		return nil, err
+	}
+	var args []StackParam
+	if param, ok := reqParams.ValueAt(2); ok {
+		if args, err = stackParamsFromParam(param); err != nil {
+			return nil, err
+		}
+	}
+	script, err := CreateFunctionInvocationScript(scriptHash, operation.StringVal, args)
+	if err != nil {
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+	return s.runScriptInVM(script), nil
+}
+
+// invoke implements the `invoke` RPC call.
+func (s *Server) invoke(reqParams Params) (interface{}, error) {
+	scriptHash, err := scriptHashFromParams(reqParams, 0)
+	if err != nil {
+		return nil, err
+	}
+	param, err := reqParams.Value(1)
+	if err != nil {
+		return nil, err
+	}
+	args, err := stackParamsFromParam(param)
+	if err != nil {
+		return nil, err
+	}
+	script, err := CreateInvocationScript(scriptHash, args)
+	if err != nil {
+		return nil, NewInvalidParamsError(err.Error(), err)
 	}
-	return result, nil
+	return s.runScriptInVM(script), nil
+}
+
+// runScriptInVM runs the given script in a test VM (with the Application
+// trigger, so no verification is performed) and returns the result. The
+// resulting stack is encoded the same way as parameters are.
+func (s *Server) runScriptInVM(script []byte) *InvokeResult {
+	v, _ := s.chain.GetTestVM()
+	v.SetGasLimit(core.FreeGAS + s.config.MaxGasInvoke)
+	v.LoadScript(script)
+	_ = v.Run()
+	state, _ := vm.StateFromString(v.State())
+	items := v.Estack().ToArray()
+	stack := make([]StackParam, 0, len(items))
+	for _, item := range items {
+		stack = append(stack, stackParamFromStackItem(item))
+	}
+	return &InvokeResult{
+		State:       state,
+		GasConsumed: v.GasConsumed().String(),
+		Script:      hex.EncodeToString(script),
+		Stack:       stack,
+	}
+}
+
+// stackParamsFromParam decodes the given parameter (that should be an array)
+// into a slice of StackParam.
+func stackParamsFromParam(param *Param) ([]StackParam, error) {
+	if _, ok := param.RawValue.([]interface{}); !ok {
+		return nil, errInvalidParams
+	}
+	raw, err := json.Marshal(param.RawValue)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	var params []StackParam
+	if err = json.Unmarshal(raw, &params); err != nil {
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+	return params, nil
 }
 
 func (s *Server) sendrawtransaction(reqParams Params) (interface{}, error) {
@@ -342,22 +1248,9 @@ func (s *Server) sendrawtransaction(reqParams Params) (interface{}, error) {
 		if r.Err != nil {
 			err = errors.Wrap(r.Err, "transaction DecodeBinary failed")
 		} else {
-			relayReason := s.coreServer.RelayTxn(tx)
-			switch relayReason {
-			case network.RelaySucceed:
+			err = relayReasonToError(s.coreServer.RelayTxn(tx))
+			if err == nil {
 				results = true
-			case network.RelayAlreadyExists:
-				err = errors.New("block or transaction already exists and cannot be sent repeatedly")
-			case network.RelayOutOfMemory:
-				err = errors.New("the memory pool is full and no more transactions can be sent")
-			case network.RelayUnableToVerify:
-				err = errors.New("the block cannot be validated")
-			case network.RelayInvalid:
-				err = errors.New("block or transaction validation failed")
-			case network.RelayPolicyFail:
-				err = errors.New("one of the Policy filters failed")
-			default:
-				err = errors.New("unknown error")
 			}
 		}
 		if err != nil {
@@ -368,6 +1261,51 @@ func (s *Server) sendrawtransaction(reqParams Params) (interface{}, error) {
 	return results, resultsErr
 }
 
+// submitblock decodes the given hex-encoded block, adds it to the chain and
+// relays it to the network.
+func (s *Server) submitblock(reqParams Params) (interface{}, error) {
+	param, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	blockBytes, err := hex.DecodeString(param.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	r := io.NewBinReaderFromBuf(blockBytes)
+	block := new(core.Block)
+	block.DecodeBinary(r)
+	if r.Err != nil {
+		err = errors.Wrap(r.Err, "block DecodeBinary failed")
+		return nil, NewInvalidParamsError(err.Error(), err)
+	}
+	if err = relayReasonToError(s.coreServer.RelayBlock(block)); err != nil {
+		return nil, NewInternalServerError(err.Error(), err)
+	}
+	return true, nil
+}
+
+// relayReasonToError converts the given RelayReason into an error describing
+// it, nil is returned for network.RelaySucceed.
+func relayReasonToError(reason network.RelayReason) error {
+	switch reason {
+	case network.RelaySucceed:
+		return nil
+	case network.RelayAlreadyExists:
+		return errors.New("block or transaction already exists and cannot be sent repeatedly")
+	case network.RelayOutOfMemory:
+		return errors.New("the memory pool is full and no more transactions can be sent")
+	case network.RelayUnableToVerify:
+		return errors.New("the block cannot be validated")
+	case network.RelayInvalid:
+		return errors.New("block or transaction validation failed")
+	case network.RelayPolicyFail:
+		return errors.New("one of the Policy filters failed")
+	default:
+		return errors.New("unknown error")
+	}
+}
+
 func (s Server) validBlockHeight(param *Param) bool {
 	return param.IntVal >= 0 && param.IntVal <= int(s.chain.BlockHeight())
 }
@@ -9,10 +9,17 @@ import (
 	"github.com/infinitete/neo-go-inf/config"
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/storage"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/network"
 	"github.com/infinitete/neo-go-inf/pkg/rpc/result"
 	"github.com/infinitete/neo-go-inf/pkg/rpc/wrappers"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,21 +87,26 @@ type GetBlockResponse struct {
 		Previousblockhash string `json:"previousblockhash"`
 		Merkleroot        string `json:"merkleroot"`
 		Time              int    `json:"time"`
-		Height            int    `json:"height"`
-		Nonce             int    `json:"nonce"`
-		NextConsensus     string `json:"next_consensus"`
+		Index             int    `json:"index"`
+		Nonce             string `json:"nonce"`
+		NextConsensus     string `json:"nextconsensus"`
 		Script            struct {
 			Invocation   string `json:"invocation"`
 			Verification string `json:"verification"`
 		} `json:"script"`
 		Tx []struct {
+			Txid       string      `json:"txid"`
+			Size       int         `json:"size"`
 			Type       string      `json:"type"`
 			Version    int         `json:"version"`
 			Attributes interface{} `json:"attributes"`
 			Vin        interface{} `json:"vin"`
 			Vout       interface{} `json:"vout"`
+			SysFee     string      `json:"sys_fee"`
+			NetFee     string      `json:"net_fee"`
 			Scripts    interface{} `json:"scripts"`
 		} `json:"tx"`
+		Size          int    `json:"size"`
 		Confirmations int    `json:"confirmations"`
 		Nextblockhash string `json:"nextblockhash"`
 		Hash          string `json:"hash"`
@@ -102,6 +114,79 @@ type GetBlockResponse struct {
 	ID int `json:"id"`
 }
 
+// GetBlockHeaderResponse struct for testing.
+type GetBlockHeaderResponse struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Result  struct {
+		Hash          string `json:"hash"`
+		Size          int    `json:"size"`
+		Index         int    `json:"index"`
+		Confirmations int    `json:"confirmations"`
+		Nextblockhash string `json:"nextblockhash"`
+	} `json:"result"`
+	ID int `json:"id"`
+}
+
+// RawTxVerboseResponse struct for testing.
+type RawTxVerboseResponse struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Result  struct {
+		Txid          string                `json:"txid"`
+		Size          int                   `json:"size"`
+		Type          string                `json:"type"`
+		Version       int                   `json:"version"`
+		Vin           []interface{}         `json:"vin"`
+		Vout          []interface{}         `json:"vout"`
+		Scripts       []transaction.Witness `json:"scripts"`
+		SysFee        string                `json:"sys_fee"`
+		NetFee        string                `json:"net_fee"`
+		Blockhash     string                `json:"blockhash"`
+		Confirmations int                   `json:"confirmations"`
+		Blocktime     int                   `json:"blocktime"`
+		Asset         *struct {
+			Type      transaction.AssetType `json:"type"`
+			Name      interface{}           `json:"name"`
+			Amount    string                `json:"amount"`
+			Precision int                   `json:"precision"`
+			Admin     string                `json:"admin"`
+		} `json:"asset"`
+	} `json:"result"`
+	ID int `json:"id"`
+}
+
+// GetTxOutResponse struct for testing.
+type GetTxOutResponse struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Result  *struct {
+		N       int    `json:"n"`
+		Asset   string `json:"asset"`
+		Value   string `json:"value"`
+		Address string `json:"address"`
+	} `json:"result"`
+	ID int `json:"id"`
+}
+
+// GetRawMempoolResponse struct for testing.
+type GetRawMempoolResponse struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Result  result.RawMempool `json:"result"`
+	ID      int               `json:"id"`
+}
+
+// GetNEP5BalancesResponse struct for testing.
+type GetNEP5BalancesResponse struct {
+	Jsonrpc string              `json:"jsonrpc"`
+	Result  result.NEP5Balances `json:"result"`
+	ID      int                 `json:"id"`
+}
+
+// GetNEP5TransfersResponse struct for testing.
+type GetNEP5TransfersResponse struct {
+	Jsonrpc string               `json:"jsonrpc"`
+	Result  result.NEP5Transfers `json:"result"`
+	ID      int                  `json:"id"`
+}
+
 // GetAssetResponse struct for testing.
 type GetAssetResponse struct {
 	Jsonrpc string `json:"jsonrpc"`
@@ -123,6 +208,17 @@ type GetAssetResponse struct {
 	ID int `json:"id"`
 }
 
+// InvokeScriptResultResponse struct for testing.
+type InvokeScriptResultResponse struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Result  struct {
+		State       string `json:"state"`
+		GasConsumed string `json:"gas_consumed"`
+		Script      string `json:"script"`
+	} `json:"result"`
+	ID int `json:"id"`
+}
+
 // GetAccountStateResponse struct for testing.
 type GetAccountStateResponse struct {
 	Jsonrpc string `json:"jsonrpc"`
@@ -139,7 +235,101 @@ type GetAccountStateResponse struct {
 	ID int `json:"id"`
 }
 
-func initServerWithInMemoryChain(ctx context.Context, t *testing.T) (*core.Blockchain, http.HandlerFunc) {
+// testValidatorsWIFs are the keys of the unit test network validators.
+var testValidatorsWIFs = []string{
+	"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+	"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+	"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+	"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+}
+
+// newTestBlock creates a new block following the current chain's top with
+// the miner transaction and the given transactions, signed by the test
+// network validators.
+func newTestBlock(t *testing.T, chain *core.Blockchain, txs ...*transaction.Transaction) *core.Block {
+	prev, err := chain.GetBlock(chain.CurrentBlockHash())
+	require.NoError(t, err)
+
+	miner := &transaction.Transaction{
+		Type:       transaction.MinerType,
+		Data:       &transaction.MinerTX{Nonce: prev.Index + 1},
+		Attributes: []*transaction.Attribute{},
+		Inputs:     []*transaction.Input{},
+		Outputs:    []*transaction.Output{},
+		Scripts:    []*transaction.Witness{},
+	}
+	txs = append([]*transaction.Transaction{miner}, txs...)
+	hashes := make([]util.Uint256, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash())
+	}
+	mt, err := crypto.NewMerkleTree(hashes)
+	require.NoError(t, err)
+	b := &core.Block{
+		BlockBase: core.BlockBase{
+			Version:       0,
+			PrevHash:      prev.Hash(),
+			MerkleRoot:    mt.Root(),
+			Timestamp:     prev.Timestamp + 1,
+			Index:         prev.Index + 1,
+			ConsensusData: 1111,
+			NextConsensus: prev.NextConsensus,
+			Script: &transaction.Witness{
+				VerificationScript: prev.Script.VerificationScript,
+			},
+		},
+		Transactions: txs,
+	}
+
+	data, err := b.GetHashableData()
+	require.NoError(t, err)
+	for _, wif := range testValidatorsWIFs {
+		priv, err := keys.NewPrivateKeyFromWIF(wif)
+		require.NoError(t, err)
+		sig, err := priv.Sign(data)
+		require.NoError(t, err)
+		// 0x40 is PUSHBYTES64
+		b.Script.InvocationScript = append(b.Script.InvocationScript, 0x40)
+		b.Script.InvocationScript = append(b.Script.InvocationScript, sig...)
+	}
+	return b
+}
+
+// testMultisig returns the verification script and the script hash of the
+// unit test network validators multisig account holding all the NEO.
+func testMultisig(t *testing.T) ([]byte, util.Uint160) {
+	pubs := make(keys.PublicKeys, 0, len(testValidatorsWIFs))
+	for _, wif := range testValidatorsWIFs {
+		priv, err := keys.NewPrivateKeyFromWIF(wif)
+		require.NoError(t, err)
+		pubs = append(pubs, priv.PublicKey())
+	}
+	script, err := smartcontract.CreateMultiSigRedeemScript(len(pubs)*2/3+1, pubs)
+	require.NoError(t, err)
+	return script, hash.Hash160(script)
+}
+
+// signTx signs the given transaction spending the outputs of the test
+// validators multisig account.
+func signTx(t *testing.T, tx *transaction.Transaction) {
+	require.Equal(t, 0, len(tx.Scripts))
+	// Hashable fields are followed by the (empty) witnesses count.
+	data := tx.Bytes()
+	data = data[:len(data)-1]
+	witness := &transaction.Witness{}
+	for _, wif := range testValidatorsWIFs {
+		priv, err := keys.NewPrivateKeyFromWIF(wif)
+		require.NoError(t, err)
+		sig, err := priv.Sign(data)
+		require.NoError(t, err)
+		witness.InvocationScript = append(witness.InvocationScript, byte(vm.PUSHBYTES64))
+		witness.InvocationScript = append(witness.InvocationScript, sig...)
+	}
+	witness.VerificationScript, _ = testMultisig(t)
+	tx.Scripts = []*transaction.Witness{witness}
+}
+
+func initServerWithInMemoryChain(ctx context.Context, t *testing.T) (*core.Blockchain, *Server, http.HandlerFunc) {
 	var nBlocks uint32
 
 	net := config.ModeUnitTestNet
@@ -166,9 +356,10 @@ func initServerWithInMemoryChain(ctx context.Context, t *testing.T) (*core.Block
 	}
 
 	serverConfig := network.NewServerConfig(cfg)
-	server := network.NewServer(serverConfig, chain)
+	server, err := network.NewServer(serverConfig, chain)
+	require.NoError(t, err)
 	rpcServer := NewServer(chain, cfg.ApplicationConfiguration.RPC, server)
 	handler := http.HandlerFunc(rpcServer.requestHandler)
 
-	return chain, handler
+	return chain, &rpcServer, handler
 }
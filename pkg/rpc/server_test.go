@@ -3,7 +3,9 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -12,14 +14,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/infinitete/neo-go-inf/config"
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/result"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRPC(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 	defer cancel()
 
-	chain, handler := initServerWithInMemoryChain(ctx, t)
+	chain, _, handler := initServerWithInMemoryChain(ctx, t)
 
 	t.Run("getbestblockhash", func(t *testing.T) {
 		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getbestblockhash", "params": []}`
@@ -32,7 +45,7 @@ func TestRPC(t *testing.T) {
 	})
 
 	t.Run("getblock", func(t *testing.T) {
-		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblock", "params": [1]}`
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblock", "params": [1, 1]}`
 		body := doRPCCall(rpc, handler, t)
 		checkErrResponse(t, body, false)
 		var res GetBlockResponse
@@ -42,6 +55,68 @@ func TestRPC(t *testing.T) {
 		assert.NoErrorf(t, err, "could not get block")
 		expectedHash := "0x" + block.Hash().ReverseString()
 		assert.Equal(t, expectedHash, res.Result.Hash)
+		assert.Equal(t, 1, res.Result.Index)
+		assert.Equal(t, io.GetVarSize(block), res.Result.Size)
+		assert.Equal(t, int(chain.BlockHeight()), res.Result.Confirmations)
+		assert.Equal(t, "0x"+chain.GetHeaderHash(2).ReverseString(), res.Result.Nextblockhash)
+		assert.Equal(t, fmt.Sprintf("%016x", block.ConsensusData), res.Result.Nonce)
+		require.Equal(t, len(block.Transactions), len(res.Result.Tx))
+		for i, tx := range res.Result.Tx {
+			assert.Equal(t, "0x"+block.Transactions[i].Hash().ReverseString(), tx.Txid)
+			assert.Equal(t, io.GetVarSize(block.Transactions[i]), tx.Size)
+		}
+	})
+
+	t.Run("getblock_raw", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblock", "params": [1]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res StringResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		rawBlock, err := hex.DecodeString(res.Result)
+		require.NoError(t, err)
+		block := new(core.Block)
+		r := io.NewBinReaderFromBuf(rawBlock)
+		block.DecodeBinary(r)
+		require.NoError(t, r.Err)
+		assert.Equal(t, chain.GetHeaderHash(1), block.Hash())
+	})
+
+	t.Run("getblockheader", func(t *testing.T) {
+		hash := chain.GetHeaderHash(1)
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getblockheader", "params": ["%s", true]}`, hash.ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetBlockHeaderResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, "0x"+hash.ReverseString(), res.Result.Hash)
+		assert.Equal(t, 1, res.Result.Index)
+		assert.Equal(t, int(chain.BlockHeight()), res.Result.Confirmations)
+		assert.Equal(t, "0x"+chain.GetHeaderHash(2).ReverseString(), res.Result.Nextblockhash)
+	})
+
+	t.Run("getblockheader_raw", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblockheader", "params": [1, 0]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res StringResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		rawHeader, err := hex.DecodeString(res.Result)
+		require.NoError(t, err)
+		header := new(core.Header)
+		r := io.NewBinReaderFromBuf(rawHeader)
+		header.DecodeBinary(r)
+		require.NoError(t, r.Err)
+		assert.Equal(t, chain.GetHeaderHash(1), header.Hash())
+	})
+
+	t.Run("getblockheader_invalid_height", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblockheader", "params": [-1]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
 	})
 
 	t.Run("getblockcount", func(t *testing.T) {
@@ -99,6 +174,91 @@ func TestRPC(t *testing.T) {
 		assert.Equal(t, []int{}, res.Result.Connected)
 	})
 
+	t.Run("invalid_version", func(t *testing.T) {
+		rpc := `{"jsonrpc": "1.0", "id": 1, "method": "getblockcount", "params": []}`
+		body := doRPCCall(rpc, handler, t)
+		var res ErrorResponse
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(body), &res))
+		assert.Equal(t, -32600, res.Error.Code)
+	})
+
+	t.Run("no_params", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getblockcount"}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+	})
+
+	t.Run("notification", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "method": "getblockcount", "params": []}`
+		body := doRPCCall(rpc, handler, t)
+		assert.Equal(t, 0, len(body))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		rpc := `[
+			{"jsonrpc": "2.0", "id": 1, "method": "getblockcount", "params": []},
+			{"jsonrpc": "2.0", "method": "getblockcount", "params": []},
+			{"jsonrpc": "2.0", "id": 2, "method": "getblockhash", "params": [0]},
+			{"jsonrpc": "2.0", "id": 3, "method": "unknownmethod", "params": []}
+		]`
+		body := doRPCCall(rpc, handler, t)
+		var res []struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		require.NoErrorf(t, json.Unmarshal(bytes.TrimSpace(body), &res), "could not parse response: %s", body)
+		require.Equal(t, 3, len(res))
+		assert.Equal(t, 1, res[0].ID)
+		assert.Equal(t, fmt.Sprintf("%d", chain.BlockHeight()+1), string(res[0].Result))
+		assert.Equal(t, 2, res[1].ID)
+		assert.Nil(t, res[1].Error)
+		assert.Equal(t, 3, res[2].ID)
+		require.NotNil(t, res[2].Error)
+		assert.Equal(t, -32601, res[2].Error.Code)
+	})
+
+	t.Run("batch_notifications", func(t *testing.T) {
+		rpc := `[{"jsonrpc": "2.0", "method": "getblockcount", "params": []}]`
+		body := doRPCCall(rpc, handler, t)
+		assert.Equal(t, 0, len(body))
+	})
+
+	t.Run("batch_empty", func(t *testing.T) {
+		body := doRPCCall(`[]`, handler, t)
+		var res ErrorResponse
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(body), &res))
+		assert.Equal(t, -32600, res.Error.Code)
+	})
+
+	t.Run("batch_invalid_element", func(t *testing.T) {
+		rpc := `[1, {"jsonrpc": "2.0", "id": 2, "method": "getblockcount", "params": []}]`
+		body := doRPCCall(rpc, handler, t)
+		var res []struct {
+			ID    *int `json:"id"`
+			Error *struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		require.NoErrorf(t, json.Unmarshal(bytes.TrimSpace(body), &res), "could not parse response: %s", body)
+		require.Equal(t, 2, len(res))
+		assert.Nil(t, res[0].ID)
+		require.NotNil(t, res[0].Error)
+		assert.Equal(t, -32600, res[0].Error.Code)
+		require.NotNil(t, res[1].ID)
+		assert.Equal(t, 2, *res[1].ID)
+		assert.Nil(t, res[1].Error)
+	})
+
+	t.Run("batch_parse_error", func(t *testing.T) {
+		body := doRPCCall(`[{"jsonrpc": "2.0", "id": 1, "method"`, handler, t)
+		var res ErrorResponse
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(body), &res))
+		assert.Equal(t, -32700, res.Error.Code)
+	})
+
 	t.Run("validateaddress_positive", func(t *testing.T) {
 		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "validateaddress", "params": ["AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i"]}`
 		body := doRPCCall(rpc, handler, t)
@@ -161,10 +321,419 @@ func TestRPC(t *testing.T) {
 		assert.Equal(t, "Invalid public account address", res.Result)
 	})
 
+	t.Run("getapplicationlog_negative", func(t *testing.T) {
+		block, _ := chain.GetBlock(chain.GetHeaderHash(0))
+		TXHash := block.Transactions[1].Hash()
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getapplicationlog", "params": ["%s"]}`, TXHash.ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getapplicationlog_invalid_hash", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getapplicationlog", "params": ["notahash"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getblocksysfee", func(t *testing.T) {
+		height := int(chain.BlockHeight())
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getblocksysfee", "params": [%d]}`, height)
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetBlockSysFeeResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		expected := chain.GetSystemFeeAmount(chain.GetHeaderHash(height))
+		assert.Equal(t, util.Fixed8FromInt64(int64(expected)), res.Result)
+	})
+
+	t.Run("getblocksysfee_invalid_height", func(t *testing.T) {
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getblocksysfee", "params": [%d]}`, chain.BlockHeight()+1)
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getclaimable", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getclaimable", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetClaimableResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", res.Result.Address)
+		var sum util.Fixed8
+		for _, c := range res.Result.Spents {
+			assert.Equal(t, c.Generated+c.SysFee, c.Unclaimed)
+			sum += c.Unclaimed
+		}
+		assert.Equal(t, sum, res.Result.Unclaimed)
+	})
+
+	t.Run("getclaimable_invalid_address", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getclaimable", "params": ["notanaddress"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getunclaimed", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getunclaimed", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetUnclaimedResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.True(t, res.Result.Unavailable > 0)
+		assert.Equal(t, res.Result.Available+res.Result.Unavailable, res.Result.Unclaimed)
+	})
+
+	t.Run("getunclaimed_invalid_address", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getunclaimed", "params": ["notanaddress"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getunspents", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getunspents", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetUnspentsResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", res.Result.Address)
+		require.Equal(t, 1, len(res.Result.Balance))
+		var sum util.Fixed8
+		for _, us := range res.Result.Balance[0].Unspents {
+			sum += us.Value
+		}
+		assert.Equal(t, sum, res.Result.Balance[0].Amount)
+	})
+
+	t.Run("getunspents_invalid_address", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getunspents", "params": ["notanaddress"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getnep5balances", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getnep5balances", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetNEP5BalancesResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		require.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", res.Result.Address)
+		assert.NotNil(t, res.Result.Balances)
+		assert.Equal(t, 0, len(res.Result.Balances))
+	})
+
+	t.Run("getnep5balances_invalid_address", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getnep5balances", "params": ["notanaddress"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getnep5transfers", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getnep5transfers", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", 0]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetNEP5TransfersResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		require.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", res.Result.Address)
+		assert.NotNil(t, res.Result.Sent)
+		assert.NotNil(t, res.Result.Received)
+		assert.Equal(t, 0, len(res.Result.Sent))
+		assert.Equal(t, 0, len(res.Result.Received))
+	})
+
+	t.Run("getnep5transfers_invalid_range", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getnep5transfers", "params": ["AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", 200, 100]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getnep5transfers_invalid_address", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getnep5transfers", "params": ["notanaddress"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("client_CalculateInputs", func(t *testing.T) {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{})
+		require.NoError(t, err)
+
+		resp, err := c.GetUnspents("AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU")
+		require.NoError(t, err)
+		require.NotNil(t, resp.Result)
+		require.Equal(t, 1, len(resp.Result.Balance))
+		balance := resp.Result.Balance[0]
+
+		inputs, total, err := c.Balancer().CalculateInputs("AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", balance.AssetHash, balance.Amount)
+		require.NoError(t, err)
+		assert.Equal(t, balance.Amount, total)
+		assert.Equal(t, len(balance.Unspents), len(inputs))
+
+		_, _, err = c.CalculateInputs("AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", balance.AssetHash, balance.Amount+1)
+		assert.Error(t, err)
+	})
+
+	t.Run("client_invoke", func(t *testing.T) {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{})
+		require.NoError(t, err)
+
+		contract, err := util.Uint160DecodeReverseString("b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4")
+		require.NoError(t, err)
+		account := util.Uint160{1, 2, 3}
+		params := []smartcontract.Parameter{
+			{Type: smartcontract.ByteArrayType, Value: []byte{0xab, 0xcd}},
+			{Type: smartcontract.Hash160Type, Value: account},
+			{Type: smartcontract.IntegerType, Value: 42},
+			{Type: smartcontract.ArrayType, Value: []smartcontract.Parameter{
+				{Type: smartcontract.StringType, Value: "qwerty"},
+			}},
+		}
+		expected := []StackParam{
+			{Type: ByteArray, Value: []byte{0xab, 0xcd}},
+			{Type: Hash160, Value: account},
+			{Type: Integer, Value: int64(42)},
+			{Type: Array, Value: []StackParam{{Type: String, Value: "qwerty"}}},
+		}
+
+		t.Run("invokefunction", func(t *testing.T) {
+			resp, err := c.InvokeFunction(contract.ReverseString(), "test", params)
+			require.NoError(t, err)
+			script, err := CreateFunctionInvocationScript(contract, "test", expected)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(script), resp.Result.Script)
+		})
+
+		t.Run("invokefunction no args", func(t *testing.T) {
+			resp, err := c.InvokeFunction(contract.ReverseString(), "test", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "00c1"+"0474657374"+"67"+hex.EncodeToString(contract.Bytes()), resp.Result.Script)
+		})
+
+		t.Run("invoke", func(t *testing.T) {
+			resp, err := c.Invoke(contract.ReverseString(), params)
+			require.NoError(t, err)
+			script, err := CreateInvocationScript(contract, expected)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(script), resp.Result.Script)
+		})
+
+		t.Run("invokescript stack", func(t *testing.T) {
+			resp, err := c.InvokeScript("02abcd")
+			require.NoError(t, err)
+			assert.Equal(t, []StackParam{{Type: ByteArray, Value: []byte{0xab, 0xcd}}}, resp.Result.Stack)
+		})
+	})
+
+	t.Run("client_typed", func(t *testing.T) {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+		c, err := NewClient(context.Background(), srv.URL, ClientOptions{})
+		require.NoError(t, err)
+
+		block, err := chain.GetBlock(chain.GetHeaderHash(1))
+		require.NoError(t, err)
+
+		t.Run("blocks", func(t *testing.T) {
+			count, err := c.GetBlockCount()
+			require.NoError(t, err)
+			assert.Equal(t, chain.BlockHeight()+1, count)
+
+			best, err := c.GetBestBlockHash()
+			require.NoError(t, err)
+			assert.Equal(t, chain.CurrentBlockHash(), best)
+
+			hash, err := c.GetBlockHash(1)
+			require.NoError(t, err)
+			assert.Equal(t, block.Hash(), hash)
+
+			b, err := c.GetBlockByIndex(1)
+			require.NoError(t, err)
+			assert.Equal(t, block.Hash(), b.Hash())
+			assert.Equal(t, len(block.Transactions), len(b.Transactions))
+
+			b, err = c.GetBlockByHash(block.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, block.Index, b.Index)
+
+			bv, err := c.GetBlockByIndexVerbose(1)
+			require.NoError(t, err)
+			assert.Equal(t, block.Hash(), bv.Hash)
+			assert.Equal(t, chain.BlockHeight(), bv.Confirmations)
+			require.Equal(t, len(block.Transactions), len(bv.Tx))
+			for i, tx := range bv.Tx {
+				assert.Equal(t, block.Transactions[i].Hash(), tx.TxHash)
+				assert.Equal(t, block.Transactions[i].Type, tx.Type)
+				assert.Equal(t, len(block.Transactions[i].Outputs), len(tx.Outputs))
+			}
+
+			bv, err = c.GetBlockByHashVerbose(block.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), bv.Index)
+			assert.Equal(t, block.Script.VerificationScript, bv.Script.VerificationScript)
+
+			h, err := c.GetBlockHeader(block.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, block.Hash(), h.Hash())
+
+			hv, err := c.GetBlockHeaderVerbose(block.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, block.PrevHash, hv.PrevHash)
+			require.NotNil(t, hv.NextBlockHash)
+			assert.Equal(t, chain.GetHeaderHash(2), *hv.NextBlockHash)
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			expected := block.Transactions[len(block.Transactions)-1]
+
+			tx, err := c.GetRawTransaction(expected.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, expected.Hash(), tx.Hash())
+
+			_, height, err := chain.GetTransaction(expected.Hash())
+			require.NoError(t, err)
+			txv, err := c.GetRawTransactionVerbose(expected.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, expected.Hash(), txv.TxHash)
+			assert.Equal(t, chain.GetHeaderHash(int(height)), txv.Blockhash)
+			assert.Equal(t, len(expected.Scripts), len(txv.Scripts))
+
+			scriptHash, err := crypto.Uint160DecodeAddress("AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU")
+			require.NoError(t, err)
+			unspents, err := chain.GetUnspents(scriptHash)
+			require.NoError(t, err)
+			require.NotEqual(t, 0, len(unspents))
+			out, err := c.GetTxOut(unspents[0].TxHash, unspents[0].Index)
+			require.NoError(t, err)
+			require.NotNil(t, out)
+			assert.Equal(t, scriptHash, out.ScriptHash)
+			assert.Equal(t, unspents[0].Output.Amount, out.Amount)
+
+			mp, err := c.GetRawMempool()
+			require.NoError(t, err)
+			assert.Equal(t, 0, len(mp))
+
+			mpv, err := c.GetRawMempoolVerbose()
+			require.NoError(t, err)
+			assert.Equal(t, chain.BlockHeight(), mpv.Height)
+		})
+
+		t.Run("node", func(t *testing.T) {
+			_, err := c.GetConnectionCount()
+			require.NoError(t, err)
+
+			v, err := c.GetVersion()
+			require.NoError(t, err)
+			assert.NotEmpty(t, v.UserAgent)
+
+			_, err = c.GetPeers()
+			require.NoError(t, err)
+
+			valid, err := c.ValidateAddress("AQVh2pG732YvtNaxEGkQUei3YA4cvo7d2i")
+			require.NoError(t, err)
+			assert.True(t, valid)
+			valid, err = c.ValidateAddress("notanaddress")
+			require.NoError(t, err)
+			assert.False(t, valid)
+
+			id, err := util.Uint256DecodeReverseString("602c79718b16e442de58778e148d0b1084e3b2dffd5de6b7b16cee7969282de7")
+			require.NoError(t, err)
+			as, err := c.GetAssetState(id)
+			require.NoError(t, err)
+			assert.Equal(t, id, as.ID)
+			assert.Equal(t, "AWKECj9RD8rS8RPcpCgYVjk1DeYyHwxZm3", as.Admin)
+
+			_, err = c.GetAssetState(util.Uint256{1, 2, 3})
+			assert.Error(t, err)
+		})
+
+		t.Run("errors", func(t *testing.T) {
+			_, err := c.GetBlockByIndex(chain.BlockHeight() + 1)
+			require.Error(t, err)
+			rpcErr, ok := err.(*Error)
+			require.True(t, ok)
+			assert.Equal(t, errInvalidParams.Code, rpcErr.Code)
+
+			_, err = c.GetRawTransaction(util.Uint256{1, 2, 3})
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("getcontractstate_unknown", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getcontractstate", "params": ["0xb0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getcontractstate_invalid_hash", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getcontractstate", "params": ["notahash"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getstorage_unknown", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getstorage", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "0102"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		assert.Contains(t, string(body), `"result":null`)
+		var res GetStorageResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Nil(t, res.Result)
+	})
+
+	t.Run("getstorage_invalid_key", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getstorage", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "zz"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+		assert.NotContains(t, string(body), `"result"`)
+	})
+
+	t.Run("findstates_empty", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "findstates", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "", "", 10]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res FindStatesResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, 0, len(res.Result.Results))
+		assert.False(t, res.Result.Truncated)
+	})
+
+	t.Run("findstates_invalid_count", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "findstates", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "", "", 0]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getvalidators", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getvalidators", "params": []}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetValidatorsResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, len(chain.GetConfig().StandbyValidators), len(res.Result))
+		for _, v := range res.Result {
+			assert.True(t, v.Active)
+		}
+	})
+
 	t.Run("getrawtransaction", func(t *testing.T) {
 		block, _ := chain.GetBlock(chain.GetHeaderHash(0))
 		TXHash := block.Transactions[1].Hash()
-		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getrawtransaction", "params": ["%s"]}"`, TXHash.ReverseString())
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getrawtransaction", "params": ["%s"]}`, TXHash.ReverseString())
 		body := doRPCCall(rpc, handler, t)
 		checkErrResponse(t, body, false)
 		var res StringResultResponse
@@ -173,6 +742,169 @@ func TestRPC(t *testing.T) {
 		assert.Equal(t, "400000455b7b226c616e67223a227a682d434e222c226e616d65223a22e5b08fe89a81e882a1227d2c7b226c616e67223a22656e222c226e616d65223a22416e745368617265227d5d0000c16ff28623000000da1745e9b549bd0bfa1a569971c77eba30cd5a4b00000000", res.Result)
 	})
 
+	t.Run("getrawtransaction_verbose", func(t *testing.T) {
+		block, _ := chain.GetBlock(chain.GetHeaderHash(0))
+		TXHash := block.Transactions[1].Hash()
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getrawtransaction", "params": ["%s", 1]}`, TXHash.ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res RawTxVerboseResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		require.NoErrorf(t, err, "could not parse response: %s", body)
+		tx := block.Transactions[1]
+		assert.Equal(t, "0x"+TXHash.ReverseString(), res.Result.Txid)
+		assert.Equal(t, io.GetVarSize(tx), res.Result.Size)
+		assert.Equal(t, "RegisterTransaction", res.Result.Type)
+		assert.Equal(t, int(tx.Version), res.Result.Version)
+		assert.Equal(t, "0", res.Result.SysFee)
+		assert.Equal(t, "0", res.Result.NetFee)
+		assert.Equal(t, 0, len(res.Result.Vin))
+		assert.Equal(t, 0, len(res.Result.Vout))
+		assert.Equal(t, len(tx.Scripts), len(res.Result.Scripts))
+		assert.Equal(t, "0x"+block.Hash().ReverseString(), res.Result.Blockhash)
+		assert.Equal(t, int(chain.BlockHeight()+1), res.Result.Confirmations)
+		assert.Equal(t, int(block.Timestamp), res.Result.Blocktime)
+		require.NotNil(t, res.Result.Asset)
+		asset := tx.Data.(*transaction.RegisterTX)
+		assert.IsType(t, []interface{}{}, res.Result.Asset.Name)
+		assert.Equal(t, asset.AssetType, res.Result.Asset.Type)
+		assert.Equal(t, asset.Amount.String(), res.Result.Asset.Amount)
+		assert.Equal(t, int(asset.Precision), res.Result.Asset.Precision)
+		assert.Equal(t, crypto.AddressFromUint160(asset.Admin), res.Result.Asset.Admin)
+	})
+
+	t.Run("gettxout", func(t *testing.T) {
+		scriptHash, err := crypto.Uint160DecodeAddress("AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU")
+		require.NoError(t, err)
+		unspents, err := chain.GetUnspents(scriptHash)
+		require.NoError(t, err)
+		require.NotEqual(t, 0, len(unspents))
+		us := unspents[0]
+
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "gettxout", "params": ["%s", %d]}`, us.TxHash.ReverseString(), us.Index)
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetTxOutResponse
+		err = json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, int(us.Index), res.Result.N)
+		assert.Equal(t, "0x"+us.Output.AssetID.ReverseString(), res.Result.Asset)
+		assert.Equal(t, us.Output.Amount.String(), res.Result.Value)
+		assert.Equal(t, "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU", res.Result.Address)
+	})
+
+	t.Run("gettxout_unknown_output", func(t *testing.T) {
+		block, _ := chain.GetBlock(chain.GetHeaderHash(0))
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "gettxout", "params": ["%s", 100]}`, block.Transactions[0].Hash().ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		assert.Contains(t, string(body), `"result":null`)
+		var res GetTxOutResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Nil(t, res.Result)
+	})
+
+	t.Run("gettxout_invalid_index", func(t *testing.T) {
+		block, _ := chain.GetBlock(chain.GetHeaderHash(0))
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "gettxout", "params": ["%s", -1]}`, block.Transactions[0].Hash().ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("getrawmempool", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getrawmempool", "params": []}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res struct {
+			Result []util.Uint256 `json:"result"`
+		}
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.NotNil(t, res.Result)
+		assert.Equal(t, 0, len(res.Result))
+	})
+
+	t.Run("getrawmempool_unverified", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getrawmempool", "params": [true]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetRawMempoolResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, chain.BlockHeight(), res.Result.Height)
+		assert.Equal(t, 0, len(res.Result.Verified))
+		assert.Equal(t, 0, len(res.Result.Unverified))
+	})
+
+	t.Run("invokescript", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invokescript", "params": ["515293"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res InvokeScriptResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, "HALT", res.Result.State)
+		assert.Equal(t, "0.001", res.Result.GasConsumed)
+	})
+
+	t.Run("invokescript_gas_limit", func(t *testing.T) {
+		// Infinite JMP loop.
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invokescript", "params": ["620000"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res InvokeScriptResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.Equal(t, "FAULT", res.Result.State)
+		assert.Equal(t, "20.001", res.Result.GasConsumed)
+	})
+
+	t.Run("invokefunction", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invokefunction", "params": ["0xb0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "test", [{"type": "String", "value": "qwerty"}, {"type": "Integer", "value": 1}]]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res InvokeScriptResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		hash, err := util.Uint160DecodeReverseString("b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4")
+		require.NoError(t, err)
+		script, err := CreateFunctionInvocationScript(hash, "test", []StackParam{
+			{Type: String, Value: "qwerty"},
+			{Type: Integer, Value: int64(1)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(script), res.Result.Script)
+		assert.NotEqual(t, "", res.Result.State)
+	})
+
+	t.Run("invokefunction_invalid_params", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invokefunction", "params": ["0xb0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "test", [{"type": "Integer", "value": "notanumber"}]]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("invoke", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invoke", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", [{"type": "String", "value": "qwerty"}]]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res InvokeScriptResultResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		hash, err := util.Uint160DecodeReverseString("b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4")
+		require.NoError(t, err)
+		script, err := CreateInvocationScript(hash, []StackParam{{Type: String, Value: "qwerty"}})
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(script), res.Result.Script)
+	})
+
+	t.Run("invoke_not_an_array", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "invoke", "params": ["b0cf8bd0f4aaaa6de4e2b8fd0ea6dd3e3ec1d8d4", "qwerty"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
 	t.Run("sendrawtransaction_positive", func(t *testing.T) {
 		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "sendrawtransaction", "params": ["d1001b00046e616d6567d3d8602814a429a91afdbaa3914884a1c90c733101201cc9c05cefffe6cdd7b182816a9152ec218d2ec000000141403387ef7940a5764259621e655b3c621a6aafd869a611ad64adcc364d8dd1edf84e00a7f8b11b630a377eaef02791d1c289d711c08b7ad04ff0d6c9caca22cfe6232103cbb45da6072c14761c9da545749d9cfd863f860c351066d16df480602a2024c6ac"]}`
 		body := doRPCCall(rpc, handler, t)
@@ -188,6 +920,414 @@ func TestRPC(t *testing.T) {
 		body := doRPCCall(rpc, handler, t)
 		checkErrResponse(t, body, true)
 	})
+
+	t.Run("submitblock_invalid_hex", func(t *testing.T) {
+		rpc := `{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["notahex"]}`
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("submitblock_already_exists", func(t *testing.T) {
+		block, err := chain.GetBlock(chain.GetHeaderHash(1))
+		require.NoError(t, err)
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["%s"]}`, hexBlock(t, block))
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("submitblock_bad_signature", func(t *testing.T) {
+		block := newTestBlock(t, chain)
+		for i := 1; i < len(block.Script.InvocationScript); i += 65 {
+			block.Script.InvocationScript[i] ^= 0xff
+		}
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["%s"]}`, hexBlock(t, block))
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+	})
+
+	t.Run("submitblock", func(t *testing.T) {
+		height := chain.BlockHeight()
+		block := newTestBlock(t, chain)
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["%s"]}`, hexBlock(t, block))
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res SendTXResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		assert.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.True(t, res.Result)
+		assert.Equal(t, height+1, chain.BlockHeight())
+		assert.Equal(t, block.Hash(), chain.CurrentBlockHash())
+	})
+
+	t.Run("getapplicationlog", func(t *testing.T) {
+		script := new(bytes.Buffer)
+		require.NoError(t, vm.EmitInt(script, 42))
+		require.NoError(t, vm.EmitString(script, "event"))
+		require.NoError(t, vm.EmitInt(script, 2))
+		require.NoError(t, vm.EmitOpcode(script, vm.PACK))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Runtime.Notify"))
+		tx := &transaction.Transaction{
+			Type:       transaction.InvocationType,
+			Version:    1,
+			Data:       &transaction.InvocationTX{Script: script.Bytes(), Version: 1},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{},
+			Outputs:    []*transaction.Output{},
+			Scripts:    []*transaction.Witness{},
+		}
+		require.NoError(t, chain.AddBlock(newTestBlock(t, chain, tx)))
+
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getapplicationlog", "params": ["%s"]}`, tx.Hash().ReverseString())
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res GetApplicationLogResponse
+		err := json.Unmarshal(bytes.TrimSpace(body), &res)
+		require.NoErrorf(t, err, "could not parse response: %s", body)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, tx.Hash(), res.Result.TxHash)
+		require.Equal(t, 1, len(res.Result.Executions))
+		exec := res.Result.Executions[0]
+		assert.Equal(t, "Application", exec.Trigger)
+		assert.Equal(t, "HALT", exec.VMState)
+		assert.Equal(t, hash.Hash160(script.Bytes()), exec.ScriptHash)
+		require.Equal(t, 1, len(exec.Events))
+		assert.Equal(t, hash.Hash160(script.Bytes()), exec.Events[0].Contract)
+		assert.Equal(t, StackParam{
+			Type: Array,
+			Value: []StackParam{
+				{Type: ByteArray, Value: []byte("event")},
+				// Integers above 16 are pushed as byte arrays.
+				{Type: ByteArray, Value: []byte{42}},
+			},
+		}, exec.Events[0].Item)
+	})
+
+	t.Run("contract storage", func(t *testing.T) {
+		// Contract deployment is paid with the GAS claimed for the NEO
+		// held by the validators since the genesis block.
+		for chain.BlockHeight() < 80 {
+			require.NoError(t, chain.AddBlock(newTestBlock(t, chain)))
+		}
+		_, account := testMultisig(t)
+		unspents, err := chain.GetUnspents(account)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(unspents))
+		neo := unspents[0]
+		move := &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{{PrevHash: neo.TxHash, PrevIndex: neo.Index}},
+			Outputs:    []*transaction.Output{neo.Output},
+		}
+		signTx(t, move)
+		require.NoError(t, chain.AddBlock(newTestBlock(t, chain, move)))
+
+		claims := []*transaction.Input{{PrevHash: neo.TxHash, PrevIndex: neo.Index}}
+		bonus, err := chain.CalculateBonus(claims)
+		require.NoError(t, err)
+		fee := util.Fixed8FromInt64(500)
+		require.True(t, bonus > fee)
+		claim := &transaction.Transaction{
+			Type:       transaction.ClaimType,
+			Data:       &transaction.ClaimTX{Claims: claims},
+			Attributes: []*transaction.Attribute{},
+			Inputs:     []*transaction.Input{},
+			Outputs: []*transaction.Output{{
+				AssetID:    core.UtilityTokenID(),
+				Amount:     bonus,
+				ScriptHash: account,
+			}},
+		}
+		signTx(t, claim)
+		require.NoError(t, chain.AddBlock(newTestBlock(t, chain, claim)))
+
+		// The contract stores some items when called.
+		contract := new(bytes.Buffer)
+		for _, k := range []string{"aa01", "bb01", "aa03", "aa02"} {
+			require.NoError(t, vm.EmitString(contract, "v"+k))
+			require.NoError(t, vm.EmitBytes(contract, []byte(k)))
+			require.NoError(t, vm.EmitSyscall(contract, "Neo.Storage.GetContext"))
+			require.NoError(t, vm.EmitSyscall(contract, "Neo.Storage.Put"))
+		}
+		require.NoError(t, vm.EmitOpcode(contract, vm.RET))
+		contractHash := hash.Hash160(contract.Bytes())
+
+		script := new(bytes.Buffer)
+		for _, s := range []string{"description", "email", "author", "1.0", "storage"} {
+			require.NoError(t, vm.EmitString(script, s))
+		}
+		require.NoError(t, vm.EmitInt(script, int64(smartcontract.HasStorage)))
+		require.NoError(t, vm.EmitInt(script, int64(smartcontract.VoidType)))
+		require.NoError(t, vm.EmitBytes(script, []byte{byte(smartcontract.StringType), byte(smartcontract.ArrayType)}))
+		require.NoError(t, vm.EmitBytes(script, contract.Bytes()))
+		require.NoError(t, vm.EmitSyscall(script, "Neo.Contract.Create"))
+		deploy := transaction.NewInvocationTX(script.Bytes())
+		deploy.Data.(*transaction.InvocationTX).Gas = fee
+		deploy.Inputs = []*transaction.Input{{PrevHash: claim.Hash(), PrevIndex: 0}}
+		deploy.Outputs = []*transaction.Output{{
+			AssetID:    core.UtilityTokenID(),
+			Amount:     bonus - fee,
+			ScriptHash: account,
+		}}
+		signTx(t, deploy)
+		require.NoError(t, chain.AddBlock(newTestBlock(t, chain, deploy)))
+		aer, err := chain.GetAppExecResult(deploy.Hash())
+		require.NoError(t, err)
+		require.Equal(t, "HALT", aer.VMState)
+
+		script = new(bytes.Buffer)
+		require.NoError(t, vm.EmitAppCall(script, contractHash, false))
+		call := transaction.NewInvocationTX(script.Bytes())
+		require.NoError(t, chain.AddBlock(newTestBlock(t, chain, call)))
+
+		t.Run("getcontractstate", func(t *testing.T) {
+			rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "getcontractstate", "params": ["%s"]}`, contractHash.ReverseString())
+			body := doRPCCall(rpc, handler, t)
+			checkErrResponse(t, body, false)
+			var res GetContractStateResponse
+			err := json.Unmarshal(bytes.TrimSpace(body), &res)
+			require.NoErrorf(t, err, "could not parse response: %s", body)
+			require.NotNil(t, res.Result)
+			assert.Equal(t, hex.EncodeToString(contract.Bytes()), res.Result.Script)
+			assert.Equal(t, []smartcontract.ParamType{smartcontract.StringType, smartcontract.ArrayType}, res.Result.ParamList)
+			assert.Equal(t, smartcontract.VoidType, res.Result.ReturnType)
+			assert.Equal(t, "storage", res.Result.Name)
+			assert.Equal(t, "1.0", res.Result.CodeVersion)
+			assert.True(t, res.Result.Properties.HasStorage)
+			assert.False(t, res.Result.Properties.IsPayable)
+		})
+
+		t.Run("findstates", func(t *testing.T) {
+			findStates := func(start string, count int) *result.FindStates {
+				rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "findstates", "params": ["%s", "%s", "%s", %d]}`,
+					contractHash.ReverseString(), hex.EncodeToString([]byte("aa")), start, count)
+				body := doRPCCall(rpc, handler, t)
+				checkErrResponse(t, body, false)
+				var res FindStatesResponse
+				err := json.Unmarshal(bytes.TrimSpace(body), &res)
+				require.NoErrorf(t, err, "could not parse response: %s", body)
+				require.NotNil(t, res.Result)
+				return res.Result
+			}
+			kv := func(k string) result.KeyValue {
+				return result.KeyValue{
+					Key:   hex.EncodeToString([]byte(k)),
+					Value: hex.EncodeToString([]byte("v" + k)),
+				}
+			}
+
+			page := findStates("", 2)
+			assert.Equal(t, []result.KeyValue{kv("aa01"), kv("aa02")}, page.Results)
+			assert.True(t, page.Truncated)
+
+			page = findStates(page.Results[1].Key, 2)
+			assert.Equal(t, []result.KeyValue{kv("aa03")}, page.Results)
+			assert.False(t, page.Truncated)
+
+			page = findStates("", 3)
+			assert.Equal(t, []result.KeyValue{kv("aa01"), kv("aa02"), kv("aa03")}, page.Results)
+			assert.False(t, page.Truncated)
+		})
+	})
+
+	t.Run("submitblock_double_spend", func(t *testing.T) {
+		_, account := testMultisig(t)
+		unspents, err := chain.GetUnspents(account)
+		require.NoError(t, err)
+		require.NotEqual(t, 0, len(unspents))
+		u := unspents[0]
+		newSpend := func(remark string) *transaction.Transaction {
+			tx := &transaction.Transaction{
+				Type: transaction.ContractType,
+				Data: &transaction.ContractTX{},
+				Attributes: []*transaction.Attribute{{
+					Usage: transaction.Remark,
+					Data:  []byte(remark),
+				}},
+				Inputs:  []*transaction.Input{{PrevHash: u.TxHash, PrevIndex: u.Index}},
+				Outputs: []*transaction.Output{u.Output},
+			}
+			signTx(t, tx)
+			return tx
+		}
+
+		height := chain.BlockHeight()
+		block := newTestBlock(t, chain, newSpend("first"), newSpend("second"))
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["%s"]}`, hexBlock(t, block))
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, true)
+		assert.Equal(t, height, chain.BlockHeight())
+	})
+
+	t.Run("submitblock_pooled_tx", func(t *testing.T) {
+		_, account := testMultisig(t)
+		unspents, err := chain.GetUnspents(account)
+		require.NoError(t, err)
+		tx := &transaction.Transaction{
+			Type:       transaction.ContractType,
+			Data:       &transaction.ContractTX{},
+			Attributes: []*transaction.Attribute{},
+		}
+		for _, u := range unspents {
+			tx.Inputs = append(tx.Inputs, &transaction.Input{PrevHash: u.TxHash, PrevIndex: u.Index})
+			tx.Outputs = append(tx.Outputs, u.Output)
+		}
+		signTx(t, tx)
+
+		rpc := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "sendrawtransaction", "params": ["%s"]}`, hex.EncodeToString(tx.Bytes()))
+		body := doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		require.True(t, chain.GetMemPool().ContainsKey(tx.Hash()))
+
+		block := newTestBlock(t, chain, tx)
+		rpc = fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "submitblock", "params": ["%s"]}`, hexBlock(t, block))
+		body = doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		var res SendTXResponse
+		err = json.Unmarshal(bytes.TrimSpace(body), &res)
+		require.NoErrorf(t, err, "could not parse response: %s", body)
+		assert.True(t, res.Result)
+		assert.Equal(t, block.Hash(), chain.CurrentBlockHash())
+		assert.False(t, chain.GetMemPool().ContainsKey(tx.Hash()))
+
+		in := tx.Inputs[0]
+		rpc = fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "gettxout", "params": ["%s", %d]}`, in.PrevHash.ReverseString(), in.PrevIndex)
+		body = doRPCCall(rpc, handler, t)
+		checkErrResponse(t, body, false)
+		assert.Contains(t, string(body), `"result":null`)
+	})
+}
+
+func hexBlock(t *testing.T, b *core.Block) string {
+	buf := io.NewBufBinWriter()
+	b.EncodeBinary(buf.BinWriter)
+	require.NoError(t, buf.Err)
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestRPCAccessControl(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	chain, rpcSrv, _ := initServerWithInMemoryChain(ctx, t)
+	newHandler := func(modify func(*config.RPCConfig)) http.HandlerFunc {
+		conf := rpcSrv.config
+		modify(&conf)
+		srv := NewServer(chain, conf, rpcSrv.coreServer)
+		return srv.requestHandler
+	}
+	call := func(handler http.HandlerFunc, body string, user, password string) (*http.Response, ErrorResponse) {
+		req := httptest.NewRequest("POST", "http://0.0.0.0:20333/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var res ErrorResponse
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(w.Body.Bytes()), &res))
+		return w.Result(), res
+	}
+	const getBlockCount = `{"jsonrpc": "2.0", "id": 1, "method": "getblockcount", "params": []}`
+
+	t.Run("disabled methods", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.DisabledMethods = []string{"getblockcount"}
+		})
+		resp, res := call(handler, getBlockCount, "", "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, -32002, res.Error.Code)
+
+		_, res = call(handler, `{"jsonrpc": "2.0", "id": 1, "method": "getbestblockhash", "params": []}`, "", "")
+		assert.Equal(t, 0, res.Error.Code)
+	})
+
+	t.Run("enabled methods", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.EnabledMethods = []string{"getbestblockhash"}
+		})
+		_, res := call(handler, getBlockCount, "", "")
+		assert.Equal(t, -32002, res.Error.Code)
+
+		_, res = call(handler, `{"jsonrpc": "2.0", "id": 1, "method": "getbestblockhash", "params": []}`, "", "")
+		assert.Equal(t, 0, res.Error.Code)
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.BasicAuth = config.RPCBasicAuth{User: "user", Password: "pass"}
+		})
+		resp, res := call(handler, getBlockCount, "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NotEqual(t, "", resp.Header.Get("WWW-Authenticate"))
+		assert.Equal(t, -32001, res.Error.Code)
+
+		_, res = call(handler, getBlockCount, "user", "wrong")
+		assert.Equal(t, -32001, res.Error.Code)
+
+		resp, res = call(handler, getBlockCount, "user", "pass")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 0, res.Error.Code)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.RateLimit = 2
+		})
+		for i := 0; i < 2; i++ {
+			_, res := call(handler, getBlockCount, "", "")
+			assert.Equal(t, 0, res.Error.Code)
+		}
+		resp, res := call(handler, getBlockCount, "", "")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, -32003, res.Error.Code)
+	})
+
+	t.Run("rate limit batch", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.RateLimit = 2
+		})
+		req := httptest.NewRequest("POST", "http://0.0.0.0:20333/", strings.NewReader(`[
+			{"jsonrpc": "2.0", "id": 1, "method": "getblockcount", "params": []},
+			{"jsonrpc": "2.0", "id": 2, "method": "getblockcount", "params": []},
+			{"jsonrpc": "2.0", "id": 3, "method": "getblockcount", "params": []}]`))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var res []ErrorResponse
+		require.NoError(t, json.Unmarshal(bytes.TrimSpace(w.Body.Bytes()), &res))
+		require.Equal(t, 3, len(res))
+		assert.Equal(t, 0, res[0].Error.Code)
+		assert.Equal(t, 0, res[1].Error.Code)
+		assert.Equal(t, -32003, res[2].Error.Code)
+
+		resp, _ := call(handler, getBlockCount, "", "")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("max body size", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {
+			c.MaxRequestBodyBytes = 16
+		})
+		resp, res := call(handler, getBlockCount, "", "")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		assert.Equal(t, -32600, res.Error.Code)
+	})
+
+	t.Run("body read error", func(t *testing.T) {
+		handler := newHandler(func(c *config.RPCConfig) {})
+		req := httptest.NewRequest("POST", "http://0.0.0.0:20333/", errReader{})
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+}
+
+// errReader is a reader that always fails.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
 }
 
 func checkErrResponse(t *testing.T, body []byte, expectingFail bool) {
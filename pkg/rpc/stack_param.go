@@ -4,9 +4,13 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"math/big"
 	"strconv"
+	"strings"
 
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
 	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	"github.com/pkg/errors"
 )
 
@@ -25,6 +29,7 @@ const (
 	PublicKey        StackParamType = 0x06
 	String           StackParamType = 0x07
 	Array            StackParamType = 0x10
+	Map              StackParamType = 0x12
 	InteropInterface StackParamType = 0xf0
 	Void             StackParamType = 0xff
 )
@@ -50,6 +55,8 @@ func (t StackParamType) String() string {
 		return "String"
 	case Array:
 		return "Array"
+	case Map:
+		return "Map"
 	case InteropInterface:
 		return "InteropInterface"
 	case Void:
@@ -80,6 +87,8 @@ func StackParamTypeFromString(s string) (StackParamType, error) {
 		return String, nil
 	case "Array":
 		return Array, nil
+	case "Map":
+		return Map, nil
 	case "InteropInterface":
 		return InteropInterface, nil
 	case "Void":
@@ -103,15 +112,68 @@ func (t *StackParamType) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+// MarshalJSON implements Marshaler interface.
+func (t StackParamType) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.String() + `"`), nil
+}
+
 // StackParam represent a stack parameter.
 type StackParam struct {
 	Type  StackParamType `json:"type"`
 	Value interface{}    `json:"value"`
 }
 
+// StackParamMapElement is a key-value pair stored in the Map StackParam.
+type StackParamMapElement struct {
+	Key   StackParam `json:"key"`
+	Value StackParam `json:"value"`
+}
+
 type rawStackParam struct {
 	Type  StackParamType  `json:"type"`
-	Value json.RawMessage `json:"value"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MarshalJSON implements Marshaler interface.
+func (p StackParam) MarshalJSON() ([]byte, error) {
+	var (
+		value interface{}
+		err   error
+		r     = rawStackParam{Type: p.Type}
+	)
+
+	switch p.Type {
+	case ByteArray, Signature, PublicKey:
+		b, ok := p.Value.([]byte)
+		if !ok {
+			return nil, errors.Errorf("failed to cast %s to []byte", p.Value)
+		}
+		value = hex.EncodeToString(b)
+	case Integer:
+		switch i := p.Value.(type) {
+		case int64:
+			value = strconv.FormatInt(i, 10)
+		case *big.Int:
+			value = i.String()
+		default:
+			return nil, errors.Errorf("failed to cast %s to int64", p.Value)
+		}
+	case Hash160:
+		h, ok := p.Value.(util.Uint160)
+		if !ok {
+			return nil, errors.Errorf("failed to cast %s to Uint160", p.Value)
+		}
+		// Hash160 is a BE string just like Hash256.
+		value = "0x" + h.ReverseString()
+	case InteropInterface, Void:
+		return json.Marshal(r)
+	default:
+		value = p.Value
+	}
+	if r.Value, err = json.Marshal(value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r)
 }
 
 // UnmarshalJSON implements Unmarshaler interface.
@@ -128,7 +190,13 @@ func (p *StackParam) UnmarshalJSON(data []byte) (err error) {
 	}
 
 	switch p.Type = r.Type; r.Type {
-	case ByteArray:
+	case Boolean:
+		var v bool
+		if err = json.Unmarshal(r.Value, &v); err != nil {
+			return
+		}
+		p.Value = v
+	case ByteArray, Signature, PublicKey:
 		if err = json.Unmarshal(r.Value, &s); err != nil {
 			return
 		}
@@ -150,10 +218,17 @@ func (p *StackParam) UnmarshalJSON(data []byte) (err error) {
 		if err = json.Unmarshal(r.Value, &s); err != nil {
 			return
 		}
-		if i, err = strconv.ParseInt(s, 10, 64); err != nil {
+		if i, err = strconv.ParseInt(s, 10, 64); err == nil {
+			p.Value = i
+			return
+		}
+		// Integers not fitting into int64 are kept as big.Int.
+		bi, ok := new(big.Int).SetString(s, 10)
+		if !ok {
 			return
 		}
-		p.Value = i
+		err = nil
+		p.Value = bi
 	case Array:
 		// https://github.com/neo-project/neo/blob/3d59ecca5a8deb057bdad94b3028a6d5e25ac088/neo/Network/RPC/RpcServer.cs#L67
 		var rs []StackParam
@@ -161,9 +236,20 @@ func (p *StackParam) UnmarshalJSON(data []byte) (err error) {
 			return
 		}
 		p.Value = rs
+	case Map:
+		var rs []StackParamMapElement
+		if err = json.Unmarshal(r.Value, &rs); err != nil {
+			return
+		}
+		p.Value = rs
+	case InteropInterface, Void:
+		p.Value = nil
 	case Hash160:
 		var h util.Uint160
-		if err = json.Unmarshal(r.Value, &h); err != nil {
+		if err = json.Unmarshal(r.Value, &s); err != nil {
+			return
+		}
+		if h, err = util.Uint160DecodeReverseString(strings.TrimPrefix(s, "0x")); err != nil {
 			return
 		}
 		p.Value = h
@@ -179,6 +265,22 @@ func (p *StackParam) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+// stackParamsFromParameters converts smart contract parameters into
+// StackParams, so that they're encoded the same way the server decodes them.
+func stackParamsFromParameters(params []smartcontract.Parameter) []StackParam {
+	res := make([]StackParam, len(params))
+	for i, p := range params {
+		res[i] = StackParam{Type: StackParamType(p.Type), Value: p.Value}
+		switch v := p.Value.(type) {
+		case int:
+			res[i].Value = int64(v)
+		case []smartcontract.Parameter:
+			res[i].Value = stackParamsFromParameters(v)
+		}
+	}
+	return res
+}
+
 // StackParams is an array of StackParam (TODO: drop it?).
 type StackParams []StackParam
 
@@ -267,3 +369,38 @@ func bytesToUint64(b []byte) uint64 {
 	copy(data[8-len(b):], util.ArrayReverse(b))
 	return binary.BigEndian.Uint64(data)
 }
+
+// stackParamFromStackItem converts VM stack item into StackParam.
+func stackParamFromStackItem(item vm.StackItem) StackParam {
+	switch t := item.(type) {
+	case *vm.ByteArrayItem:
+		return StackParam{Type: ByteArray, Value: t.Value().([]byte)}
+	case *vm.BigIntegerItem:
+		i := t.Value().(*big.Int)
+		if !i.IsInt64() {
+			return StackParam{Type: Integer, Value: new(big.Int).Set(i)}
+		}
+		return StackParam{Type: Integer, Value: i.Int64()}
+	case *vm.BoolItem:
+		return StackParam{Type: Boolean, Value: t.Value().(bool)}
+	case *vm.ArrayItem, *vm.StructItem:
+		items := t.Value().([]vm.StackItem)
+		params := make([]StackParam, 0, len(items))
+		for _, it := range items {
+			params = append(params, stackParamFromStackItem(it))
+		}
+		return StackParam{Type: Array, Value: params}
+	case *vm.MapItem:
+		m := t.Value().(map[interface{}]vm.StackItem)
+		elems := make([]StackParamMapElement, 0, len(m))
+		for k, v := range m {
+			elems = append(elems, StackParamMapElement{
+				Key:   stackParamFromStackItem(vm.NewElement(k).Item()),
+				Value: stackParamFromStackItem(v),
+			})
+		}
+		return StackParam{Type: Map, Value: elems}
+	default:
+		return StackParam{Type: InteropInterface}
+	}
+}
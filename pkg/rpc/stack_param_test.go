@@ -3,10 +3,13 @@ package rpc
 import (
 	"encoding/hex"
 	"encoding/json"
+	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+	"github.com/stretchr/testify/require"
 )
 
 var testCases = []struct {
@@ -41,6 +44,26 @@ var testCases = []struct {
 			},
 		},
 	},
+	{
+		input:  `{"type":"Boolean","value":true}`,
+		result: StackParam{Type: Boolean, Value: true},
+	},
+	{
+		input:  `{"type":"InteropInterface"}`,
+		result: StackParam{Type: InteropInterface},
+	},
+	{
+		input: `{"type":"Map","value":[{"key":{"type":"String","value":"k"},"value":{"type":"Integer","value":"1"}}]}`,
+		result: StackParam{
+			Type: Map,
+			Value: []StackParamMapElement{
+				{
+					Key:   StackParam{Type: String, Value: "k"},
+					Value: StackParam{Type: Integer, Value: int64(1)},
+				},
+			},
+		},
+	},
 }
 
 var errorCases = []string{
@@ -55,6 +78,8 @@ var errorCases = []string{
 	`{"type": "Hash160","value": "0bcd"}`,  // incorrect Uint160 value
 	`{"type": "Hash256","value": "0bcd"}`,  // incorrect Uint256 value
 	`{"type": "Stringg","value": ""}`,      // incorrect type
+	`{"type": "Boolean","value": "true"}`,  // incorrect Boolean value
+	`{"type": "Map","value": 1}`,           // incorrect Map value
 }
 
 func TestStackParam_UnmarshalJSON(t *testing.T) {
@@ -76,7 +101,7 @@ func TestStackParam_UnmarshalJSON(t *testing.T) {
 		t.Errorf("error while unmarhsalling: %v", err)
 	}
 
-	h160, err := util.Uint160DecodeString("0bcd2978634d961c24f5aea0802297ff128724d6")
+	h160, err := util.Uint160DecodeReverseString("0bcd2978634d961c24f5aea0802297ff128724d6")
 	if err != nil {
 		t.Errorf("unmarshal error: %v", err)
 	}
@@ -105,6 +130,69 @@ func TestStackParam_UnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestStackParam_MarshalJSON(t *testing.T) {
+	for _, tc := range testCases {
+		data, err := json.Marshal(tc.result)
+		require.NoError(t, err)
+
+		var actual StackParam
+		require.NoError(t, json.Unmarshal(data, &actual))
+		require.Equal(t, tc.result, actual)
+	}
+
+	data, err := json.Marshal(StackParam{Type: Integer, Value: int64(42)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"Integer","value":"42"}`, string(data))
+
+	h160, err := util.Uint160DecodeReverseString("0bcd2978634d961c24f5aea0802297ff128724d6")
+	require.NoError(t, err)
+	data, err = json.Marshal(StackParam{Type: Hash160, Value: h160})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"Hash160","value":"0x0bcd2978634d961c24f5aea0802297ff128724d6"}`, string(data))
+
+	_, err = json.Marshal(StackParam{Type: ByteArray, Value: 42})
+	require.Error(t, err)
+}
+
+func TestStackParamFromStackItem(t *testing.T) {
+	m := vm.NewMapItem()
+	m.Add(vm.NewBoolItem(true), vm.NewByteArrayItem([]byte{1, 2}))
+
+	item := vm.NewArrayItem([]vm.StackItem{
+		vm.NewBigIntegerItem(-5),
+		vm.NewStructItem([]vm.StackItem{vm.NewBoolItem(false)}),
+		m,
+		vm.NewInteropItem(nil),
+	})
+	expected := StackParam{
+		Type: Array,
+		Value: []StackParam{
+			{Type: Integer, Value: int64(-5)},
+			{Type: Array, Value: []StackParam{{Type: Boolean, Value: false}}},
+			{Type: Map, Value: []StackParamMapElement{{
+				Key:   StackParam{Type: Boolean, Value: true},
+				Value: StackParam{Type: ByteArray, Value: []byte{1, 2}},
+			}}},
+			{Type: InteropInterface},
+		},
+	}
+	require.Equal(t, expected, stackParamFromStackItem(item))
+
+	bigItem := vm.NewBigIntegerItem(1)
+	bigValue := bigItem.Value().(*big.Int)
+	bigValue.Lsh(bigValue, 100)
+	param := stackParamFromStackItem(bigItem)
+	require.Equal(t, StackParam{Type: Integer, Value: bigValue}, param)
+
+	data, err := json.Marshal(param)
+	require.NoError(t, err)
+	require.Equal(t, `{"type":"Integer","value":"1267650600228229401496703205376"}`, string(data))
+
+	var actual StackParam
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.Equal(t, param, actual)
+}
+
 const (
 	hash160    = "0bcd2978634d961c24f5aea0802297ff128724d6"
 	hash256    = "7fe610b7c8259ae949accacb091a1bc53219c51a1cb8752fbc6457674c13ec0b"
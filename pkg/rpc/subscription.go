@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+)
+
+// EventID is an event identifier used both for subscriptions and for the
+// notifications sent to subscribers.
+type EventID byte
+
+const (
+	// InvalidEventID is an invalid event id that is the default value of
+	// EventID. It's only used as an initial value similar to nil.
+	InvalidEventID EventID = iota
+	// BlockEventID is a `block_added` event.
+	BlockEventID
+	// TransactionEventID corresponds to `transaction_added` event.
+	TransactionEventID
+	// NotificationEventID represents `notification_from_execution` events.
+	NotificationEventID
+	// ExecutionEventID is used for `transaction_executed` events.
+	ExecutionEventID
+	// MissedEventID notifies user of missed events.
+	MissedEventID EventID = 255
+)
+
+// String is a good old Stringer implementation.
+func (e EventID) String() string {
+	switch e {
+	case BlockEventID:
+		return "block_added"
+	case TransactionEventID:
+		return "transaction_added"
+	case NotificationEventID:
+		return "notification_from_execution"
+	case ExecutionEventID:
+		return "transaction_executed"
+	case MissedEventID:
+		return "event_missed"
+	default:
+		return "unknown"
+	}
+}
+
+// eventIDFromString converts the given string into an EventID if it's a
+// valid subscription event name.
+func eventIDFromString(s string) (EventID, error) {
+	for _, e := range []EventID{BlockEventID, TransactionEventID, NotificationEventID, ExecutionEventID} {
+		if s == e.String() {
+			return e, nil
+		}
+	}
+	return InvalidEventID, fmt.Errorf("unknown event: %s", s)
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (e EventID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+type (
+	// TxFilter is a filter for the transaction_added events, it matches
+	// transactions witnessed by the given sender (any of the transaction's
+	// verification scripts has the sender's script hash).
+	TxFilter struct {
+		Sender util.Uint160
+	}
+
+	// NotificationFilter is a filter for the notification_from_execution
+	// events, both the contract hash and the notification name (the first
+	// element of the notification array) are optional.
+	NotificationFilter struct {
+		Contract *util.Uint160
+		Name     *string
+	}
+
+	// ExecutionFilter is a filter for the transaction_executed events, it
+	// matches executions with the given VM state ("HALT" or "FAULT").
+	ExecutionFilter struct {
+		State string
+	}
+
+	// subscriber is a single WebSocket client with its subscriptions.
+	subscriber struct {
+		writer    chan<- *websocket.PreparedMessage
+		overflown bool
+		// Subscription IDs are indexes in this array, unused slots have
+		// InvalidEventID.
+		feeds [maxFeeds]feed
+	}
+
+	// feed is a single subscription of the subscriber.
+	feed struct {
+		event  EventID
+		filter interface{}
+	}
+
+	// Notification is a message sent to the subscriber when the event it's
+	// subscribed to happens.
+	Notification struct {
+		JSONRPC string        `json:"jsonrpc"`
+		Event   EventID       `json:"method"`
+		Payload []interface{} `json:"params"`
+	}
+
+	// NotificationEvent is a payload of the notification_from_execution
+	// event, a single notification along with the transaction that
+	// emitted it.
+	NotificationEvent struct {
+		TxHash util.Uint256 `json:"txid"`
+		NotificationState
+	}
+)
+
+const (
+	// maxFeeds is the maximum number of subscriptions a single client can
+	// have.
+	maxFeeds = 16
+	// maxSubscribers is the maximum number of simultaneous WebSocket
+	// clients.
+	maxSubscribers = 64
+	// notificationBufSize is the number of notifications that can be
+	// queued for a single client, if it doesn't read them fast enough
+	// further notifications are dropped and event_missed is sent.
+	notificationBufSize = 256
+)
+
+// newFilter parses the filter object for the given event from the
+// subscription request params.
+func newFilter(event EventID, param *Param) (interface{}, error) {
+	if param == nil {
+		return nil, nil
+	}
+	fields, ok := param.RawValue.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidParams
+	}
+	str := func(name string) (*string, error) {
+		v, ok := fields[name]
+		if !ok {
+			return nil, nil
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, errInvalidParams
+		}
+		return &s, nil
+	}
+	switch event {
+	case TransactionEventID:
+		sender, err := str("sender")
+		if err != nil || sender == nil {
+			return nil, errInvalidParams
+		}
+		u, err := crypto.Uint160DecodeAddress(*sender)
+		if err != nil {
+			return nil, errInvalidParams
+		}
+		return TxFilter{Sender: u}, nil
+	case NotificationEventID:
+		var flt NotificationFilter
+		contract, err := str("contract")
+		if err != nil {
+			return nil, err
+		}
+		if contract != nil {
+			u, err := util.Uint160DecodeReverseString(strings.TrimPrefix(*contract, "0x"))
+			if err != nil {
+				return nil, errInvalidParams
+			}
+			flt.Contract = &u
+		}
+		if flt.Name, err = str("name"); err != nil {
+			return nil, err
+		}
+		return flt, nil
+	case ExecutionEventID:
+		state, err := str("state")
+		if err != nil || state == nil || (*state != "HALT" && *state != "FAULT") {
+			return nil, errInvalidParams
+		}
+		return ExecutionFilter{State: *state}, nil
+	default:
+		return nil, errInvalidParams
+	}
+}
+
+// subscribedTo checks whether the subscriber has a feed matching the given
+// event.
+func (s *subscriber) subscribedTo(event EventID, item interface{}) bool {
+	for i := range s.feeds {
+		if s.feeds[i].matches(event, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches checks whether the given event passes the filter of the feed.
+func (f *feed) matches(event EventID, item interface{}) bool {
+	if f.event != event {
+		return false
+	}
+	if f.filter == nil {
+		return true
+	}
+	switch filt := f.filter.(type) {
+	case TxFilter:
+		tx := item.(*transaction.Transaction)
+		for _, w := range tx.Scripts {
+			if w.ScriptHash().Equals(filt.Sender) {
+				return true
+			}
+		}
+		return false
+	case NotificationFilter:
+		ne := item.(*core.NotificationEvent)
+		if filt.Contract != nil && !ne.ScriptHash.Equals(*filt.Contract) {
+			return false
+		}
+		return filt.Name == nil || notificationName(ne.Item) == *filt.Name
+	case ExecutionFilter:
+		return item.(*core.AppExecResult).VMState == filt.State
+	}
+	return false
+}
+
+// notificationName returns the name of the notification which is the
+// first element of the notification array (if it's an array).
+func notificationName(item vm.StackItem) string {
+	var elems []vm.StackItem
+	switch t := item.(type) {
+	case *vm.ArrayItem:
+		elems = t.Value().([]vm.StackItem)
+	case *vm.StructItem:
+		elems = t.Value().([]vm.StackItem)
+	}
+	if len(elems) == 0 {
+		return ""
+	}
+	name, ok := elems[0].Value().([]byte)
+	if !ok {
+		return ""
+	}
+	return string(name)
+}
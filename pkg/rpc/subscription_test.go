@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wsMessage is a response or a notification received via WebSocket.
+type wsMessage struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  *Error            `json:"error"`
+}
+
+func readWSMessage(t *testing.T, ws *websocket.Conn) wsMessage {
+	var msg wsMessage
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.ReadJSON(&msg))
+	return msg
+}
+
+func callWS(t *testing.T, ws *websocket.Conn, method string, params string) wsMessage {
+	req := `{"jsonrpc": "2.0", "id": 1, "method": "` + method + `", "params": ` + params + `}`
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(req)))
+	return readWSMessage(t, ws)
+}
+
+func TestSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	chain, rpcSrv, handler := initServerWithInMemoryChain(ctx, t)
+	go rpcSrv.handleSubEvents()
+	defer func() { _ = rpcSrv.Shutdown() }()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + wsPath
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	t.Run("regular method", func(t *testing.T) {
+		msg := callWS(t, ws, "getblockcount", `[]`)
+		require.Nil(t, msg.Error)
+		var count uint32
+		require.NoError(t, json.Unmarshal(msg.Result, &count))
+		assert.Equal(t, chain.BlockHeight()+1, count)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, params := range []string{
+			`["unknown_event"]`,
+			`[1]`,
+			`["block_added", {"sender": "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU"}]`,
+			`["transaction_added", {"sender": "notanaddress"}]`,
+			`["transaction_executed", {"state": "UNKNOWN"}]`,
+			`["notification_from_execution", {"contract": "notahash"}]`,
+		} {
+			msg := callWS(t, ws, "subscribe", params)
+			assert.NotNil(t, msg.Error, params)
+		}
+		msg := callWS(t, ws, "unsubscribe", `["0"]`)
+		assert.NotNil(t, msg.Error)
+	})
+
+	t.Run("block_added", func(t *testing.T) {
+		msg := callWS(t, ws, "subscribe", `["block_added"]`)
+		require.Nil(t, msg.Error)
+		var id string
+		require.NoError(t, json.Unmarshal(msg.Result, &id))
+
+		b := newTestBlock(t, chain)
+		require.NoError(t, chain.AddBlock(b))
+		msg = readWSMessage(t, ws)
+		assert.Equal(t, "block_added", msg.Method)
+		require.Equal(t, 1, len(msg.Params))
+		var blk struct {
+			Hash  util.Uint256 `json:"hash"`
+			Index uint32       `json:"index"`
+		}
+		require.NoError(t, json.Unmarshal(msg.Params[0], &blk))
+		assert.Equal(t, b.Hash(), blk.Hash)
+		assert.Equal(t, b.Index, blk.Index)
+
+		msg = callWS(t, ws, "unsubscribe", `["`+id+`"]`)
+		require.Nil(t, msg.Error)
+		var ok bool
+		require.NoError(t, json.Unmarshal(msg.Result, &ok))
+		assert.True(t, ok)
+		msg = callWS(t, ws, "unsubscribe", `["`+id+`"]`)
+		assert.NotNil(t, msg.Error)
+	})
+}
+
+func TestFeedFilters(t *testing.T) {
+	witness := &transaction.Witness{VerificationScript: []byte{1, 2, 3}}
+	tx := transaction.NewInvocationTX([]byte{byte(vm.RET)})
+	tx.Scripts = []*transaction.Witness{witness}
+
+	contract := util.Uint160{1, 2, 3}
+	transfer := "transfer"
+	other := "other"
+	ne := &core.NotificationEvent{
+		ScriptHash: contract,
+		Item:       vm.NewArrayItem([]vm.StackItem{vm.NewByteArrayItem([]byte(transfer))}),
+	}
+	aer := &core.AppExecResult{VMState: "HALT"}
+
+	testCases := []struct {
+		feed    feed
+		event   EventID
+		item    interface{}
+		matches bool
+	}{
+		{feed{event: BlockEventID}, BlockEventID, &core.Block{}, true},
+		{feed{event: BlockEventID}, TransactionEventID, tx, false},
+		{feed{event: TransactionEventID, filter: TxFilter{Sender: witness.ScriptHash()}}, TransactionEventID, tx, true},
+		{feed{event: TransactionEventID, filter: TxFilter{Sender: util.Uint160{}}}, TransactionEventID, tx, false},
+		{feed{event: NotificationEventID, filter: NotificationFilter{Contract: &contract}}, NotificationEventID, ne, true},
+		{feed{event: NotificationEventID, filter: NotificationFilter{Contract: &util.Uint160{}}}, NotificationEventID, ne, false},
+		{feed{event: NotificationEventID, filter: NotificationFilter{Name: &transfer}}, NotificationEventID, ne, true},
+		{feed{event: NotificationEventID, filter: NotificationFilter{Contract: &contract, Name: &other}}, NotificationEventID, ne, false},
+		{feed{event: ExecutionEventID, filter: ExecutionFilter{State: "HALT"}}, ExecutionEventID, aer, true},
+		{feed{event: ExecutionEventID, filter: ExecutionFilter{State: "FAULT"}}, ExecutionEventID, aer, false},
+	}
+	for i, tc := range testCases {
+		assert.Equal(t, tc.matches, tc.feed.matches(tc.event, tc.item), "case %d", i)
+	}
+}
@@ -1,11 +1,14 @@
 package rpc
 
 import (
+	"bytes"
+
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
 	"github.com/infinitete/neo-go-inf/pkg/crypto"
 	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
 	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/infinitete/neo-go-inf/pkg/vm"
 	errs "github.com/pkg/errors"
 )
 
@@ -84,3 +87,108 @@ func GetInvocationScript(tx *transaction.Transaction, wif keys.WIF) ([]byte, err
 	}
 	return append([]byte{pushbytes64}, signature...), nil
 }
+
+// CreateFunctionInvocationScript creates a script to invoke the given method
+// of the given contract with the given parameters, parameters are packed into
+// an array (which is empty if there are no parameters).
+func CreateFunctionInvocationScript(contract util.Uint160, method string, params []StackParam) ([]byte, error) {
+	script := new(bytes.Buffer)
+	if err := expandArrayIntoScript(script, params); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitInt(script, int64(len(params))); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitOpcode(script, vm.PACK); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitString(script, method); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitAppCall(script, contract, false); err != nil {
+		return nil, err
+	}
+	return script.Bytes(), nil
+}
+
+// CreateInvocationScript creates a script to invoke the given contract with
+// the given parameters pushed onto the stack as is.
+func CreateInvocationScript(contract util.Uint160, params []StackParam) ([]byte, error) {
+	script := new(bytes.Buffer)
+	if err := expandArrayIntoScript(script, params); err != nil {
+		return nil, err
+	}
+	if err := vm.EmitAppCall(script, contract, false); err != nil {
+		return nil, err
+	}
+	return script.Bytes(), nil
+}
+
+// expandArrayIntoScript pushes all parameters from the given slice onto the
+// stack in reverse order, so that the first one ends up on the top.
+func expandArrayIntoScript(script *bytes.Buffer, slice []StackParam) error {
+	for i := len(slice) - 1; i >= 0; i-- {
+		if err := emitStackParam(script, slice[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitStackParam emits the code pushing the given parameter onto the stack.
+func emitStackParam(script *bytes.Buffer, p StackParam) error {
+	var err error
+	switch p.Type {
+	case ByteArray, Signature, PublicKey:
+		b, ok := p.Value.([]byte)
+		if !ok {
+			return errs.Errorf("failed to cast %v to []byte", p.Value)
+		}
+		err = vm.EmitBytes(script, b)
+	case String:
+		str, ok := p.Value.(string)
+		if !ok {
+			return errs.Errorf("failed to cast %v to string", p.Value)
+		}
+		err = vm.EmitString(script, str)
+	case Hash160:
+		hash, ok := p.Value.(util.Uint160)
+		if !ok {
+			return errs.Errorf("failed to cast %v to Uint160", p.Value)
+		}
+		err = vm.EmitBytes(script, hash.Bytes())
+	case Hash256:
+		hash, ok := p.Value.(util.Uint256)
+		if !ok {
+			return errs.Errorf("failed to cast %v to Uint256", p.Value)
+		}
+		err = vm.EmitBytes(script, hash.Bytes())
+	case Integer:
+		i, ok := p.Value.(int64)
+		if !ok {
+			return errs.Errorf("failed to cast %v to int64", p.Value)
+		}
+		err = vm.EmitInt(script, i)
+	case Boolean:
+		b, ok := p.Value.(bool)
+		if !ok {
+			return errs.Errorf("failed to cast %v to bool", p.Value)
+		}
+		err = vm.EmitBool(script, b)
+	case Array:
+		arr, ok := p.Value.([]StackParam)
+		if !ok {
+			return errs.Errorf("failed to cast %v to []StackParam", p.Value)
+		}
+		if err = expandArrayIntoScript(script, arr); err != nil {
+			return err
+		}
+		if err = vm.EmitInt(script, int64(len(arr))); err != nil {
+			return err
+		}
+		err = vm.EmitOpcode(script, vm.PACK)
+	default:
+		return errs.Errorf("parameter type %s is not supported", p.Type)
+	}
+	return err
+}
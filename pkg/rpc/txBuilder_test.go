@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFunctionInvocationScript(t *testing.T) {
+	contract := util.Uint160{1, 2, 3}
+	hashHex := hex.EncodeToString(contract.Bytes())
+
+	t.Run("no params", func(t *testing.T) {
+		script, err := CreateFunctionInvocationScript(contract, "test", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "00c1"+"0474657374"+"67"+hashHex, hex.EncodeToString(script))
+	})
+
+	t.Run("params", func(t *testing.T) {
+		script, err := CreateFunctionInvocationScript(contract, "test", []StackParam{
+			{Type: String, Value: "a"},
+			{Type: Integer, Value: int64(5)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "55"+"0161"+"52c1"+"0474657374"+"67"+hashHex, hex.EncodeToString(script))
+	})
+
+	t.Run("nested array", func(t *testing.T) {
+		script, err := CreateFunctionInvocationScript(contract, "test", []StackParam{
+			{Type: Array, Value: []StackParam{{Type: Boolean, Value: true}}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "51"+"51c1"+"51c1"+"0474657374"+"67"+hashHex, hex.EncodeToString(script))
+	})
+
+	t.Run("bad value", func(t *testing.T) {
+		_, err := CreateFunctionInvocationScript(contract, "test", []StackParam{{Type: Integer, Value: "5"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateInvocationScript(t *testing.T) {
+	contract := util.Uint160{1, 2, 3}
+	param := util.Uint160{4, 5, 6}
+
+	script, err := CreateInvocationScript(contract, []StackParam{
+		{Type: Boolean, Value: true},
+		{Type: Hash160, Value: param},
+	})
+	require.NoError(t, err)
+	expected := "14" + hex.EncodeToString(param.Bytes()) + "51" + "67" + hex.EncodeToString(contract.Bytes())
+	assert.Equal(t, expected, hex.EncodeToString(script))
+
+	_, err = CreateInvocationScript(contract, []StackParam{{Type: Map}})
+	assert.Error(t, err)
+}
@@ -1,7 +1,12 @@
 package rpc
 
 import (
+	"encoding/json"
+
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/result"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/wrappers"
+	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/infinitete/neo-go-inf/pkg/vm"
 )
 
@@ -21,6 +26,69 @@ type InvokeResult struct {
 	Stack       []StackParam
 }
 
+// GetApplicationLogResponse stores response for the getapplicationlog call.
+type GetApplicationLogResponse struct {
+	responseHeader
+	Error  *Error          `json:"error,omitempty"`
+	Result *ApplicationLog `json:"result,omitempty"`
+}
+
+// GetBlockSysFeeResponse holds the getblocksysfee response.
+type GetBlockSysFeeResponse struct {
+	responseHeader
+	Error  *Error      `json:"error,omitempty"`
+	Result util.Fixed8 `json:"result"`
+}
+
+// GetContractStateResponse holds the getcontractstate response.
+type GetContractStateResponse struct {
+	responseHeader
+	Error  *Error                  `json:"error,omitempty"`
+	Result *wrappers.ContractState `json:"result,omitempty"`
+}
+
+// GetStorageResponse holds the getstorage response.
+type GetStorageResponse struct {
+	responseHeader
+	Error  *Error  `json:"error,omitempty"`
+	Result *string `json:"result"`
+}
+
+// FindStatesResponse holds the findstates response.
+type FindStatesResponse struct {
+	responseHeader
+	Error  *Error             `json:"error,omitempty"`
+	Result *result.FindStates `json:"result,omitempty"`
+}
+
+// GetClaimableResponse holds the getclaimable response.
+type GetClaimableResponse struct {
+	responseHeader
+	Error  *Error                `json:"error,omitempty"`
+	Result *result.ClaimableInfo `json:"result,omitempty"`
+}
+
+// GetUnclaimedResponse holds the getunclaimed response.
+type GetUnclaimedResponse struct {
+	responseHeader
+	Error  *Error            `json:"error,omitempty"`
+	Result *result.Unclaimed `json:"result,omitempty"`
+}
+
+// GetUnspentsResponse holds the getunspents response.
+type GetUnspentsResponse struct {
+	responseHeader
+	Error  *Error           `json:"error,omitempty"`
+	Result *result.Unspents `json:"result,omitempty"`
+}
+
+// GetValidatorsResponse holds the getvalidators response.
+type GetValidatorsResponse struct {
+	responseHeader
+	Error  *Error             `json:"error,omitempty"`
+	Result []result.Validator `json:"result"`
+}
+
 // AccountStateResponse holds the getaccountstate response.
 type AccountStateResponse struct {
 	responseHeader
@@ -74,6 +142,14 @@ type response struct {
 	Result interface{} `json:"result"`
 }
 
+// rawResponse is a response with the result left undecoded, it's used by
+// the typed client methods.
+type rawResponse struct {
+	responseHeader
+	Error  *Error          `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
 // SendToAddressResponse stores response for the sendtoaddress call.
 type SendToAddressResponse struct {
 	responseHeader
@@ -82,6 +158,8 @@ type SendToAddressResponse struct {
 }
 
 // GetRawTxResponse represents verbose output of `getrawtransaction` RPC call.
+//
+// Deprecated: use GetRawTransactionVerbose that returns RawTxResponse.
 type GetRawTxResponse struct {
 	responseHeader
 	Error  *Error         `json:"error"`
@@ -89,18 +167,18 @@ type GetRawTxResponse struct {
 }
 
 // RawTxResponse stores transaction with blockchain metadata to be sent as a response.
-type RawTxResponse struct {
-	TxResponse
-	BlockHash     string `json:"blockhash"`
-	Confirmations uint   `json:"confirmations"`
-	BlockTime     uint   `json:"blocktime"`
-}
+//
+// Deprecated: use wrappers.TransactionOutputRaw.
+type RawTxResponse = wrappers.TransactionOutputRaw
 
-// TxResponse stores transaction to be sent as a response.
+// TxResponse stores transaction to be sent as a response. Fees are not
+// known to the client, so they're not filled by SendToAddress.
 type TxResponse struct {
-	TxID       string                  `json:"txid"`
+	TxID string `json:"txid"`
+	// Deprecated: the fields below are the same as in wrappers.Transaction
+	// returned by GetRawTransactionVerbose, use it instead.
 	Size       int                     `json:"size"`
-	Type       string                  `json:"type"` // todo: convert to TransactionType
+	Type       string                  `json:"type"`
 	Version    int                     `json:"version"`
 	Attributes []transaction.Attribute `json:"attributes"`
 	Vins       []Vin                   `json:"vin"`
@@ -111,15 +189,11 @@ type TxResponse struct {
 }
 
 // Vin represents JSON-serializable tx input.
-type Vin struct {
-	TxID string `json:"txid"`
-	Vout int    `json:"vout"`
-}
+//
+// Deprecated: use transaction.Input.
+type Vin = transaction.Input
 
 // Vout represents JSON-serializable tx output.
-type Vout struct {
-	N       int    `json:"n"`
-	Asset   string `json:"asset"`
-	Value   int    `json:"value"`
-	Address string `json:"address"`
-}
+//
+// Deprecated: use transaction.Output.
+type Vout = transaction.Output
@@ -0,0 +1,422 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
+	"github.com/infinitete/neo-go-inf/pkg/rpc/wrappers"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// wsPath is the HTTP path of the WebSocket endpoint.
+	wsPath = "/ws"
+	// wsPongLimit is the time to wait for the pong (or any other message)
+	// from the client before closing the connection.
+	wsPongLimit = 60 * time.Second
+	// wsPingPeriod is the period of pinging the client, it's less than
+	// wsPongLimit to give the client some time to answer.
+	wsPingPeriod = wsPongLimit * 9 / 10
+	// wsWriteLimit is the deadline for a single write to the client.
+	wsWriteLimit = wsPingPeriod / 2
+)
+
+// handleWsRequest upgrades the given HTTP request to a WebSocket connection
+// and serves it until it's closed.
+func (s *Server) handleWsRequest(w http.ResponseWriter, httpRequest *http.Request) {
+	s.subsLock.RLock()
+	numOfSubs := len(s.subscribers)
+	s.subsLock.RUnlock()
+	if numOfSubs >= maxSubscribers {
+		req := NewRequest(s.config.EnableCORSWorkaround)
+		req.WriteErrorResponse(w, NewInternalServerError("websocket clients limit reached", nil))
+		return
+	}
+	ws, err := s.upgrader.Upgrade(w, httpRequest, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err": err,
+		}).Info("websocket connection upgrade failed")
+		return
+	}
+	resChan := make(chan Response)
+	subChan := make(chan *websocket.PreparedMessage, notificationBufSize)
+	subscr := &subscriber{writer: subChan}
+	s.subsLock.Lock()
+	s.subscribers[subscr] = true
+	s.subsLock.Unlock()
+	go s.handleWsWrites(ws, resChan, subChan)
+	s.handleWsReads(ws, clientAddress(httpRequest), resChan, subscr)
+}
+
+// handleWsWrites is a goroutine that writes responses, notifications and
+// pings to the WebSocket client.
+func (s *Server) handleWsWrites(ws *websocket.Conn, resChan <-chan Response, subChan <-chan *websocket.PreparedMessage) {
+	pingTicker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		pingTicker.Stop()
+		ws.Close()
+		// The reader will fail on the closed connection, but it might
+		// be sending a response at the moment.
+		for range resChan {
+		}
+	}()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case msg := <-subChan:
+			if err := ws.SetWriteDeadline(time.Now().Add(wsWriteLimit)); err != nil {
+				return
+			}
+			if err := ws.WritePreparedMessage(msg); err != nil {
+				return
+			}
+		case res, ok := <-resChan:
+			if !ok {
+				return
+			}
+			if err := ws.SetWriteDeadline(time.Now().Add(wsWriteLimit)); err != nil {
+				return
+			}
+			if err := ws.WriteJSON(res); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := ws.SetWriteDeadline(time.Now().Add(wsWriteLimit)); err != nil {
+				return
+			}
+			if err := ws.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleWsReads reads requests from the WebSocket client and passes
+// responses to the writer, it cleans up client's subscriptions when the
+// connection is closed.
+func (s *Server) handleWsReads(ws *websocket.Conn, client string, resChan chan<- Response, subscr *subscriber) {
+	ws.SetReadLimit(s.maxRequestBodyBytes())
+	_ = ws.SetReadDeadline(time.Now().Add(wsPongLimit))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(wsPongLimit))
+	})
+requestloop:
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		_ = ws.SetReadDeadline(time.Now().Add(wsPongLimit))
+		var res *Response
+		if s.limiter != nil && !s.limiter.allow(client) {
+			r := NewRequest(false).errorResponse(NewTooManyRequestsError("Rate limit exceeded", nil))
+			res = &r
+		} else {
+			res = s.handleWsMessage(data, subscr)
+		}
+		if res == nil {
+			continue
+		}
+		select {
+		case <-s.shutdown:
+			break requestloop
+		case resChan <- *res:
+		}
+	}
+
+	s.subsLock.Lock()
+	delete(s.subscribers, subscr)
+	s.subsLock.Unlock()
+	s.subsCounterLock.Lock()
+	for _, f := range subscr.feeds {
+		if f.event != InvalidEventID {
+			s.unsubscribeFromChannel(f.event)
+		}
+	}
+	s.subsCounterLock.Unlock()
+	close(resChan)
+	ws.Close()
+}
+
+// handleWsMessage processes a single request received via WebSocket, nil
+// is returned for notifications as they're not answered.
+func (s *Server) handleWsMessage(data []byte, subscr *subscriber) *Response {
+	req := NewRequest(false)
+	if err := json.Unmarshal(data, req); err != nil {
+		res := req.errorResponse(NewParseError("Problem parsing JSON-RPC request body", err))
+		return &res
+	}
+	res := s.handleRequest(req, subscr)
+	if req.isNotification() {
+		return nil
+	}
+	return &res
+}
+
+// subscribe handles subscription requests from websocket clients, the
+// first parameter is the event name and the second (optional) one is the
+// filter object. Subscription ID is returned.
+func (s *Server) subscribe(reqParams Params, sub *subscriber) (interface{}, error) {
+	p, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	event, err := eventIDFromString(p.StringVal)
+	if err != nil {
+		return nil, errInvalidParams
+	}
+	var filter interface{}
+	if p, ok := reqParams.ValueAt(1); ok {
+		filter, err = newFilter(event, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.subsLock.Lock()
+	var id int
+	for ; id < len(sub.feeds); id++ {
+		if sub.feeds[id].event == InvalidEventID {
+			break
+		}
+	}
+	if id == len(sub.feeds) {
+		s.subsLock.Unlock()
+		return nil, NewInternalServerError("maximum number of subscriptions is reached", nil)
+	}
+	sub.feeds[id].event = event
+	sub.feeds[id].filter = filter
+	s.subsLock.Unlock()
+
+	s.subsCounterLock.Lock()
+	s.subscribeToChannel(event)
+	s.subsCounterLock.Unlock()
+	return strconv.Itoa(id), nil
+}
+
+// unsubscribe handles unsubscription requests from websocket clients, the
+// only parameter is the subscription ID.
+func (s *Server) unsubscribe(reqParams Params, sub *subscriber) (interface{}, error) {
+	p, err := reqParams.ValueWithType(0, "string")
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(p.StringVal)
+	if err != nil || id < 0 || id >= len(sub.feeds) {
+		return nil, errInvalidParams
+	}
+
+	s.subsLock.Lock()
+	event := sub.feeds[id].event
+	if event == InvalidEventID {
+		s.subsLock.Unlock()
+		return nil, errInvalidParams
+	}
+	sub.feeds[id].event = InvalidEventID
+	sub.feeds[id].filter = nil
+	s.subsLock.Unlock()
+
+	s.subsCounterLock.Lock()
+	s.unsubscribeFromChannel(event)
+	s.subsCounterLock.Unlock()
+	return true, nil
+}
+
+// subscribeToChannel subscribes the server to the chain's event feed needed
+// for the given event if it's not yet subscribed, subsCounterLock must be
+// held by the caller.
+func (s *Server) subscribeToChannel(event EventID) {
+	if s.subEventsStopped {
+		return
+	}
+	switch event {
+	case BlockEventID:
+		if s.blockSubs == 0 {
+			s.chain.SubscribeForBlocks(s.blockCh)
+		}
+		s.blockSubs++
+	case TransactionEventID:
+		if s.txSubs == 0 {
+			s.chain.SubscribeForTransactions(s.txCh)
+		}
+		s.txSubs++
+	case NotificationEventID, ExecutionEventID:
+		if s.executionSubs == 0 {
+			s.chain.SubscribeForExecutions(s.executionCh)
+		}
+		s.executionSubs++
+	}
+}
+
+// unsubscribeFromChannel unsubscribes the server from the chain's event feed
+// of the given event if there are no more subscribers for it,
+// subsCounterLock must be held by the caller.
+func (s *Server) unsubscribeFromChannel(event EventID) {
+	if s.subEventsStopped {
+		return
+	}
+	switch event {
+	case BlockEventID:
+		s.blockSubs--
+		if s.blockSubs == 0 {
+			s.chain.UnsubscribeFromBlocks(s.blockCh)
+		}
+	case TransactionEventID:
+		s.txSubs--
+		if s.txSubs == 0 {
+			s.chain.UnsubscribeFromTransactions(s.txCh)
+		}
+	case NotificationEventID, ExecutionEventID:
+		s.executionSubs--
+		if s.executionSubs == 0 {
+			s.chain.UnsubscribeFromExecutions(s.executionCh)
+		}
+	}
+}
+
+// handleSubEvents is a goroutine that receives events from the chain and
+// sends notifications to the subscribed clients.
+func (s *Server) handleSubEvents() {
+	b, err := json.Marshal(Notification{
+		JSONRPC: jsonRPCVersion,
+		Event:   MissedEventID,
+		Payload: make([]interface{}, 0),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("fatal: failed to marshal overflow event")
+		return
+	}
+	overflowMsg, err := websocket.NewPreparedMessage(websocket.TextMessage, b)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("fatal: failed to prepare overflow message")
+		return
+	}
+chloop:
+	for {
+		select {
+		case <-s.shutdown:
+			break chloop
+		case b := <-s.blockCh:
+			s.sendNotification(overflowMsg, BlockEventID, b, func() interface{} {
+				return wrappers.NewBlock(b, s.chain)
+			})
+		case tx := <-s.txCh:
+			s.sendNotification(overflowMsg, TransactionEventID, tx, func() interface{} {
+				return wrappers.NewTransaction(tx, s.chain)
+			})
+		case aer := <-s.executionCh:
+			s.sendNotification(overflowMsg, ExecutionEventID, aer, func() interface{} {
+				var scriptHash util.Uint160
+				if tx, _, err := s.chain.GetTransaction(aer.TxHash); err == nil {
+					if t, ok := tx.Data.(*transaction.InvocationTX); ok {
+						scriptHash = hash.Hash160(t.Script)
+					}
+				}
+				return NewApplicationLog(aer, scriptHash)
+			})
+			for i := range aer.Events {
+				ne := &aer.Events[i]
+				s.sendNotification(overflowMsg, NotificationEventID, ne, func() interface{} {
+					return NotificationEvent{
+						TxHash: aer.TxHash,
+						NotificationState: NotificationState{
+							Contract: ne.ScriptHash,
+							Item:     stackParamFromStackItem(ne.Item),
+						},
+					}
+				})
+			}
+		}
+	}
+
+	// The chain might be blocked sending an event to us, so the channels
+	// are drained while unsubscribing from them.
+	drainDone := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-s.blockCh:
+			case <-s.txCh:
+			case <-s.executionCh:
+			case <-drainDone:
+				return
+			}
+		}
+	}()
+	s.subsCounterLock.Lock()
+	if s.blockSubs > 0 {
+		s.chain.UnsubscribeFromBlocks(s.blockCh)
+	}
+	if s.txSubs > 0 {
+		s.chain.UnsubscribeFromTransactions(s.txCh)
+	}
+	if s.executionSubs > 0 {
+		s.chain.UnsubscribeFromExecutions(s.executionCh)
+	}
+	s.subEventsStopped = true
+	s.subsCounterLock.Unlock()
+	close(drainDone)
+}
+
+// sendNotification sends the notification about the given event to all
+// clients subscribed to it, the payload is only created if there are such
+// clients. Clients that don't read notifications fast enough miss some of
+// them and get the event_missed notification instead.
+func (s *Server) sendNotification(overflowMsg *websocket.PreparedMessage, event EventID, item interface{}, payload func() interface{}) {
+	var msg *websocket.PreparedMessage
+
+	s.subsLock.RLock()
+	defer s.subsLock.RUnlock()
+	for sub := range s.subscribers {
+		if sub.overflown {
+			select {
+			case sub.writer <- overflowMsg:
+				sub.overflown = false
+			default:
+				continue
+			}
+		}
+		if !sub.subscribedTo(event, item) {
+			continue
+		}
+		if msg == nil {
+			var err error
+			msg, err = newNotificationMessage(event, payload())
+			if err != nil {
+				log.WithFields(log.Fields{
+					"err":   err,
+					"event": event.String(),
+				}).Error("failed to prepare notification")
+				return
+			}
+		}
+		select {
+		case sub.writer <- msg:
+		default:
+			sub.overflown = true
+		}
+	}
+}
+
+// newNotificationMessage prepares the WebSocket message for the event with
+// the given payload.
+func newNotificationMessage(event EventID, payload interface{}) (*websocket.PreparedMessage, error) {
+	b, err := json.Marshal(Notification{
+		JSONRPC: jsonRPCVersion,
+		Event:   event,
+		Payload: []interface{}{payload},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal notification")
+	}
+	return websocket.NewPreparedMessage(websocket.TextMessage, b)
+}
@@ -1,33 +1,76 @@
 package wrappers
 
 import (
+	"fmt"
+
 	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 )
 
 type (
+	// Header wrapper used for the representation of
+	// core.Header / core.BlockBase on the RPC Server.
+	Header struct {
+		Hash          util.Uint256         `json:"hash"`
+		Size          int                  `json:"size"`
+		Version       uint32               `json:"version"`
+		PrevHash      util.Uint256         `json:"previousblockhash"`
+		MerkleRoot    util.Uint256         `json:"merkleroot"`
+		Timestamp     uint32               `json:"time"`
+		Index         uint32               `json:"index"`
+		Nonce         string               `json:"nonce"`
+		NextConsensus string               `json:"nextconsensus"`
+		Script        *transaction.Witness `json:"script"`
+		Confirmations uint32               `json:"confirmations"`
+		NextBlockHash *util.Uint256        `json:"nextblockhash,omitempty"`
+	}
+
 	// Block wrapper used for the representation of
-	// core.Block / core.BlockBase on the RPC Server.
+	// core.Block on the RPC Server.
 	Block struct {
-		*core.Block
-		Confirmations uint32       `json:"confirmations"`
-		NextBlockHash util.Uint256 `json:"nextblockhash,omitempty"`
-		Hash          util.Uint256 `json:"hash"`
+		Header
+		Tx []Transaction `json:"tx"`
 	}
 )
 
+// NewHeader creates a new Header wrapper.
+func NewHeader(header *core.Header, chain core.Blockchainer) Header {
+	return newHeader(&header.BlockBase, io.GetVarSize(header), chain)
+}
+
 // NewBlock creates a new Block wrapper.
 func NewBlock(block *core.Block, chain core.Blockchainer) Block {
 	blockWrapper := Block{
-		Block: block,
-		Hash:  block.Hash(),
+		Header: newHeader(&block.BlockBase, io.GetVarSize(block), chain),
+		Tx:     make([]Transaction, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		blockWrapper.Tx = append(blockWrapper.Tx, NewTransaction(tx, chain))
 	}
+	return blockWrapper
+}
 
-	hash := chain.GetHeaderHash(int(block.Index) + 1)
-	if !hash.Equals(util.Uint256{}) {
-		blockWrapper.NextBlockHash = hash
+func newHeader(base *core.BlockBase, size int, chain core.Blockchainer) Header {
+	header := Header{
+		Hash:          base.Hash(),
+		Size:          size,
+		Version:       base.Version,
+		PrevHash:      base.PrevHash,
+		MerkleRoot:    base.MerkleRoot,
+		Timestamp:     base.Timestamp,
+		Index:         base.Index,
+		Nonce:         fmt.Sprintf("%016x", base.ConsensusData),
+		NextConsensus: crypto.AddressFromUint160(base.NextConsensus),
+		Script:        base.Script,
+		Confirmations: chain.BlockHeight() - base.Index + 1,
 	}
 
-	blockWrapper.Confirmations = chain.BlockHeight() - block.Index - 1
-	return blockWrapper
+	hash := chain.GetHeaderHash(int(base.Index) + 1)
+	if !hash.Equals(util.Uint256{}) {
+		header.NextBlockHash = &hash
+	}
+	return header
 }
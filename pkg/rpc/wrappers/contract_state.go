@@ -0,0 +1,59 @@
+package wrappers
+
+import (
+	"encoding/hex"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/smartcontract"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+// ContractState wrapper used for the representation of
+// core.ContractState on the RPC Server.
+type ContractState struct {
+	Version     byte                      `json:"version"`
+	ScriptHash  util.Uint160              `json:"hash"`
+	Script      string                    `json:"script"`
+	ParamList   []smartcontract.ParamType `json:"parameters"`
+	ReturnType  smartcontract.ParamType   `json:"returntype"`
+	Name        string                    `json:"name"`
+	CodeVersion string                    `json:"code_version"`
+	Author      string                    `json:"author"`
+	Email       string                    `json:"email"`
+	Description string                    `json:"description"`
+	Properties  Properties                `json:"properties"`
+}
+
+// Properties response wrapper.
+type Properties struct {
+	HasStorage       bool `json:"storage"`
+	HasDynamicInvoke bool `json:"dynamic_invoke"`
+	IsPayable        bool `json:"is_payable"`
+}
+
+// NewContractState creates a new ContractState wrapper.
+func NewContractState(c *core.ContractState) ContractState {
+	// reverse scriptHash to be consistent with other client
+	scriptHash, err := util.Uint160DecodeBytes(c.ScriptHash().BytesReverse())
+	if err != nil {
+		scriptHash = c.ScriptHash()
+	}
+
+	return ContractState{
+		Version:     0,
+		ScriptHash:  scriptHash,
+		Script:      hex.EncodeToString(c.Script),
+		ParamList:   c.ParamList,
+		ReturnType:  c.ReturnType,
+		Name:        c.Name,
+		CodeVersion: c.CodeVersion,
+		Author:      c.Author,
+		Email:       c.Email,
+		Description: c.Description,
+		Properties: Properties{
+			HasStorage:       c.HasStorage(),
+			HasDynamicInvoke: c.HasDynamicInvoke(),
+			IsPayable:        c.IsPayable(),
+		},
+	}
+}
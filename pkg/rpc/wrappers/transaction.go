@@ -0,0 +1,97 @@
+package wrappers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/infinitete/neo-go-inf/pkg/core"
+	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
+	"github.com/infinitete/neo-go-inf/pkg/crypto"
+	"github.com/infinitete/neo-go-inf/pkg/io"
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
+
+type (
+	// Transaction wrapper used for the representation of
+	// transaction.Transaction on the RPC Server. Along with the
+	// common transaction fields it contains the hash, the size, the fees
+	// and type-specific data of the transaction.
+	Transaction struct {
+		*transaction.Transaction
+		TxHash util.Uint256 `json:"txid"`
+		Size   int          `json:"size"`
+		SysFee util.Fixed8  `json:"sys_fee"`
+		NetFee util.Fixed8  `json:"net_fee"`
+
+		// MinerTransaction.
+		Nonce *uint32 `json:"nonce,omitempty"`
+		// ClaimTransaction.
+		Claims []*transaction.Input `json:"claims,omitempty"`
+		// EnrollmentTransaction.
+		PublicKey string `json:"pubkey,omitempty"`
+		// RegisterTransaction.
+		Asset *RegisteredAsset `json:"asset,omitempty"`
+		// InvocationTransaction.
+		Script string       `json:"script,omitempty"`
+		Gas    *util.Fixed8 `json:"gas,omitempty"`
+	}
+
+	// RegisteredAsset represents an asset registered by the
+	// RegisterTransaction.
+	RegisteredAsset struct {
+		Type      transaction.AssetType `json:"type"`
+		Name      json.RawMessage       `json:"name"`
+		Amount    util.Fixed8           `json:"amount"`
+		Precision uint8                 `json:"precision"`
+		Owner     string                `json:"owner"`
+		Admin     string                `json:"admin"`
+	}
+)
+
+// NewTransaction creates a new Transaction wrapper.
+func NewTransaction(tx *transaction.Transaction, chain core.Blockchainer) Transaction {
+	// set index position
+	for i, o := range tx.Outputs {
+		o.Position = i
+	}
+	res := Transaction{
+		Transaction: tx,
+		TxHash:      tx.Hash(),
+		Size:        io.GetVarSize(tx),
+		SysFee:      chain.SystemFee(tx),
+		NetFee:      chain.NetworkFee(tx),
+	}
+
+	switch data := tx.Data.(type) {
+	case *transaction.MinerTX:
+		res.Nonce = &data.Nonce
+	case *transaction.ClaimTX:
+		res.Claims = data.Claims
+	case *transaction.EnrollmentTX:
+		res.PublicKey = hex.EncodeToString(data.PublicKey.Bytes())
+	case *transaction.RegisterTX:
+		res.Asset = &RegisteredAsset{
+			Type:      data.AssetType,
+			Name:      assetName(data.Name),
+			Amount:    data.Amount,
+			Precision: data.Precision,
+			Owner:     hex.EncodeToString(data.Owner.Bytes()),
+			Admin:     crypto.AddressFromUint160(data.Admin),
+		}
+	case *transaction.InvocationTX:
+		res.Script = hex.EncodeToString(data.Script)
+		res.Gas = &data.Gas
+	}
+	return res
+}
+
+// assetName returns the name of the registered asset as JSON, names are
+// usually JSON arrays of localized names, so they're passed as is, any other
+// name is represented as a string.
+func assetName(name string) json.RawMessage {
+	if json.Valid([]byte(name)) {
+		return json.RawMessage(name)
+	}
+	b, _ := json.Marshal(name)
+	return b
+}
@@ -3,18 +3,13 @@ package wrappers
 import (
 	"github.com/infinitete/neo-go-inf/pkg/core"
 	"github.com/infinitete/neo-go-inf/pkg/core/transaction"
-	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 )
 
 // TransactionOutputRaw is used as a wrapper to represents
 // a Transaction.
 type TransactionOutputRaw struct {
-	*transaction.Transaction
-	TxHash        util.Uint256 `json:"txid"`
-	Size          int          `json:"size"`
-	SysFee        util.Fixed8  `json:"sys_fee"`
-	NetFee        util.Fixed8  `json:"net_fee"`
+	Transaction
 	Blockhash     util.Uint256 `json:"blockhash"`
 	Confirmations int          `json:"confirmations"`
 	Timestamp     uint32       `json:"blocktime"`
@@ -24,16 +19,8 @@ type TransactionOutputRaw struct {
 func NewTransactionOutputRaw(tx *transaction.Transaction, header *core.Header, chain core.Blockchainer) TransactionOutputRaw {
 	// confirmations formula
 	confirmations := int(chain.BlockHeight() - header.BlockBase.Index + 1)
-	// set index position
-	for i, o := range tx.Outputs {
-		o.Position = i
-	}
 	return TransactionOutputRaw{
-		Transaction:   tx,
-		TxHash:        tx.Hash(),
-		Size:          io.GetVarSize(tx),
-		SysFee:        chain.SystemFee(tx),
-		NetFee:        chain.NetworkFee(tx),
+		Transaction:   NewTransaction(tx, chain),
 		Blockhash:     header.Hash(),
 		Confirmations: confirmations,
 		Timestamp:     header.Timestamp,
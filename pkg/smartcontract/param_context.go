@@ -1,23 +1,47 @@
 package smartcontract
 
-import "github.com/infinitete/neo-go-inf/pkg/util"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/infinitete/neo-go-inf/pkg/util"
+)
 
 // ParamType represents the Type of the contract parameter.
 type ParamType byte
 
 // A list of supported smart contract parameter types.
 const (
-	SignatureType ParamType = iota
-	BoolType
-	IntegerType
-	Hash160Type
-	Hash256Type
-	ByteArrayType
-	PublicKeyType
-	StringType
-	ArrayType
+	SignatureType        ParamType = 0x00
+	BoolType             ParamType = 0x01
+	IntegerType          ParamType = 0x02
+	Hash160Type          ParamType = 0x03
+	Hash256Type          ParamType = 0x04
+	ByteArrayType        ParamType = 0x05
+	PublicKeyType        ParamType = 0x06
+	StringType           ParamType = 0x07
+	ArrayType            ParamType = 0x10
+	MapType              ParamType = 0x12
+	InteropInterfaceType ParamType = 0xf0
+	VoidType             ParamType = 0xff
 )
 
+// paramTypes are all the parameter types known.
+var paramTypes = []ParamType{
+	SignatureType,
+	BoolType,
+	IntegerType,
+	Hash160Type,
+	Hash256Type,
+	ByteArrayType,
+	PublicKeyType,
+	StringType,
+	ArrayType,
+	MapType,
+	InteropInterfaceType,
+	VoidType,
+}
+
 // PropertyState represents contract properties (flags).
 type PropertyState byte
 
@@ -57,6 +81,12 @@ func (pt ParamType) String() string {
 		return "String"
 	case ArrayType:
 		return "Array"
+	case MapType:
+		return "Map"
+	case InteropInterfaceType:
+		return "InteropInterface"
+	case VoidType:
+		return "Void"
 	default:
 		return ""
 	}
@@ -67,6 +97,21 @@ func (pt ParamType) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + pt.String() + `"`), nil
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (pt *ParamType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, t := range paramTypes {
+		if t.String() == s {
+			*pt = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown parameter type: %s", s)
+}
+
 // NewParameter returns a Parameter with proper initialized Value
 // of the given ParamType.
 func NewParameter(t ParamType) Parameter {
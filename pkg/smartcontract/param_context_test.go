@@ -0,0 +1,29 @@
+package smartcontract
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamTypeMarshalUnmarshalJSON(t *testing.T) {
+	for _, pt := range paramTypes {
+		data, err := json.Marshal(pt)
+		require.NoError(t, err)
+
+		var actual ParamType
+		require.NoError(t, json.Unmarshal(data, &actual))
+		assert.Equal(t, pt, actual)
+	}
+
+	var pt ParamType
+	require.NoError(t, json.Unmarshal([]byte(`"Void"`), &pt))
+	assert.Equal(t, VoidType, pt)
+	require.NoError(t, json.Unmarshal([]byte(`"InteropInterface"`), &pt))
+	assert.Equal(t, InteropInterfaceType, pt)
+
+	assert.Error(t, json.Unmarshal([]byte(`"Unknown"`), &pt))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &pt))
+}
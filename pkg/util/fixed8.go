@@ -104,6 +104,20 @@ func (f *Fixed8) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UnmarshalYAML implements the yaml unmarshaler interface.
+func (f *Fixed8) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	p, err := Fixed8FromString(s)
+	if err != nil {
+		return err
+	}
+	*f = p
+	return nil
+}
+
 // MarshalJSON implements the json marshaller interface.
 func (f Fixed8) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + f.String() + `"`), nil
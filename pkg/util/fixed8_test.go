@@ -71,6 +71,30 @@ func TestSatoshi(t *testing.T) {
 	assert.Equal(t, "0.00000001", satoshif8.String())
 }
 
+func TestFixed8UnmarshalYAML(t *testing.T) {
+	var testCases = map[string]Fixed8{
+		"10":     Fixed8FromInt64(10),
+		"123.45": Fixed8(12345000000),
+	}
+
+	for str, expected := range testCases {
+		var f Fixed8
+		err := f.UnmarshalYAML(func(v interface{}) error {
+			*(v.(*string)) = str
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, f)
+	}
+
+	var f Fixed8
+	err := f.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*string)) = "not a number"
+		return nil
+	})
+	assert.NotNil(t, err)
+}
+
 func TestFixed8UnmarshalJSON(t *testing.T) {
 	var testCases = []float64{
 		123.45,
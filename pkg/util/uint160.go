@@ -25,6 +25,15 @@ func Uint160DecodeString(s string) (Uint160, error) {
 	return Uint160DecodeBytes(b)
 }
 
+// Uint160DecodeReverseString attempts to decode the given string (in LE
+// representation) into an Uint160.
+func Uint160DecodeReverseString(s string) (u Uint160, err error) {
+	if u, err = Uint160DecodeString(s); err != nil {
+		return u, err
+	}
+	return Uint160DecodeBytes(u.BytesReverse())
+}
+
 // Uint160DecodeBytes attempts to decode the given bytes into an Uint160.
 func Uint160DecodeBytes(b []byte) (u Uint160, err error) {
 	if len(b) != uint160Size {
@@ -43,6 +43,18 @@ func TestUInt160DecodeString(t *testing.T) {
 	assert.Equal(t, hexStr, val.String())
 }
 
+func TestUInt160DecodeReverseString(t *testing.T) {
+	hexStr := "2d3b96ae1bcc5a585e075e3b81920210dec16302"
+	val, err := Uint160DecodeReverseString(hexStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hexStr, val.ReverseString())
+
+	_, err = Uint160DecodeReverseString(hexStr[2:])
+	assert.Error(t, err)
+}
+
 func TestUint160DecodeBytes(t *testing.T) {
 	hexStr := "2d3b96ae1bcc5a585e075e3b81920210dec16302"
 	b, err := hex.DecodeString(hexStr)
@@ -96,3 +96,16 @@ func IsSignatureContract(script []byte) bool {
 func IsStandardContract(script []byte) bool {
 	return IsSignatureContract(script) || IsMultiSigContract(script)
 }
+
+// IsPushOnly checks whether the passed script only pushes data to the stack,
+// invocation scripts of witnesses have to be push-only.
+func IsPushOnly(script []byte) bool {
+	ctx := NewContext(script)
+	for ctx.nextip < len(script) {
+		instr, _, err := ctx.Next()
+		if err != nil || instr > PUSH16 {
+			return false
+		}
+	}
+	return true
+}
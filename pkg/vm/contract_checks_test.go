@@ -225,3 +225,19 @@ func TestIsMultiSigContractBadRead3(t *testing.T) {
 	prog[70] = byte(PUSHBYTES1)
 	assert.Equal(t, false, IsMultiSigContract(prog))
 }
+
+func TestIsPushOnlyGood(t *testing.T) {
+	prog := []byte{byte(PUSH0), byte(PUSHBYTES2), 1, 2, byte(PUSHDATA1), 1, 3, byte(PUSHM1), byte(PUSH16)}
+	assert.Equal(t, true, IsPushOnly(prog))
+	assert.Equal(t, true, IsPushOnly([]byte{}))
+}
+
+func TestIsPushOnlyBadInstr(t *testing.T) {
+	prog := []byte{byte(PUSH1), byte(SYSCALL), 1, 'a'}
+	assert.Equal(t, false, IsPushOnly(prog))
+}
+
+func TestIsPushOnlyBadRead(t *testing.T) {
+	prog := []byte{byte(PUSHBYTES2), 1}
+	assert.Equal(t, false, IsPushOnly(prog))
+}
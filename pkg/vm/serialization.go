@@ -1,7 +1,9 @@
 package vm
 
 import (
+	"bytes"
 	"errors"
+	gio "io"
 	"math/big"
 
 	"github.com/infinitete/neo-go-inf/pkg/io"
@@ -17,23 +19,86 @@ const (
 	arrayT     stackItemType = 0x80
 	structT    stackItemType = 0x81
 	mapT       stackItemType = 0x82
+
+	// interopT is only used when persisting VM results, interop items can't
+	// be serialized from contracts.
+	interopT stackItemType = 0xf0
 )
 
-func serializeItem(item StackItem) ([]byte, error) {
-	w := io.NewBufBinWriter()
-	serializeItemTo(item, w.BinWriter, make(map[StackItem]bool))
+var errItemTooBig = errors.New("serialized item is too big")
+
+// limitedWriter is a writer failing once more than n bytes are written to it,
+// so that serialization of huge items (like the ones referencing the same
+// item many times) stops early.
+type limitedWriter struct {
+	w gio.Writer
+	n int
+}
+
+// Write implements the io.Writer interface.
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > lw.n {
+		return 0, errItemTooBig
+	}
+	lw.n -= len(p)
+	return lw.w.Write(p)
+}
+
+// serializeLimited serializes the item failing if the result exceeds
+// MaxItemSize.
+func serializeLimited(item StackItem, allowInterop bool) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := io.NewBinWriterFromIO(&limitedWriter{w: buf, n: MaxItemSize})
+	serializeItemTo(item, w, make(map[StackItem]bool), allowInterop)
 	if w.Err != nil {
 		return nil, w.Err
 	}
-	return w.Bytes(), nil
+	return buf.Bytes(), nil
+}
+
+func serializeItem(item StackItem) ([]byte, error) {
+	return serializeLimited(item, false)
 }
 
-func serializeItemTo(item StackItem, w *io.BinWriter, seen map[StackItem]bool) {
+// EncodeBinaryStackItem encodes given StackItem into the given BinWriter. It's
+// the same format Runtime.Serialize uses except that interop items are allowed
+// (and encoded without their values), so it can be used to store any VM
+// execution result. Recursive references can't be stored either, so they're
+// replaced with (empty) interop items, items referenced several times without
+// making a cycle are stored as is. The encoded item can't exceed MaxItemSize,
+// an error is set on the writer if it does.
+func EncodeBinaryStackItem(item StackItem, w *io.BinWriter) {
+	if w.Err != nil {
+		return
+	}
+	data, err := serializeLimited(item, true)
+	if err != nil {
+		w.Err = err
+		return
+	}
+	w.WriteLE(data)
+}
+
+func serializeItemTo(item StackItem, w *io.BinWriter, seen map[StackItem]bool, allowInterop bool) {
+	if w.Err != nil {
+		return
+	}
 	if seen[item] {
+		if allowInterop {
+			w.WriteLE(byte(interopT))
+			return
+		}
 		w.Err = errors.New("recursive structures are not supported")
 		return
 	}
 	seen[item] = true
+	if allowInterop {
+		// Only the items being serialized are checked for when
+		// storing results, so that shared (not recursive) items
+		// are stored fully. Runtime.Serialize rejects any repeated
+		// reference just like the reference implementation does.
+		defer delete(seen, item)
+	}
 
 	switch t := item.(type) {
 	case *ByteArrayItem:
@@ -46,7 +111,11 @@ func serializeItemTo(item StackItem, w *io.BinWriter, seen map[StackItem]bool) {
 		w.WriteLE(byte(integerT))
 		w.WriteBytes(t.Bytes())
 	case *InteropItem:
-		w.Err = errors.New("not supported")
+		if !allowInterop {
+			w.Err = errors.New("not supported")
+			return
+		}
+		w.WriteLE(byte(interopT))
 	case *ArrayItem, *StructItem:
 		_, isArray := t.(*ArrayItem)
 		if isArray {
@@ -58,28 +127,34 @@ func serializeItemTo(item StackItem, w *io.BinWriter, seen map[StackItem]bool) {
 		arr := t.Value().([]StackItem)
 		w.WriteVarUint(uint64(len(arr)))
 		for i := range arr {
-			serializeItemTo(arr[i], w, seen)
+			serializeItemTo(arr[i], w, seen, allowInterop)
 		}
 	case *MapItem:
 		w.WriteLE(byte(mapT))
 		w.WriteVarUint(uint64(len(t.value)))
 		for k, v := range t.value {
-			serializeItemTo(v, w, seen)
-			serializeItemTo(makeStackItem(k), w, seen)
+			serializeItemTo(v, w, seen, allowInterop)
+			serializeItemTo(makeStackItem(k), w, seen, allowInterop)
 		}
 	}
 }
 
 func deserializeItem(data []byte) (StackItem, error) {
 	r := io.NewBinReaderFromBuf(data)
-	item := deserializeItemFrom(r)
+	item := deserializeItemFrom(r, false)
 	if r.Err != nil {
 		return nil, r.Err
 	}
 	return item, nil
 }
 
-func deserializeItemFrom(r *io.BinReader) StackItem {
+// DecodeBinaryStackItem decodes StackItem encoded with EncodeBinaryStackItem
+// from the given BinReader. Interop items are decoded with nil values.
+func DecodeBinaryStackItem(r *io.BinReader) StackItem {
+	return deserializeItemFrom(r, true)
+}
+
+func deserializeItemFrom(r *io.BinReader, allowInterop bool) StackItem {
 	var t byte
 	r.ReadLE(&t)
 	if r.Err != nil {
@@ -104,7 +179,7 @@ func deserializeItemFrom(r *io.BinReader) StackItem {
 		size := int(r.ReadVarUint())
 		arr := make([]StackItem, size)
 		for i := 0; i < size; i++ {
-			arr[i] = deserializeItemFrom(r)
+			arr[i] = deserializeItemFrom(r, allowInterop)
 		}
 
 		if stackItemType(t) == arrayT {
@@ -115,14 +190,19 @@ func deserializeItemFrom(r *io.BinReader) StackItem {
 		size := int(r.ReadVarUint())
 		m := NewMapItem()
 		for i := 0; i < size; i++ {
-			value := deserializeItemFrom(r)
-			key := deserializeItemFrom(r)
+			value := deserializeItemFrom(r, allowInterop)
+			key := deserializeItemFrom(r, allowInterop)
 			if r.Err != nil {
 				break
 			}
 			m.Add(key, value)
 		}
 		return m
+	case interopT:
+		if allowInterop {
+			return NewInteropItem(nil)
+		}
+		fallthrough
 	default:
 		r.Err = errors.New("unknown type")
 		return nil
@@ -64,6 +64,11 @@ func (e *Element) Value() interface{} {
 	return e.value.Value()
 }
 
+// Item returns StackItem contained in the element.
+func (e *Element) Item() StackItem {
+	return e.value
+}
+
 // BigInt attempts to get the underlying value of the element as a big integer.
 // Will panic if the assertion failed which will be caught by the VM.
 func (e *Element) BigInt() *big.Int {
@@ -391,6 +396,15 @@ func (s *Stack) popSigElements() ([][]byte, error) {
 	return elems, nil
 }
 
+// ToArray converts stack to an array of stackitems with top item being the last.
+func (s *Stack) ToArray() []StackItem {
+	items := make([]StackItem, 0, s.len)
+	for e := s.Back(); e != nil; e = e.Prev() {
+		items = append(items, e.Item())
+	}
+	return items
+}
+
 // MarshalJSON implements JSON marshalling interface.
 func (s *Stack) MarshalJSON() ([]byte, error) {
 	return json.Marshal(stackToArray(s))
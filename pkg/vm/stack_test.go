@@ -255,6 +255,20 @@ func TestPopSigElements(t *testing.T) {
 	assert.Equal(t, z, [][]byte{b1, b2})
 }
 
+func TestToArray(t *testing.T) {
+	s := NewStack("test")
+	items := []StackItem{
+		makeStackItem(1),
+		makeStackItem([]byte{2}),
+		makeStackItem(true),
+	}
+	for _, item := range items {
+		s.Push(&Element{value: item})
+	}
+	assert.Equal(t, items, s.ToArray())
+	assert.Equal(t, 3, s.Len())
+}
+
 func makeElements(n int) []*Element {
 	elems := make([]*Element, n)
 	for i := 0; i < n; i++ {
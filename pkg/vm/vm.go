@@ -65,6 +65,14 @@ type VM struct {
 	// callback to get scripts.
 	getScript func(util.Uint160) []byte
 
+	// callback to get the price of the instruction being executed.
+	getPrice func(*VM, Instruction, []byte) util.Fixed8
+
+	// gasConsumed is the amount of GAS consumed so far, gasLimit is the
+	// maximum amount allowed (0 means no limit).
+	gasConsumed util.Fixed8
+	gasLimit    util.Fixed8
+
 	istack *Stack // invocation stack.
 	estack *Stack // execution stack.
 	astack *Stack // alt stack.
@@ -120,6 +128,15 @@ func (v *VM) RegisterInteropFunc(name string, f InteropFunc, price int) {
 	v.interop[name] = InteropFuncPrice{f, price}
 }
 
+// GetInterop returns the interop function registered with the given name or
+// nil if there is no such function.
+func (v *VM) GetInterop(name string) *InteropFuncPrice {
+	if ifunc, ok := v.interop[name]; ok {
+		return &ifunc
+	}
+	return nil
+}
+
 // RegisterInteropFuncs registers all interop functions passed in a map in
 // the VM. Effectively it's a batched version of RegisterInteropFunc.
 func (v *VM) RegisterInteropFuncs(interops map[string]InteropFuncPrice) {
@@ -229,6 +246,7 @@ func (v *VM) Load(prog []byte) {
 	v.estack.Clear()
 	v.astack.Clear()
 	v.state = noneState
+	v.gasConsumed = 0
 	v.LoadScript(prog)
 }
 
@@ -446,6 +464,23 @@ func (v *VM) SetScriptGetter(gs func(util.Uint160) []byte) {
 	v.getScript = gs
 }
 
+// SetPriceGetter sets the function used to calculate the price of every
+// executed instruction. If it's not set no GAS is consumed at all.
+func (v *VM) SetPriceGetter(f func(*VM, Instruction, []byte) util.Fixed8) {
+	v.getPrice = f
+}
+
+// SetGasLimit sets the maximum amount of GAS that can be consumed by the VM,
+// 0 means no limit.
+func (v *VM) SetGasLimit(max util.Fixed8) {
+	v.gasLimit = max
+}
+
+// GasConsumed returns the amount of GAS consumed during execution.
+func (v *VM) GasConsumed() util.Fixed8 {
+	return v.gasConsumed
+}
+
 // execute performs an instruction cycle in the VM. Acting on the instruction (opcode).
 func (v *VM) execute(ctx *Context, op Instruction, parameter []byte) (err error) {
 	// Instead of polluting the whole VM logic with error handling, we will recover
@@ -460,6 +495,14 @@ func (v *VM) execute(ctx *Context, op Instruction, parameter []byte) (err error)
 		}
 	}()
 
+	// Implicit RET at the end of the script is free.
+	if v.getPrice != nil && ctx.ip < len(ctx.prog) {
+		v.gasConsumed += v.getPrice(v, op, parameter)
+		if v.gasLimit > 0 && v.gasConsumed > v.gasLimit {
+			panic("gas limit is exceeded")
+		}
+	}
+
 	if op >= PUSHBYTES1 && op <= PUSHBYTES75 {
 		v.estack.PushVal(parameter)
 		return
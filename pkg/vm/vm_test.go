@@ -10,6 +10,7 @@ import (
 
 	"github.com/infinitete/neo-go-inf/pkg/crypto/hash"
 	"github.com/infinitete/neo-go-inf/pkg/crypto/keys"
+	"github.com/infinitete/neo-go-inf/pkg/io"
 	"github.com/infinitete/neo-go-inf/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -72,6 +73,54 @@ func TestPushBytesNoParam(t *testing.T) {
 	checkVMFailed(t, vm)
 }
 
+func fooPriceGetter(v *VM, op Instruction, p []byte) util.Fixed8 {
+	if op == ADD {
+		return 2
+	}
+	return 1
+}
+
+func TestGasConsumption(t *testing.T) {
+	prog := makeProgram(PUSH1, PUSH2, ADD)
+
+	t.Run("NoPriceGetter", func(t *testing.T) {
+		v := load(prog)
+		runVM(t, v)
+		require.EqualValues(t, 0, v.GasConsumed())
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		v := load(prog)
+		v.SetPriceGetter(fooPriceGetter)
+		runVM(t, v)
+		// The final RET is explicit in makeProgram, so it's paid for.
+		require.EqualValues(t, 5, v.GasConsumed())
+	})
+
+	t.Run("EnoughGas", func(t *testing.T) {
+		v := load(prog)
+		v.SetPriceGetter(fooPriceGetter)
+		v.SetGasLimit(5)
+		runVM(t, v)
+		require.EqualValues(t, 5, v.GasConsumed())
+	})
+
+	t.Run("NotEnoughGas", func(t *testing.T) {
+		v := load(prog)
+		v.SetPriceGetter(fooPriceGetter)
+		v.SetGasLimit(3)
+		checkVMFailed(t, v)
+		require.EqualValues(t, 4, v.GasConsumed())
+	})
+
+	t.Run("ImplicitRET", func(t *testing.T) {
+		v := load([]byte{byte(PUSH1)})
+		v.SetPriceGetter(fooPriceGetter)
+		runVM(t, v)
+		require.EqualValues(t, 1, v.GasConsumed())
+	})
+}
+
 func runVM(t *testing.T, vm *VM) {
 	err := vm.Run()
 	require.NoError(t, err)
@@ -422,6 +471,78 @@ func TestSerializeMap(t *testing.T) {
 	require.Equal(t, item.value, vm.estack.Top().value.(*MapItem).value)
 }
 
+func TestEncodeDecodeBinaryStackItem(t *testing.T) {
+	item := NewArrayItem([]StackItem{
+		makeStackItem([]byte{1, 2, 3}),
+		makeStackItem(123),
+		NewInteropItem(42),
+	})
+
+	w := io.NewBufBinWriter()
+	EncodeBinaryStackItem(item, w.BinWriter)
+	require.NoError(t, w.Err)
+
+	r := io.NewBinReaderFromBuf(w.Bytes())
+	actual := DecodeBinaryStackItem(r)
+	require.NoError(t, r.Err)
+	require.IsType(t, (*ArrayItem)(nil), actual)
+	arr := actual.Value().([]StackItem)
+	require.Equal(t, 3, len(arr))
+	require.Equal(t, item.value[:2], arr[:2])
+	require.IsType(t, (*InteropItem)(nil), arr[2])
+	require.Nil(t, arr[2].Value())
+
+	// Contracts still can't deserialize interop items.
+	_, err := deserializeItem(w.Bytes())
+	require.Error(t, err)
+
+	// Recursive references are replaced with interop items.
+	item.value = append(item.value, item)
+	w = io.NewBufBinWriter()
+	EncodeBinaryStackItem(item, w.BinWriter)
+	require.NoError(t, w.Err)
+
+	r = io.NewBinReaderFromBuf(w.Bytes())
+	actual = DecodeBinaryStackItem(r)
+	require.NoError(t, r.Err)
+	arr = actual.Value().([]StackItem)
+	require.Equal(t, 4, len(arr))
+	require.IsType(t, (*InteropItem)(nil), arr[3])
+
+	// Shared items are not recursive, so they're stored fully.
+	shared := NewArrayItem([]StackItem{makeStackItem(1)})
+	bytes := makeStackItem([]byte{1, 2, 3})
+	item = NewArrayItem([]StackItem{shared, shared, bytes, bytes})
+	w = io.NewBufBinWriter()
+	EncodeBinaryStackItem(item, w.BinWriter)
+	require.NoError(t, w.Err)
+
+	r = io.NewBinReaderFromBuf(w.Bytes())
+	actual = DecodeBinaryStackItem(r)
+	require.NoError(t, r.Err)
+	arr = actual.Value().([]StackItem)
+	require.Equal(t, 4, len(arr))
+	for i := range arr {
+		require.Equal(t, item.value[i], arr[i])
+	}
+}
+
+func TestSerializeSharedItemsLimit(t *testing.T) {
+	// Every array references the previous one twice, so the serialized
+	// item grows exponentially.
+	item := makeStackItem([]byte{1, 2, 3})
+	for i := 0; i < 64; i++ {
+		item = NewArrayItem([]StackItem{item, item})
+	}
+
+	w := io.NewBufBinWriter()
+	EncodeBinaryStackItem(item, w.BinWriter)
+	require.Equal(t, errItemTooBig, w.Err)
+
+	_, err := serializeItem(item)
+	require.Error(t, err)
+}
+
 func TestSerializeInterop(t *testing.T) {
 	vm := load(getSerializeProg())
 	item := NewInteropItem("kek")
@@ -81,6 +81,12 @@ func (a *Account) Encrypt(passphrase string) error {
 	return nil
 }
 
+// PrivateKey returns the private key of the account, it's nil for
+// accounts that are not decrypted.
+func (a *Account) PrivateKey() *keys.PrivateKey {
+	return a.privateKey
+}
+
 // NewAccountFromWIF creates a new Account from the given WIF.
 func NewAccountFromWIF(wif string) (*Account, error) {
 	privKey, err := keys.NewPrivateKeyFromWIF(wif)